@@ -12,6 +12,7 @@ import (
 
 	"github.com/RedDuck-Software/poolsea-go/minipool"
 	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/settings/protocol"
 	"github.com/RedDuck-Software/poolsea-go/storage"
 	rptypes "github.com/RedDuck-Software/poolsea-go/types"
 )
@@ -288,6 +289,82 @@ func GetQueueMinipoolAtPosition(rp *rocketpool.RocketPool, position uint64, opts
 	return getMinipool("minipools.available.empty")
 }
 
+// Get the minipools in the legacy queues in assignment order, with the user ETH each one needs
+func GetQueueEntries(rp *rocketpool.RocketPool, opts *bind.CallOpts, legacyRocketMinipoolQueueAddress *common.Address) ([]minipool.QueueEntry, error) {
+
+	// The queues in the order they're cleared
+	queues := []struct {
+		depositType   rptypes.MinipoolDeposit
+		key           string
+		getUserAmount func(*rocketpool.RocketPool, *bind.CallOpts) (*big.Int, error)
+	}{
+		{rptypes.Half, "minipools.available.half", protocol.GetMinipoolHalfDepositUserAmount},
+		{rptypes.Full, "minipools.available.full", protocol.GetMinipoolFullDepositUserAmount},
+		{rptypes.Empty, "minipools.available.empty", protocol.GetMinipoolEmptyDepositUserAmount},
+	}
+
+	entries := []minipool.QueueEntry{}
+	for _, queue := range queues {
+
+		// Get the queue length and user deposit amount
+		length, err := GetQueueLength(rp, queue.depositType, opts, legacyRocketMinipoolQueueAddress)
+		if err != nil {
+			return nil, fmt.Errorf("Could not get queue length of type %s: %w", queue.depositType, err)
+		}
+		if length == 0 {
+			continue
+		}
+		userAmount, err := queue.getUserAmount(rp, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Load the queued addresses in batches
+		addresses := make([]common.Address, length)
+		key := crypto.Keccak256Hash([]byte(queue.key))
+		for bsi := uint64(0); bsi < length; bsi += minipool.MinipoolAddressBatchSize {
+
+			// Get batch start & end index
+			msi := bsi
+			mei := bsi + minipool.MinipoolAddressBatchSize
+			if mei > length {
+				mei = length
+			}
+
+			// Load addresses
+			var wg errgroup.Group
+			for mi := msi; mi < mei; mi++ {
+				mi := mi
+				wg.Go(func() error {
+					address, err := storage.GetAddressQueueItem(rp, opts, key, big.NewInt(int64(mi)))
+					if err != nil {
+						return fmt.Errorf("Could not get address in queue at position %d: %w", mi, err)
+					}
+					addresses[mi] = address
+					return nil
+				})
+			}
+			if err := wg.Wait(); err != nil {
+				return nil, err
+			}
+
+		}
+
+		for _, address := range addresses {
+			entries = append(entries, minipool.QueueEntry{
+				MinipoolAddress:   address,
+				DepositType:       queue.depositType,
+				UserDepositAmount: userAmount,
+			})
+		}
+
+	}
+
+	// Return
+	return entries, nil
+
+}
+
 // Get contracts
 var rocketMinipoolQueueLock sync.Mutex
 
@@ -0,0 +1,467 @@
+package minipool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/node"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	rptypes "github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+)
+
+// The distributable balance at which v3 minipools treat a distribution as a full withdrawal and finalise
+var FullWithdrawalThreshold = eth.EthToWei(8)
+
+// A transaction in a minipool exit workflow
+type ExitStepType string
+
+const (
+	ExitStepRefund                       ExitStepType = "refund"
+	ExitStepDistributeBalance            ExitStepType = "distributeBalance"
+	ExitStepDistributeBalanceAndFinalise ExitStepType = "distributeBalanceAndFinalise"
+	ExitStepFinalise                     ExitStepType = "finalise"
+	ExitStepClose                        ExitStepType = "close"
+	ExitStepDistributeFees               ExitStepType = "distributeFees"
+)
+
+// The minipool and fee distributor details an exit workflow is planned from
+type ExitWorkflowState struct {
+	MinipoolAddress    common.Address         `json:"minipoolAddress"`
+	Version            uint8                  `json:"version"`
+	Status             rptypes.MinipoolStatus `json:"status"`
+	Finalised          bool                   `json:"finalised"`
+	UserDistributed    bool                   `json:"userDistributed"`
+	Balance            *big.Int               `json:"balance"`
+	NodeRefundBalance  *big.Int               `json:"nodeRefundBalance"`
+	DistributorAddress common.Address         `json:"distributorAddress"` // Empty if the fee distributor isn't part of the workflow
+	DistributorBalance *big.Int               `json:"distributorBalance"`
+}
+
+// A single transaction in an exit workflow
+type ExitStep struct {
+	Type        ExitStepType       `json:"type"`
+	Target      common.Address     `json:"target"`
+	RewardsOnly bool               `json:"rewardsOnly"`
+	GasInfo     rocketpool.GasInfo `json:"gasInfo"`         // Estimated when the workflow is planned, and again when the step is sent since the earlier steps can change it
+	NodePayout  *big.Int           `json:"nodePayout"`      // The ETH the node operator is expected to receive
+	UserPayout  *big.Int           `json:"userPayout"`      // The ETH the staking pool is expected to receive
+	TxHash      common.Hash        `json:"txHash"`          // Set once the transaction has been sent, and cleared if it's dropped
+	Nonce       *uint64            `json:"nonce,omitempty"` // Set once the transaction has been sent; a dropped transaction is sent again with the same nonce
+	Done        bool               `json:"done"`            // Set once the transaction has been mined
+	Failed      bool               `json:"failed"`          // Set if the transaction was mined but failed
+}
+
+// An ordered plan of the transactions that recover a minipool's funds
+type ExitWorkflow struct {
+	MinipoolAddress common.Address `json:"minipoolAddress"`
+	Version         uint8          `json:"version"`
+	Steps           []*ExitStep    `json:"steps"`
+	Complete        bool           `json:"complete"`      // True if the steps recover all of the minipool's funds
+	WaitingReason   string         `json:"waitingReason"` // Why the minipool can't be fully exited yet
+}
+
+// Get the details of a minipool and its node's fee distributor needed to plan an exit
+func GetExitWorkflowState(rp *rocketpool.RocketPool, mp Minipool, includeFeeDistributor bool, opts *bind.CallOpts) (ExitWorkflowState, error) {
+	state := ExitWorkflowState{
+		MinipoolAddress: mp.GetAddress(),
+		Version:         mp.GetVersion(),
+	}
+	ctx := rocketpool.GetCallContext(opts)
+	var blockNumber *big.Int
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+	}
+
+	statusDetails, err := mp.GetStatusDetails(opts)
+	if err != nil {
+		return ExitWorkflowState{}, err
+	}
+	state.Status = statusDetails.Status
+	if state.Finalised, err = mp.GetFinalised(opts); err != nil {
+		return ExitWorkflowState{}, err
+	}
+	if mpv3, ok := GetMinipoolAsV3(mp); ok {
+		if state.UserDistributed, err = mpv3.GetUserDistributed(opts); err != nil {
+			return ExitWorkflowState{}, err
+		}
+	}
+	if state.NodeRefundBalance, err = mp.GetNodeRefundBalance(opts); err != nil {
+		return ExitWorkflowState{}, err
+	}
+	if state.Balance, err = rp.Client.BalanceAt(ctx, state.MinipoolAddress, blockNumber); err != nil {
+		return ExitWorkflowState{}, fmt.Errorf("Could not get minipool %s balance: %w", state.MinipoolAddress.Hex(), err)
+	}
+
+	state.DistributorBalance = big.NewInt(0)
+	if includeFeeDistributor {
+		nodeAddress, err := mp.GetNodeAddress(opts)
+		if err != nil {
+			return ExitWorkflowState{}, err
+		}
+		if state.DistributorAddress, err = node.GetDistributorAddress(rp, nodeAddress, opts); err != nil {
+			return ExitWorkflowState{}, err
+		}
+		if state.DistributorBalance, err = rp.Client.BalanceAt(ctx, state.DistributorAddress, blockNumber); err != nil {
+			return ExitWorkflowState{}, fmt.Errorf("Could not get fee distributor %s balance: %w", state.DistributorAddress.Hex(), err)
+		}
+	}
+
+	return state, nil
+}
+
+// Plan the transactions that recover a minipool's funds from its current state, without payouts or gas estimates
+func PlanExitSteps(state ExitWorkflowState) ExitWorkflow {
+	workflow := ExitWorkflow{
+		MinipoolAddress: state.MinipoolAddress,
+		Version:         state.Version,
+		Steps:           []*ExitStep{},
+		Complete:        true,
+	}
+	addStep := func(stepType ExitStepType, target common.Address, rewardsOnly bool) {
+		workflow.Steps = append(workflow.Steps, &ExitStep{
+			Type:        stepType,
+			Target:      target,
+			RewardsOnly: rewardsOnly,
+		})
+	}
+	waitFor := func(reason string, args ...interface{}) {
+		workflow.Complete = false
+		workflow.WaitingReason = fmt.Sprintf(reason, args...)
+	}
+
+	balance := bigOrZero(state.Balance)
+	refund := bigOrZero(state.NodeRefundBalance)
+	distributable := big.NewInt(0).Sub(balance, refund)
+
+	// Recover the minipool's balance
+	switch {
+	case state.Status == rptypes.Dissolved:
+		addStep(ExitStepClose, state.MinipoolAddress, false)
+
+	case state.Finalised:
+		// Nothing left in the minipool
+
+	case state.Version >= 3:
+		if state.Status != rptypes.Staking {
+			waitFor("the minipool is %s, not Staking", state.Status)
+			break
+		}
+		if state.UserDistributed {
+			// A user distributed the balance, so the node's share is waiting in the minipool
+			addStep(ExitStepFinalise, state.MinipoolAddress, false)
+			break
+		}
+		if distributable.Cmp(FullWithdrawalThreshold) >= 0 {
+			// Distributing a full withdrawal finalises the minipool
+			addStep(ExitStepDistributeBalance, state.MinipoolAddress, false)
+			break
+		}
+		if distributable.Sign() > 0 {
+			addStep(ExitStepDistributeBalance, state.MinipoolAddress, true)
+		} else if refund.Sign() > 0 && distributable.Sign() == 0 {
+			addStep(ExitStepRefund, state.MinipoolAddress, false)
+		}
+		waitFor("the validator's balance hasn't been withdrawn to the minipool yet")
+
+	default:
+		if state.Status == rptypes.Withdrawable {
+			addStep(ExitStepDistributeBalanceAndFinalise, state.MinipoolAddress, false)
+			break
+		}
+		if state.Status == rptypes.Staking && refund.Sign() > 0 && distributable.Sign() >= 0 {
+			addStep(ExitStepRefund, state.MinipoolAddress, false)
+		}
+		waitFor("the minipool is %s, not Withdrawable", state.Status)
+	}
+
+	// Recover the node's share of the fee distributor
+	if state.DistributorAddress != (common.Address{}) && bigOrZero(state.DistributorBalance).Sign() > 0 {
+		addStep(ExitStepDistributeFees, state.DistributorAddress, false)
+	}
+
+	return workflow
+}
+
+// Create a plan of the transactions that recover a minipool's funds, with gas estimates and the expected payout of each
+func NewExitWorkflow(rp *rocketpool.RocketPool, mp Minipool, includeFeeDistributor bool, opts *bind.TransactOpts) (*ExitWorkflow, error) {
+	callOpts := &bind.CallOpts{}
+	if opts != nil {
+		callOpts.Context = opts.Context
+	}
+	state, err := GetExitWorkflowState(rp, mp, includeFeeDistributor, callOpts)
+	if err != nil {
+		return nil, err
+	}
+	workflow := PlanExitSteps(state)
+
+	for i, step := range workflow.Steps {
+		// Get the expected payout
+		switch step.Type {
+		case ExitStepRefund:
+			step.NodePayout = big.NewInt(0).Set(state.NodeRefundBalance)
+			step.UserPayout = big.NewInt(0)
+
+		case ExitStepDistributeBalance, ExitStepDistributeBalanceAndFinalise:
+			distributable := big.NewInt(0).Sub(state.Balance, state.NodeRefundBalance)
+			nodeShare, err := mp.CalculateNodeShare(distributable, callOpts)
+			if err != nil {
+				return nil, err
+			}
+			userShare, err := mp.CalculateUserShare(distributable, callOpts)
+			if err != nil {
+				return nil, err
+			}
+			step.NodePayout = nodeShare.Add(nodeShare, state.NodeRefundBalance)
+			step.UserPayout = userShare
+
+		case ExitStepFinalise, ExitStepClose:
+			step.NodePayout = big.NewInt(0).Set(state.Balance)
+			step.UserPayout = big.NewInt(0)
+
+		case ExitStepDistributeFees:
+			distributor, err := node.NewDistributor(rp, step.Target, callOpts)
+			if err != nil {
+				return nil, err
+			}
+			if step.NodePayout, err = distributor.GetNodeShare(callOpts); err != nil {
+				return nil, err
+			}
+			if step.UserPayout, err = distributor.GetUserShare(callOpts); err != nil {
+				return nil, err
+			}
+		}
+
+		// Estimate the gas; the later steps run against the state the earlier ones leave behind, so their estimates are
+		// provisional, left empty if they can't be made yet, and redone when the steps are sent
+		gasInfo, err := estimateExitStepGas(rp, mp, step, opts)
+		if err == nil {
+			step.GasInfo = gasInfo
+		} else if i == 0 {
+			return nil, fmt.Errorf("Could not estimate the gas of minipool %s exit step %s: %w", workflow.MinipoolAddress.Hex(), step.Type, err)
+		}
+	}
+
+	return &workflow, nil
+}
+
+// Get the total payouts of the steps
+func (w *ExitWorkflow) GetTotalPayouts() (*big.Int, *big.Int) {
+	nodeTotal := big.NewInt(0)
+	userTotal := big.NewInt(0)
+	for _, step := range w.Steps {
+		nodeTotal.Add(nodeTotal, bigOrZero(step.NodePayout))
+		userTotal.Add(userTotal, bigOrZero(step.UserPayout))
+	}
+	return nodeTotal, userTotal
+}
+
+// Check if every step has been mined
+func (w *ExitWorkflow) IsDone() bool {
+	for _, step := range w.Steps {
+		if !step.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// Run the workflow's remaining steps in order, waiting for each transaction to be mined before sending the next
+func (w *ExitWorkflow) Execute(rp *rocketpool.RocketPool, opts *bind.TransactOpts, progress func(*ExitWorkflow) error) error {
+	return w.ExecuteContext(context.Background(), rp, opts, progress)
+}
+
+// Run the workflow's remaining steps in order, waiting for each transaction to be mined before sending the next and stopping early if the provided context is cancelled.
+// The progress callback (if provided) is called whenever a step's transaction is sent, dropped, mined or fails, so the workflow can be saved and resumed by calling this again if it's interrupted.
+// Steps that were sent but not mined are waited on instead of being sent again. If their transaction was dropped, it's sent again with the same nonce so only one of them can be mined;
+// if another transaction used the nonce instead, or a step's transaction failed, the workflow stops and has to be planned again from the minipool's current state.
+// Each step's gas is estimated again before it's sent, as the earlier steps change the state it runs against.
+func (w *ExitWorkflow) ExecuteContext(ctx context.Context, rp *rocketpool.RocketPool, opts *bind.TransactOpts, progress func(*ExitWorkflow) error) error {
+	if opts == nil {
+		return fmt.Errorf("Could not execute minipool %s exit workflow: no transactor was provided", w.MinipoolAddress.Hex())
+	}
+	report := func() error {
+		if progress == nil {
+			return nil
+		}
+		return progress(w)
+	}
+
+	var mp Minipool
+	for _, step := range w.Steps {
+		if step.Done {
+			continue
+		}
+		if step.Failed {
+			return fmt.Errorf("Could not complete minipool %s exit step %s: transaction %s failed, so the workflow needs to be planned again", w.MinipoolAddress.Hex(), step.Type, step.TxHash.Hex())
+		}
+
+		for !step.Done {
+
+			// Send the transaction if it wasn't sent before the workflow was interrupted, or send it again with the same nonce if it was dropped
+			if step.TxHash == (common.Hash{}) {
+				if mp == nil {
+					var err error
+					mp, err = NewMinipoolFromVersion(rp, w.MinipoolAddress, w.Version, &bind.CallOpts{Context: ctx})
+					if err != nil {
+						return err
+					}
+				}
+				hash, nonce, err := w.sendStep(ctx, rp, mp, step, opts)
+				if err != nil {
+					return err
+				}
+				step.TxHash = hash
+				step.Nonce = &nonce
+				if err := report(); err != nil {
+					return err
+				}
+			}
+
+			// Wait for it to be mined
+			receipt, err := utils.WaitForTransactionContext(ctx, rp.Client, step.TxHash)
+			if err == nil {
+				step.Done = true
+				if err := report(); err != nil {
+					return err
+				}
+				break
+			}
+			if receipt != nil && receipt.Status == types.ReceiptStatusFailed {
+				step.Failed = true
+				if err := report(); err != nil {
+					return err
+				}
+				return fmt.Errorf("Could not complete minipool %s exit step %s: transaction %s failed", w.MinipoolAddress.Hex(), step.Type, step.TxHash.Hex())
+			}
+			if !errors.Is(err, utils.ErrTransactionNotFound) {
+				return fmt.Errorf("Could not complete minipool %s exit step %s (transaction %s): %w", w.MinipoolAddress.Hex(), step.Type, step.TxHash.Hex(), err)
+			}
+
+			// The transaction wasn't found, so it can only be sent again if nothing else has used its nonce
+			if step.Nonce == nil {
+				return fmt.Errorf("Could not complete minipool %s exit step %s: transaction %s wasn't found and its nonce is unknown", w.MinipoolAddress.Hex(), step.Type, step.TxHash.Hex())
+			}
+			confirmedNonce, err := rp.Client.NonceAt(ctx, opts.From, nil)
+			if err != nil {
+				return fmt.Errorf("Could not get the nonce of %s: %w", opts.From.Hex(), err)
+			}
+			if confirmedNonce > *step.Nonce {
+				return fmt.Errorf("Could not complete minipool %s exit step %s: transaction %s wasn't found, but another transaction with its nonce %d was mined, so the workflow needs to be planned again", w.MinipoolAddress.Hex(), step.Type, step.TxHash.Hex(), *step.Nonce)
+			}
+			step.TxHash = common.Hash{}
+			if err := report(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Send an exit step's transaction, with the step's nonce if it was sent before, returning the hash and nonce of the transaction.
+// The step's gas is estimated again unless the options have a gas limit, as the earlier steps change the state it runs against.
+func (w *ExitWorkflow) sendStep(ctx context.Context, rp *rocketpool.RocketPool, mp Minipool, step *ExitStep, opts *bind.TransactOpts) (common.Hash, uint64, error) {
+	stepOpts := *opts
+	stepOpts.Context = ctx
+	if step.Nonce != nil {
+		stepOpts.Nonce = new(big.Int).SetUint64(*step.Nonce)
+	}
+	if stepOpts.GasLimit == 0 {
+		gasInfo, err := estimateExitStepGas(rp, mp, step, &stepOpts)
+		if err != nil {
+			return common.Hash{}, 0, fmt.Errorf("Could not estimate the gas of minipool %s exit step %s: %w", w.MinipoolAddress.Hex(), step.Type, err)
+		}
+		step.GasInfo = gasInfo
+		stepOpts.GasLimit = gasInfo.SafeGasLimit
+	}
+
+	// Get the nonce from the signed transaction, since it can be allocated by the transaction manager
+	var nonce uint64
+	if signer := opts.Signer; signer != nil {
+		stepOpts.Signer = func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			nonce = tx.Nonce()
+			return signer(address, tx)
+		}
+	}
+	hash, err := sendExitStep(rp, mp, step, &stepOpts)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	return hash, nonce, nil
+}
+
+// Estimate the gas of an exit step
+func estimateExitStepGas(rp *rocketpool.RocketPool, mp Minipool, step *ExitStep, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	switch step.Type {
+	case ExitStepRefund:
+		return mp.EstimateRefundGas(opts)
+	case ExitStepDistributeBalance:
+		if mpv3, ok := GetMinipoolAsV3(mp); ok {
+			return mpv3.EstimateDistributeBalanceGas(step.RewardsOnly, opts)
+		}
+		if mpv2, ok := GetMinipoolAsV2(mp); ok {
+			return mpv2.EstimateDistributeBalanceGas(opts)
+		}
+	case ExitStepDistributeBalanceAndFinalise:
+		if mpv2, ok := GetMinipoolAsV2(mp); ok {
+			return mpv2.EstimateDistributeBalanceAndFinaliseGas(opts)
+		}
+	case ExitStepFinalise:
+		return mp.EstimateFinaliseGas(opts)
+	case ExitStepClose:
+		return mp.EstimateCloseGas(opts)
+	case ExitStepDistributeFees:
+		distributor, err := node.NewDistributor(rp, step.Target, nil)
+		if err != nil {
+			return rocketpool.GasInfo{}, err
+		}
+		return distributor.EstimateDistributeGas(opts)
+	}
+	return rocketpool.GasInfo{}, fmt.Errorf("minipool version %d doesn't support exit step %s", mp.GetVersion(), step.Type)
+}
+
+// Send an exit step's transaction
+func sendExitStep(rp *rocketpool.RocketPool, mp Minipool, step *ExitStep, opts *bind.TransactOpts) (common.Hash, error) {
+	switch step.Type {
+	case ExitStepRefund:
+		return mp.Refund(opts)
+	case ExitStepDistributeBalance:
+		if mpv3, ok := GetMinipoolAsV3(mp); ok {
+			return mpv3.DistributeBalance(step.RewardsOnly, opts)
+		}
+		if mpv2, ok := GetMinipoolAsV2(mp); ok {
+			return mpv2.DistributeBalance(opts)
+		}
+	case ExitStepDistributeBalanceAndFinalise:
+		if mpv2, ok := GetMinipoolAsV2(mp); ok {
+			return mpv2.DistributeBalanceAndFinalise(opts)
+		}
+	case ExitStepFinalise:
+		return mp.Finalise(opts)
+	case ExitStepClose:
+		return mp.Close(opts)
+	case ExitStepDistributeFees:
+		distributor, err := node.NewDistributor(rp, step.Target, nil)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return distributor.Distribute(opts)
+	}
+	return common.Hash{}, fmt.Errorf("minipool version %d doesn't support exit step %s", mp.GetVersion(), step.Type)
+}
+
+// Get a value, or zero if it's nil
+func bigOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return big.NewInt(0)
+	}
+	return value
+}
@@ -60,10 +60,13 @@ func createMinipoolContractFromEncodedAbi(rp *rocketpool.RocketPool, address com
 
 	// Create and return
 	return &rocketpool.Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               "poolseaMinipool",
+		TransactionManager: rp.GetTransactionManager(),
+		GasSettings:        rp.GetGasSettings(),
 	}, nil
 }
 
@@ -71,10 +74,13 @@ func createMinipoolContractFromEncodedAbi(rp *rocketpool.RocketPool, address com
 func createMinipoolContractFromAbi(rp *rocketpool.RocketPool, address common.Address, abi *abi.ABI) (*rocketpool.Contract, error) {
 	// Create and return
 	return &rocketpool.Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               "poolseaMinipool",
+		TransactionManager: rp.GetTransactionManager(),
+		GasSettings:        rp.GetGasSettings(),
 	}, nil
 }
 
@@ -1,7 +1,6 @@
 package minipool
 
 import (
-	"context"
 	"fmt"
 	"math/big"
 	"time"
@@ -589,14 +588,14 @@ func (mp *minipool_v3) GetPrestakeEvent(intervalSize *big.Int, opts *bind.CallOp
 	topicFilter := [][]common.Hash{{mp.Contract.ABI.Events["MinipoolPrestaked"].ID}}
 
 	// Grab the latest block number
-	currentBlock, err := mp.RocketPool.Client.BlockNumber(context.Background())
+	currentBlock, err := mp.RocketPool.Client.BlockNumber(rocketpool.GetCallContext(opts))
 	if err != nil {
 		return PrestakeData{}, fmt.Errorf("Error getting current block %s: %w", mp.Address.Hex(), err)
 	}
 
 	// Grab the lowest block number worth querying from (should never have to go back this far in practice)
 	deployBlockHash := crypto.Keccak256Hash([]byte("deploy.block"))
-	fromBlockBig, err := mp.RocketPool.RocketStorage.GetUint(nil, deployBlockHash)
+	fromBlockBig, err := mp.RocketPool.RocketStorage.GetUint(rocketpool.WithCallContext(rocketpool.GetCallContext(opts), nil), deployBlockHash)
 	if err != nil {
 		return PrestakeData{}, fmt.Errorf("Error getting deploy block %s: %w", mp.Address.Hex(), err)
 	}
@@ -615,7 +614,7 @@ func (mp *minipool_v3) GetPrestakeEvent(intervalSize *big.Int, opts *bind.CallOp
 		fromBig := big.NewInt(0).SetUint64(from)
 		toBig := big.NewInt(0).SetUint64(i)
 
-		logs, err := eth.GetLogs(mp.RocketPool, addressFilter, topicFilter, intervalSize, fromBig, toBig, nil)
+		logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), mp.RocketPool, addressFilter, topicFilter, intervalSize, fromBig, toBig, nil)
 		if err != nil {
 			return PrestakeData{}, fmt.Errorf("Error getting prestake logs for minipool %s: %w", mp.Address.Hex(), err)
 		}
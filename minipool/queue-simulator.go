@@ -0,0 +1,247 @@
+package minipool
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/RedDuck-Software/poolsea-go/deposit"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/settings/protocol"
+	"github.com/RedDuck-Software/poolsea-go/storage"
+	rptypes "github.com/RedDuck-Software/poolsea-go/types"
+)
+
+// The address queue storage key of the Atlas variable queue
+const variableQueueKey = "minipools.available.variable"
+
+// A minipool waiting in one of the deposit queues for its user ETH
+type QueueEntry struct {
+	MinipoolAddress   common.Address          `json:"minipoolAddress"`
+	DepositType       rptypes.MinipoolDeposit `json:"depositType"`       // Variable for the Atlas queue, Full / Half / Empty for the legacy queues
+	UserDepositAmount *big.Int                `json:"userDepositAmount"` // The user ETH the minipool is assigned when it leaves the queue
+}
+
+// Check if the entry is in one of the legacy per-type queues
+func (entry QueueEntry) IsLegacy() bool {
+	return entry.DepositType != rptypes.Variable
+}
+
+// A model of user deposits into the deposit pool, where DepositAmount is deposited every DepositInterval.
+// Each deposit triggers a round of assignments.
+type QueueInflowModel struct {
+	DepositAmount   *big.Int      `json:"depositAmount"`
+	DepositInterval time.Duration `json:"depositInterval"`
+}
+
+// The queue and deposit pool details the simulator runs on
+type QueueSimulationInput struct {
+	Entries                   []QueueEntry     `json:"entries"`            // In assignment order, with the legacy queues first
+	DepositPoolBalance        *big.Int         `json:"depositPoolBalance"` // The user balance, which excludes ETH that nodes have put in the pool for their own minipools
+	AssignDepositsEnabled     bool             `json:"assignDepositsEnabled"`
+	MaximumDepositAssignments uint64           `json:"maximumDepositAssignments"`
+	Inflow                    QueueInflowModel `json:"inflow"`
+}
+
+// The estimated assignment of a queued minipool
+type QueueAssignmentEstimate struct {
+	MinipoolAddress     common.Address          `json:"minipoolAddress"`
+	DepositType         rptypes.MinipoolDeposit `json:"depositType"`
+	Position            uint64                  `json:"position"` // 1-indexed across the legacy and Atlas queues
+	UserDepositAmount   *big.Int                `json:"userDepositAmount"`
+	EthRequired         *big.Int                `json:"ethRequired"` // The user ETH that has to be deposited before the minipool can be assigned
+	Assignable          bool                    `json:"assignable"`  // False if the minipool is never assigned under the inflow model
+	DepositsRequired    uint64                  `json:"depositsRequired"`
+	AssignmentTime      time.Time               `json:"assignmentTime"`
+	TimeUntilAssignment time.Duration           `json:"timeUntilAssignment"`
+}
+
+// Simulate the assignment of the queued minipools and estimate when each one gets its user ETH.
+// Minipools the current balance already covers are assigned at currentTime, as anyone can call AssignDeposits.
+// Like the deposit pool, a round of assignments that starts in the legacy queues doesn't continue into the Atlas queue.
+func SimulateQueue(input QueueSimulationInput, currentTime time.Time) []QueueAssignmentEstimate {
+
+	// Get the ETH required to reach each position
+	estimates := make([]QueueAssignmentEstimate, len(input.Entries))
+	startBalance := bigOrZero(input.DepositPoolBalance)
+	totalAmount := big.NewInt(0)
+	for i, entry := range input.Entries {
+		amount := bigOrZero(entry.UserDepositAmount)
+		totalAmount.Add(totalAmount, amount)
+		ethRequired := big.NewInt(0).Sub(totalAmount, startBalance)
+		if ethRequired.Sign() < 0 {
+			ethRequired.SetUint64(0)
+		}
+		estimates[i] = QueueAssignmentEstimate{
+			MinipoolAddress:   entry.MinipoolAddress,
+			DepositType:       entry.DepositType,
+			Position:          uint64(i + 1),
+			UserDepositAmount: big.NewInt(0).Set(amount),
+			EthRequired:       ethRequired,
+		}
+	}
+	if !input.AssignDepositsEnabled || input.MaximumDepositAssignments == 0 {
+		return estimates
+	}
+	inflowAmount := bigOrZero(input.Inflow.DepositAmount)
+	hasInflow := inflowAmount.Sign() > 0 && input.Inflow.DepositInterval > 0
+
+	// Run the assignment rounds
+	balance := big.NewInt(0).Set(startBalance)
+	deposits := uint64(0)
+	next := 0
+	for next < len(input.Entries) {
+
+		// Assign as many minipools as the balance and the assignment limit allow
+		legacyRound := input.Entries[next].IsLegacy()
+		for assigned := uint64(0); assigned < input.MaximumDepositAssignments && next < len(input.Entries); assigned++ {
+			entry := input.Entries[next]
+			amount := bigOrZero(entry.UserDepositAmount)
+			if entry.IsLegacy() != legacyRound || balance.Cmp(amount) < 0 {
+				break
+			}
+			balance.Sub(balance, amount)
+			untilAssignment := time.Duration(deposits) * input.Inflow.DepositInterval
+			estimates[next].Assignable = true
+			estimates[next].DepositsRequired = deposits
+			estimates[next].AssignmentTime = currentTime.Add(untilAssignment)
+			estimates[next].TimeUntilAssignment = untilAssignment
+			next++
+		}
+		if next == len(input.Entries) || !hasInflow {
+			break
+		}
+
+		// Skip to the first deposit that covers the next minipool
+		rounds := big.NewInt(1)
+		deficit := big.NewInt(0).Sub(bigOrZero(input.Entries[next].UserDepositAmount), balance)
+		if deficit.Sign() > 0 {
+			rounds.Add(deficit, inflowAmount)
+			rounds.Sub(rounds, big.NewInt(1))
+			rounds.Div(rounds, inflowAmount)
+		}
+		deposits += rounds.Uint64()
+		balance.Add(balance, rounds.Mul(rounds, inflowAmount))
+
+	}
+
+	return estimates
+
+}
+
+// Load the simulator's input from the Atlas queue and deposit pool.
+// legacyEntries are the contents of the legacy queues, which can be loaded with the legacy minipool package's GetQueueEntries;
+// they're placed ahead of the variable queue, which is the only queue loaded here.
+func GetQueueSimulationInput(rp *rocketpool.RocketPool, legacyEntries []QueueEntry, inflow QueueInflowModel, opts *bind.CallOpts) (QueueSimulationInput, error) {
+
+	// Data
+	var wg errgroup.Group
+	var entries []QueueEntry
+	var balance *big.Int
+	var assignDepositsEnabled bool
+	var maximumDepositAssignments uint64
+
+	// Load data
+	wg.Go(func() error {
+		var err error
+		entries, err = GetQueueEntries(rp, opts)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		balance, err = deposit.GetUserBalance(rp, opts)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		assignDepositsEnabled, err = protocol.GetAssignDepositsEnabled(rp, opts)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		maximumDepositAssignments, err = protocol.GetMaximumDepositAssignments(rp, opts)
+		return err
+	})
+
+	// Wait for data
+	if err := wg.Wait(); err != nil {
+		return QueueSimulationInput{}, err
+	}
+
+	// Return
+	return QueueSimulationInput{
+		Entries:                   append(append([]QueueEntry{}, legacyEntries...), entries...),
+		DepositPoolBalance:        balance,
+		AssignDepositsEnabled:     assignDepositsEnabled,
+		MaximumDepositAssignments: maximumDepositAssignments,
+		Inflow:                    inflow,
+	}, nil
+
+}
+
+// Get the minipools in the Atlas variable queue in assignment order, with the user ETH each one needs.
+// The queue contract's getTotalLength and getMinipoolAt include the legacy queues, so the variable queue is read from storage directly.
+func GetQueueEntries(rp *rocketpool.RocketPool, opts *bind.CallOpts) ([]QueueEntry, error) {
+
+	// Get the queue length and launch balance
+	key := crypto.Keccak256Hash([]byte(variableQueueKey))
+	length, err := storage.GetAddressQueueLength(rp, opts, key)
+	if err != nil {
+		return nil, err
+	}
+	launchBalance, err := protocol.GetMinipoolLaunchBalance(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the entries in batches
+	entries := make([]QueueEntry, length)
+	for bsi := uint64(0); bsi < length; bsi += MinipoolAddressBatchSize {
+
+		// Get batch start & end index
+		msi := bsi
+		mei := bsi + MinipoolAddressBatchSize
+		if mei > length {
+			mei = length
+		}
+
+		// Load entries
+		var wg errgroup.Group
+		for mi := msi; mi < mei; mi++ {
+			mi := mi
+			wg.Go(func() error {
+				address, err := storage.GetAddressQueueItem(rp, opts, key, big.NewInt(int64(mi)))
+				if err != nil {
+					return fmt.Errorf("Could not get address in queue at position %d: %w", mi, err)
+				}
+				mp, err := NewMinipool(rp, address, opts)
+				if err != nil {
+					return err
+				}
+				nodeDepositBalance, err := mp.GetNodeDepositBalance(opts)
+				if err != nil {
+					return err
+				}
+				entries[mi] = QueueEntry{
+					MinipoolAddress:   address,
+					DepositType:       rptypes.Variable,
+					UserDepositAmount: big.NewInt(0).Sub(launchBalance, nodeDepositBalance),
+				}
+				return nil
+			})
+		}
+		if err := wg.Wait(); err != nil {
+			return nil, err
+		}
+
+	}
+
+	// Return
+	return entries, nil
+
+}
@@ -140,5 +140,5 @@ var rocketMinipoolQueueLock sync.Mutex
 func getRocketMinipoolQueue(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketMinipoolQueueLock.Lock()
 	defer rocketMinipoolQueueLock.Unlock()
-	return rp.GetContract("poolseaMinipoolQueue", opts)
+	return rp.GetContractForCall("poolseaMinipoolQueue", opts)
 }
@@ -86,7 +86,7 @@ var rocketNodeDistributorFactoryLock sync.Mutex
 func getRocketNodeDistributorFactory(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketNodeDistributorFactoryLock.Lock()
 	defer rocketNodeDistributorFactoryLock.Unlock()
-	return rp.GetContract("poolseaNodeDistributorFactory", opts)
+	return rp.GetContractForCall("poolseaNodeDistributorFactory", opts)
 }
 
 // Get a distributor contract
@@ -1,7 +1,6 @@
 package node
 
 import (
-	"context"
 	"fmt"
 	"math"
 	"math/big"
@@ -450,7 +449,7 @@ func GetPricesSubmissions(rp *rocketpool.RocketPool, nodeAddress common.Address,
 	topicFilter := [][]common.Hash{{rocketNetworkPrices.ABI.Events["PricesSubmitted"].ID}, {nodeAddress.Hash()}}
 
 	// Get the event logs
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -478,7 +477,7 @@ func GetBalancesSubmissions(rp *rocketpool.RocketPool, nodeAddress common.Addres
 	topicFilter := [][]common.Hash{{rocketNetworkBalances.ABI.Events["BalancesSubmitted"].ID}, {nodeAddress.Hash()}}
 
 	// Get the event logs
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -507,7 +506,7 @@ func getLatestMemberCountChangedBlock(rp *rocketpool.RocketPool, fromBlock uint6
 	topicFilter := [][]common.Hash{{rocketDaoNodeTrustedActions.ABI.Events["ActionJoined"].ID, rocketDaoNodeTrustedActions.ABI.Events["ActionLeave"].ID, rocketDaoNodeTrustedActions.ABI.Events["ActionKick"].ID, rocketDaoNodeTrustedActions.ABI.Events["ActionChallengeDecided"].ID}}
 
 	// Get the event logs
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -538,7 +537,7 @@ func CalculateTrustedNodePricesParticipation(rp *rocketpool.RocketPool, interval
 		return nil, err
 	}
 	// Get the current block
-	currentBlock, err := rp.Client.HeaderByNumber(context.Background(), nil)
+	currentBlock, err := rp.Client.HeaderByNumber(rocketpool.GetCallContext(opts), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -625,7 +624,7 @@ func CalculateTrustedNodeBalancesParticipation(rp *rocketpool.RocketPool, interv
 		return nil, err
 	}
 	// Get the current block
-	currentBlock, err := rp.Client.HeaderByNumber(context.Background(), nil)
+	currentBlock, err := rp.Client.HeaderByNumber(rocketpool.GetCallContext(opts), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -716,7 +715,7 @@ func GetLatestBalancesSubmissions(rp *rocketpool.RocketPool, fromBlock uint64, i
 	topicFilter := [][]common.Hash{{rocketNetworkBalances.ABI.Events["BalancesSubmitted"].ID}}
 
 	// Get the event logs
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -738,7 +737,7 @@ func GetTrustedNodeLatestBalancesParticipation(rp *rocketpool.RocketPool, interv
 		return nil, err
 	}
 	// Get the current block
-	currentBlock, err := rp.Client.HeaderByNumber(context.Background(), nil)
+	currentBlock, err := rp.Client.HeaderByNumber(rocketpool.GetCallContext(opts), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -777,7 +776,7 @@ func GetLatestPricesSubmissions(rp *rocketpool.RocketPool, fromBlock uint64, int
 	topicFilter := [][]common.Hash{{rocketNetworkPrices.ABI.Events["PricesSubmitted"].ID}}
 
 	// Get the event logs
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, big.NewInt(int64(fromBlock)), nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -799,7 +798,7 @@ func GetTrustedNodeLatestPricesParticipation(rp *rocketpool.RocketPool, interval
 		return nil, err
 	}
 	// Get the current block
-	currentBlock, err := rp.Client.HeaderByNumber(context.Background(), nil)
+	currentBlock, err := rp.Client.HeaderByNumber(rocketpool.GetCallContext(opts), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -943,7 +942,7 @@ var rocketNodeManagerLock sync.Mutex
 func getRocketNodeManager(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketNodeManagerLock.Lock()
 	defer rocketNodeManagerLock.Unlock()
-	return rp.GetContract("poolseaNodeManager", opts)
+	return rp.GetContractForCall("poolseaNodeManager", opts)
 }
 
 var rocketNetworkPricesLock sync.Mutex
@@ -951,7 +950,7 @@ var rocketNetworkPricesLock sync.Mutex
 func getRocketNetworkPrices(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketNetworkPricesLock.Lock()
 	defer rocketNetworkPricesLock.Unlock()
-	return rp.GetContract("poolseaNetworkPrices", opts)
+	return rp.GetContractForCall("poolseaNetworkPrices", opts)
 }
 
 var rocketNetworkBalancesLock sync.Mutex
@@ -959,7 +958,7 @@ var rocketNetworkBalancesLock sync.Mutex
 func getRocketNetworkBalances(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketNetworkBalancesLock.Lock()
 	defer rocketNetworkBalancesLock.Unlock()
-	return rp.GetContract("poolseaNetworkBalances", opts)
+	return rp.GetContractForCall("poolseaNetworkBalances", opts)
 }
 
 var rocketDAONodeTrustedActionsLock sync.Mutex
@@ -967,5 +966,5 @@ var rocketDAONodeTrustedActionsLock sync.Mutex
 func getRocketDAONodeTrustedActions(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
 	rocketDAONodeTrustedActionsLock.Lock()
 	defer rocketDAONodeTrustedActionsLock.Unlock()
-	return rp.GetContract("poolseaDAONodeTrustedActions", opts)
+	return rp.GetContractForCall("poolseaDAONodeTrustedActions", opts)
 }
@@ -0,0 +1,18 @@
+package rocketpool
+
+import (
+	"context"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+)
+
+// This is the common interface for Beacon chain clients.
+type BeaconClient interface {
+
+	// GetValidators returns the details of the validators with the given pubkeys in the given state.
+	// Validators that aren't on the Beacon chain yet are left out of the results.
+	GetValidators(ctx context.Context, stateId types.BeaconStateId, pubkeys []types.ValidatorPubkey) (map[types.ValidatorPubkey]types.ValidatorDetails, error)
+
+	// GetFinalityCheckpoints returns the justified and finalized checkpoints of the given state.
+	GetFinalityCheckpoints(ctx context.Context, stateId types.BeaconStateId) (types.FinalityCheckpoints, error)
+}
@@ -0,0 +1,111 @@
+package rocketpool
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Bundles that ship with the library, one <network>.json file per network, generated with gen-bundle.go
+//
+//go:embed bundles
+var embeddedBundles embed.FS
+
+// The registry of embedded bundles, loaded on first use
+var (
+	embeddedRegistry     *ContractBundleRegistry
+	embeddedRegistryErr  error
+	embeddedRegistryOnce sync.Once
+)
+
+// A set of contract bundles, looked up by the chain ID or RocketStorage address of their network
+type ContractBundleRegistry struct {
+	bundles []*ContractBundle
+}
+
+// Load a registry from the bundle files in the root directory of a filesystem
+func NewContractBundleRegistry(fsys fs.FS) (*ContractBundleRegistry, error) {
+	filenames, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("Could not list contract bundles: %w", err)
+	}
+	registry := &ContractBundleRegistry{
+		bundles: make([]*ContractBundle, 0, len(filenames)),
+	}
+	for _, filename := range filenames {
+		bundleBytes, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read contract bundle %s: %w", filename, err)
+		}
+		bundle, err := ParseContractBundle(bundleBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse contract bundle %s: %w", filename, err)
+		}
+		for _, other := range registry.bundles {
+			if other.ChainID == bundle.ChainID || other.StorageAddress == bundle.StorageAddress {
+				return nil, fmt.Errorf("Contract bundle %s is for the same network as the %s bundle", filename, other.Network)
+			}
+		}
+		registry.bundles = append(registry.bundles, bundle)
+	}
+	return registry, nil
+}
+
+// Get the registry of the bundles that ship with the library
+func GetEmbeddedContractBundleRegistry() (*ContractBundleRegistry, error) {
+	embeddedRegistryOnce.Do(func() {
+		bundles, err := fs.Sub(embeddedBundles, "bundles")
+		if err != nil {
+			embeddedRegistryErr = fmt.Errorf("Could not open embedded contract bundles: %w", err)
+			return
+		}
+		embeddedRegistry, embeddedRegistryErr = NewContractBundleRegistry(bundles)
+	})
+	return embeddedRegistry, embeddedRegistryErr
+}
+
+// Get the bundles in the registry
+func (r *ContractBundleRegistry) GetBundles() []*ContractBundle {
+	bundles := make([]*ContractBundle, len(r.bundles))
+	copy(bundles, r.bundles)
+	return bundles
+}
+
+// Get the bundle for the network with a chain ID, if there is one
+func (r *ContractBundleRegistry) GetBundleByChainID(chainID uint64) (*ContractBundle, bool) {
+	for _, bundle := range r.bundles {
+		if bundle.ChainID == chainID {
+			return bundle, true
+		}
+	}
+	return nil, false
+}
+
+// Get the bundle for the network with a RocketStorage address, if there is one
+func (r *ContractBundleRegistry) GetBundleByStorageAddress(storageAddress common.Address) (*ContractBundle, bool) {
+	for _, bundle := range r.bundles {
+		if bundle.StorageAddress == storageAddress {
+			return bundle, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve contracts from the registry's bundle for this network's RocketStorage, if it has one; contracts are still resolved
+// from the chain if it doesn't. A chain ID of 0 skips the chain ID check. Returns the bundle in use, or nil.
+func (rp *RocketPool) UseRegisteredContractBundle(registry *ContractBundleRegistry, chainID uint64, settings ContractBundleSettings) (*ContractBundle, error) {
+	bundle, exists := registry.GetBundleByStorageAddress(*rp.RocketStorageContract.Address)
+	if !exists {
+		return nil, nil
+	}
+	if chainID != 0 && bundle.ChainID != chainID {
+		return nil, fmt.Errorf("The %s contract bundle for RocketStorage at %s is for chain ID %d, not %d", bundle.Network, bundle.StorageAddress.Hex(), bundle.ChainID, chainID)
+	}
+	if err := rp.UseContractBundle(bundle, settings); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
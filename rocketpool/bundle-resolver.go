@@ -0,0 +1,314 @@
+package rocketpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+)
+
+// The time allowed for validating a bundled contract in the background
+const BundleValidationTimeout = 30 * time.Second
+
+// How bundled contracts are checked against RocketStorage
+type BundleValidation int
+
+const (
+	BundleValidationLazy BundleValidation = iota // Each bundled contract is checked in the background the first time it's used
+	BundleValidationNone                         // Bundled contracts are trusted; use ValidateContractBundle to check them explicitly
+)
+
+// Contract bundle settings
+type ContractBundleSettings struct {
+	Validation BundleValidation
+
+	// Called when a bundled contract is found not to match RocketStorage; it is resolved from the chain from then on
+	OnMismatch func(contractName string, err error)
+}
+
+// A bundle used by a RocketPool instance to resolve contracts, and the validation state of its contracts
+type contractBundleState struct {
+	bundle     *ContractBundle
+	settings   ContractBundleSettings
+	abis       map[string]*abi.ABI // Decoded bundled ABIs
+	checked    map[string]bool
+	mismatched map[string]error
+	validation sync.WaitGroup
+	lock       sync.Mutex
+}
+
+// Resolve the latest addresses and ABIs of the contracts in a bundle from the bundle instead of RocketStorage; nil stops using a bundle.
+// Historical lookups (with a block number in the call options) and contracts that aren't in the bundle still use RocketStorage.
+func (rp *RocketPool) UseContractBundle(bundle *ContractBundle, settings ContractBundleSettings) error {
+
+	// Check the bundle is for this network's RocketStorage
+	if bundle != nil && bundle.StorageAddress != (common.Address{}) && bundle.StorageAddress != *rp.RocketStorageContract.Address {
+		return fmt.Errorf("Contract bundle is for RocketStorage at %s, not %s", bundle.StorageAddress.Hex(), rp.RocketStorageContract.Address.Hex())
+	}
+
+	// Set the bundle
+	var state *contractBundleState
+	if bundle != nil {
+		state = &contractBundleState{
+			bundle:     bundle,
+			settings:   settings,
+			abis:       map[string]*abi.ABI{},
+			checked:    map[string]bool{},
+			mismatched: map[string]error{},
+		}
+	}
+	rp.bundleLock.Lock()
+	rp.bundle = state
+	rp.bundleLock.Unlock()
+
+	// Clear anything resolved without it
+	rp.addressesLock.Lock()
+	rp.addresses = map[string]cachedAddress{}
+	rp.addressesLock.Unlock()
+	rp.abisLock.Lock()
+	rp.abis = map[string]cachedABI{}
+	rp.abisLock.Unlock()
+	rp.contractsLock.Lock()
+	rp.contracts = map[string]cachedContract{}
+	rp.contractsLock.Unlock()
+	return nil
+
+}
+
+// Get the bundle used to resolve contracts, if any
+func (rp *RocketPool) GetContractBundle() *ContractBundle {
+	state := rp.getBundleState()
+	if state == nil {
+		return nil
+	}
+	return state.bundle
+}
+
+// Check every contract in the bundle against RocketStorage, and stop using the ones that don't match.
+// Returns an error wrapping ErrBundleMismatch that lists the mismatched contracts, if there are any.
+func (rp *RocketPool) ValidateContractBundle(ctx context.Context) error {
+
+	// Get the bundle
+	state := rp.getBundleState()
+	if state == nil {
+		return nil
+	}
+
+	// Check the contracts
+	var wg errgroup.Group
+	contractNames := state.bundle.GetContractNames()
+	mismatches := make([]error, len(contractNames))
+	for ci, contractName := range contractNames {
+		ci, contractName := ci, contractName
+		wg.Go(func() error {
+			mismatch, err := rp.validateBundledContract(ctx, state, contractName)
+			mismatches[ci] = mismatch
+			return err
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return err
+	}
+
+	// Report mismatches
+	mismatchedNames := []string{}
+	for ci, mismatch := range mismatches {
+		if mismatch != nil {
+			mismatchedNames = append(mismatchedNames, contractNames[ci])
+		}
+	}
+	if len(mismatchedNames) > 0 {
+		return fmt.Errorf("%w: %s", ErrBundleMismatch, strings.Join(mismatchedNames, ", "))
+	}
+	return nil
+
+}
+
+// Wait for any background validation of bundled contracts to finish
+func (rp *RocketPool) WaitForContractBundleValidation() {
+	if state := rp.getBundleState(); state != nil {
+		state.validation.Wait()
+	}
+}
+
+// Get the names of the bundled contracts that were found not to match RocketStorage, sorted
+func (rp *RocketPool) GetMismatchedBundleContracts() []string {
+	state := rp.getBundleState()
+	if state == nil {
+		return []string{}
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	names := make([]string, 0, len(state.mismatched))
+	for name := range state.mismatched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get the current bundle state
+func (rp *RocketPool) getBundleState() *contractBundleState {
+	rp.bundleLock.RLock()
+	defer rp.bundleLock.RUnlock()
+	return rp.bundle
+}
+
+// Get a contract's address from the bundle, if it's bundled and hasn't been found to mismatch
+func (rp *RocketPool) getBundledAddress(contractName string) (*common.Address, bool) {
+	state, contract, ok := rp.getBundledContract(contractName)
+	if !ok {
+		return nil, false
+	}
+	rp.startBundleValidation(state, contractName)
+	address := contract.Address
+	return &address, true
+}
+
+// Get a contract's ABI from the bundle, if it's bundled and hasn't been found to mismatch
+func (rp *RocketPool) getBundledABI(contractName string) (*abi.ABI, bool, error) {
+	state, contract, ok := rp.getBundledContract(contractName)
+	if !ok {
+		return nil, false, nil
+	}
+	state.lock.Lock()
+	contractAbi, decoded := state.abis[contractName]
+	state.lock.Unlock()
+	if !decoded {
+		var err error
+		contractAbi, err = DecodeAbi(contract.ABI)
+		if err != nil {
+			return nil, true, fmt.Errorf("Could not decode bundled contract %s ABI: %w", contractName, err)
+		}
+		state.lock.Lock()
+		state.abis[contractName] = contractAbi
+		state.lock.Unlock()
+	}
+	rp.startBundleValidation(state, contractName)
+	return contractAbi, true, nil
+}
+
+// Get a contract from the bundle, if it's bundled and hasn't been found to mismatch
+func (rp *RocketPool) getBundledContract(contractName string) (*contractBundleState, BundledContract, bool) {
+	state := rp.getBundleState()
+	if state == nil {
+		return nil, BundledContract{}, false
+	}
+	contract, exists := state.bundle.Contracts[contractName]
+	if !exists {
+		return nil, BundledContract{}, false
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if _, mismatched := state.mismatched[contractName]; mismatched {
+		return nil, BundledContract{}, false
+	}
+	return state, contract, true
+}
+
+// Check a bundled contract against RocketStorage in the background, if lazy validation is enabled and it hasn't been checked yet
+func (rp *RocketPool) startBundleValidation(state *contractBundleState, contractName string) {
+	if state.settings.Validation != BundleValidationLazy {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if state.checked[contractName] {
+		return
+	}
+	state.checked[contractName] = true
+	state.validation.Add(1)
+	go func() {
+		defer state.validation.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), BundleValidationTimeout)
+		defer cancel()
+		if _, err := rp.validateBundledContract(ctx, state, contractName); err != nil {
+			// Couldn't reach the chain, so try again next time the contract is used
+			state.lock.Lock()
+			delete(state.checked, contractName)
+			state.lock.Unlock()
+		}
+	}()
+}
+
+// Check a bundled contract against RocketStorage, and stop using it if it doesn't match.
+// Returns the mismatch if there is one, or an error if the check couldn't be made.
+func (rp *RocketPool) validateBundledContract(ctx context.Context, state *contractBundleState, contractName string) (mismatch error, err error) {
+
+	// Get the contract from the bundle and from RocketStorage
+	bundled := state.bundle.Contracts[contractName]
+	stored, err := getStorageContract(ctx, rp, contractName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare them
+	if stored.Address != bundled.Address {
+		mismatch = fmt.Errorf("%w: contract %s address is %s, not %s", ErrBundleMismatch, contractName, stored.Address.Hex(), bundled.Address.Hex())
+	} else if stored.ABI != bundled.ABI {
+		storedAbi, err := DecodeAbi(stored.ABI)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode contract %s ABI: %w", contractName, err)
+		}
+		bundledAbi, bundledErr := DecodeAbi(bundled.ABI)
+		if bundledErr != nil || !abisMatch(storedAbi, bundledAbi) {
+			mismatch = fmt.Errorf("%w: contract %s ABI has changed", ErrBundleMismatch, contractName)
+		}
+	}
+	if mismatch == nil {
+		return nil, nil
+	}
+
+	// Stop using it; the contract cache is locked so a contract resolved from the bundle can't be cached after this
+	rp.contractsLock.Lock()
+	state.lock.Lock()
+	_, alreadyMismatched := state.mismatched[contractName]
+	state.mismatched[contractName] = mismatch
+	state.lock.Unlock()
+	delete(rp.contracts, contractName)
+	rp.contractsLock.Unlock()
+	rp.deleteCachedAddress(contractName)
+	rp.deleteCachedABI(contractName)
+	if !alreadyMismatched && state.settings.OnMismatch != nil {
+		state.settings.OnMismatch(contractName, mismatch)
+	}
+	return mismatch, nil
+
+}
+
+// Stop resolving a contract from the bundle because it has changed on chain
+func (rp *RocketPool) dropBundledContract(contractName string) {
+	state := rp.getBundleState()
+	if state == nil {
+		return
+	}
+	if _, exists := state.bundle.Contracts[contractName]; !exists {
+		return
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	if _, mismatched := state.mismatched[contractName]; !mismatched {
+		state.mismatched[contractName] = fmt.Errorf("%w: contract %s has changed on chain", ErrBundleMismatch, contractName)
+	}
+}
+
+// Cache a contract, unless it's bundled and has been found not to match RocketStorage since it was resolved
+func (rp *RocketPool) setCachedResolvedContract(contractName string, value cachedContract) {
+	rp.contractsLock.Lock()
+	defer rp.contractsLock.Unlock()
+	if state := rp.getBundleState(); state != nil {
+		state.lock.Lock()
+		_, mismatched := state.mismatched[contractName]
+		state.lock.Unlock()
+		if mismatched {
+			return
+		}
+	}
+	rp.contracts[contractName] = value
+}
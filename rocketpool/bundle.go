@@ -0,0 +1,193 @@
+package rocketpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/sync/errgroup"
+)
+
+// The current contract bundle file format
+const ContractBundleFormatVersion = 1
+
+// Returned when a bundled contract doesn't match RocketStorage
+var ErrBundleMismatch = errors.New("bundled contract does not match RocketStorage")
+
+// A versioned snapshot of the addresses and ABIs of a network's contracts, created from the chain with CreateContractBundle
+type ContractBundle struct {
+	FormatVersion  int                        `json:"formatVersion"`
+	Version        string                     `json:"version"`
+	Network        string                     `json:"network"`
+	ChainID        uint64                     `json:"chainId"`
+	StorageAddress common.Address             `json:"storageAddress"`
+	Contracts      map[string]BundledContract `json:"contracts"`
+}
+
+// A contract in a bundle; the ABI is zlib-compressed and base64-encoded, as it is in RocketStorage
+type BundledContract struct {
+	Address common.Address `json:"address"`
+	ABI     string         `json:"abi"`
+}
+
+// Load a contract bundle from a file
+func LoadContractBundle(path string) (*ContractBundle, error) {
+	bundleBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read contract bundle file %s: %w", path, err)
+	}
+	bundle, err := ParseContractBundle(bundleBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse contract bundle file %s: %w", path, err)
+	}
+	return bundle, nil
+}
+
+// Parse a serialized contract bundle
+func ParseContractBundle(bundleBytes []byte) (*ContractBundle, error) {
+	bundle := new(ContractBundle)
+	if err := json.Unmarshal(bundleBytes, bundle); err != nil {
+		return nil, err
+	}
+	if bundle.FormatVersion != ContractBundleFormatVersion {
+		return nil, fmt.Errorf("unsupported contract bundle format version %d", bundle.FormatVersion)
+	}
+	if bundle.Contracts == nil {
+		bundle.Contracts = map[string]BundledContract{}
+	}
+	return bundle, nil
+}
+
+// Save a contract bundle to a file
+func (b *ContractBundle) Save(path string) error {
+	bundleBytes, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Could not serialize contract bundle: %w", err)
+	}
+	if err := os.WriteFile(path, bundleBytes, 0644); err != nil {
+		return fmt.Errorf("Could not write contract bundle file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get the names of the contracts in a bundle, sorted
+func (b *ContractBundle) GetContractNames() []string {
+	names := make([]string, 0, len(b.Contracts))
+	for name := range b.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get a bundled contract's address and decoded ABI
+func (b *ContractBundle) GetContract(contractName string) (*common.Address, *abi.ABI, error) {
+	contract, exists := b.Contracts[contractName]
+	if !exists {
+		return nil, nil, fmt.Errorf("Contract %s is not in the %s contract bundle", contractName, b.Network)
+	}
+	contractAbi, err := DecodeAbi(contract.ABI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not decode bundled contract %s ABI: %w", contractName, err)
+	}
+	address := contract.Address
+	return &address, contractAbi, nil
+}
+
+// Create a bundle from the current addresses and ABIs of some contracts in RocketStorage
+func CreateContractBundle(ctx context.Context, rp *RocketPool, network string, chainID uint64, version string, contractNames ...string) (*ContractBundle, error) {
+
+	// Load the contracts
+	var wg errgroup.Group
+	contracts := make([]BundledContract, len(contractNames))
+	for ci, contractName := range contractNames {
+		ci, contractName := ci, contractName
+		wg.Go(func() error {
+			contract, err := getStorageContract(ctx, rp, contractName, nil)
+			if err == nil {
+				contracts[ci] = contract
+			}
+			return err
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Create the bundle
+	bundle := &ContractBundle{
+		FormatVersion:  ContractBundleFormatVersion,
+		Version:        version,
+		Network:        network,
+		ChainID:        chainID,
+		StorageAddress: *rp.RocketStorageContract.Address,
+		Contracts:      map[string]BundledContract{},
+	}
+	for ci, contractName := range contractNames {
+		bundle.Contracts[contractName] = contracts[ci]
+	}
+	return bundle, nil
+
+}
+
+// Get a contract's address and encoded ABI directly from RocketStorage, skipping the caches and any bundle
+func getStorageContract(ctx context.Context, rp *RocketPool, contractName string, opts *bind.CallOpts) (BundledContract, error) {
+	address, err := rp.RocketStorage.GetAddress(WithCallContext(ctx, opts), crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)))
+	if err != nil {
+		return BundledContract{}, fmt.Errorf("Could not load contract %s address: %w", contractName, err)
+	}
+	if address == (common.Address{}) {
+		return BundledContract{}, fmt.Errorf("Contract %s does not exist in RocketStorage", contractName)
+	}
+	abiEncoded, err := rp.RocketStorage.GetString(WithCallContext(ctx, opts), crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)))
+	if err != nil {
+		return BundledContract{}, fmt.Errorf("Could not load contract %s ABI: %w", contractName, err)
+	}
+	return BundledContract{
+		Address: address,
+		ABI:     abiEncoded,
+	}, nil
+}
+
+// Check whether two ABIs have the same methods, events and errors
+func abisMatch(a *abi.ABI, b *abi.ABI) bool {
+	if len(a.Methods) != len(b.Methods) || len(a.Events) != len(b.Events) || len(a.Errors) != len(b.Errors) {
+		return false
+	}
+	for name, method := range a.Methods {
+		if other, exists := b.Methods[name]; !exists || other.Sig != method.Sig || !argumentsMatch(other.Outputs, method.Outputs) {
+			return false
+		}
+	}
+	for name, event := range a.Events {
+		if other, exists := b.Events[name]; !exists || other.ID != event.ID {
+			return false
+		}
+	}
+	for name, abiError := range a.Errors {
+		if other, exists := b.Errors[name]; !exists || other.ID != abiError.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// Check whether two argument lists have the same types
+func argumentsMatch(a abi.Arguments, b abi.Arguments) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type.String() != b[i].Type.String() {
+			return false
+		}
+	}
+	return true
+}
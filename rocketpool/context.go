@@ -0,0 +1,36 @@
+package rocketpool
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Get the context to use for a call, defaulting to the background context if the options don't provide one
+func GetCallContext(opts *bind.CallOpts) context.Context {
+	if opts == nil || opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// Get the context to use for a transaction, defaulting to the background context if the options don't provide one
+func GetTransactContext(opts *bind.TransactOpts) context.Context {
+	if opts == nil || opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// Get a copy of the call options that uses the provided context
+// Nil options are treated as a call against the latest block
+func WithCallContext(ctx context.Context, opts *bind.CallOpts) *bind.CallOpts {
+	if opts == nil {
+		return &bind.CallOpts{
+			Context: ctx,
+		}
+	}
+	newOpts := *opts
+	newOpts.Context = ctx
+	return &newOpts
+}
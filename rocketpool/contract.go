@@ -3,12 +3,10 @@ package rocketpool
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
-	"regexp"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -17,7 +15,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
-// Transaction settings
+// Default transaction settings
 const (
 	GasLimitMultiplier    float64 = 1.5
 	MaxGasLimit           uint64  = 30000000
@@ -30,12 +28,40 @@ type Contract struct {
 	Address  *common.Address
 	ABI      *abi.ABI
 	Client   ExecutionClient
+	Name     string
+
+	// If set, transactions are sent through this instead of directly to the client
+	TransactionManager TransactionManager
+
+	// Gas limit and fee settings; the defaults are used if this is nil
+	GasSettings *GasSettings
 }
 
 // Response for gas limits from network and from user request
+// Fees and costs (in wei) are only set when the contract has a fee oracle
 type GasInfo struct {
-	EstGasLimit  uint64 `json:"estGasLimit"`
-	SafeGasLimit uint64 `json:"safeGasLimit"`
+	EstGasLimit  uint64   `json:"estGasLimit"`
+	SafeGasLimit uint64   `json:"safeGasLimit"`
+	BaseFee      *big.Int `json:"baseFee,omitempty"`
+	GasTipCap    *big.Int `json:"gasTipCap,omitempty"`
+	GasFeeCap    *big.Int `json:"gasFeeCap,omitempty"`
+	EstGasCost   *big.Int `json:"estGasCost,omitempty"`
+	MaxGasCost   *big.Int `json:"maxGasCost,omitempty"`
+}
+
+// Set the fees and the estimated and maximum costs of a transaction
+func (g *GasInfo) setFees(fees FeeEstimate) {
+	g.BaseFee = fees.BaseFee
+	g.GasTipCap = fees.GasTipCap
+	g.GasFeeCap = fees.GasFeeCap
+
+	// The expected cost pays the current base fee plus the tip, up to the max fee
+	gasPrice := new(big.Int).Add(fees.BaseFee, fees.GasTipCap)
+	if gasPrice.Cmp(fees.GasFeeCap) > 0 {
+		gasPrice.Set(fees.GasFeeCap)
+	}
+	g.EstGasCost = new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(g.EstGasLimit))
+	g.MaxGasCost = new(big.Int).Mul(fees.GasFeeCap, new(big.Int).SetUint64(g.SafeGasLimit))
 }
 
 // Call a contract method
@@ -45,8 +71,18 @@ func (c *Contract) Call(opts *bind.CallOpts, result interface{}, method string,
 	return c.Contract.Call(opts, &results, method, params...)
 }
 
+// Call a contract method, using the provided context for the network call
+func (c *Contract) CallContext(ctx context.Context, opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return c.Call(WithCallContext(ctx, opts), result, method, params...)
+}
+
 // Get Gas Limit for transaction
 func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string, params ...interface{}) (GasInfo, error) {
+	return c.GetTransactionGasInfoContext(GetTransactContext(opts), opts, method, params...)
+}
+
+// Get Gas Limit for transaction, using the provided context for the network call
+func (c *Contract) GetTransactionGasInfoContext(ctx context.Context, opts *bind.TransactOpts, method string, params ...interface{}) (GasInfo, error) {
 
 	response := GasInfo{}
 
@@ -57,7 +93,7 @@ func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string,
 	}
 
 	// Estimate gas limit
-	estGasLimit, safeGasLimit, err := c.estimateGasLimit(opts, input)
+	estGasLimit, safeGasLimit, err := c.estimateGasLimit(ctx, opts, input)
 
 	if err != nil {
 		return response, fmt.Errorf("Error getting transaction gas info: could not estimate gas limit: %w", err)
@@ -65,11 +101,21 @@ func (c *Contract) GetTransactionGasInfo(opts *bind.TransactOpts, method string,
 	response.EstGasLimit = estGasLimit
 	response.SafeGasLimit = safeGasLimit
 
+	// Get fees
+	if err := c.setGasInfoFees(ctx, opts, &response); err != nil {
+		return response, fmt.Errorf("Error getting transaction gas info: %w", err)
+	}
+
 	return response, err
 }
 
 // Transact on a contract method and wait for a receipt
 func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return c.TransactContext(GetTransactContext(opts), opts, method, params...)
+}
+
+// Transact on a contract method, using the provided context for gas estimation and submission
+func (c *Contract) TransactContext(ctx context.Context, opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
 
 	// Estimate gas limit
 	if opts.GasLimit == 0 {
@@ -77,7 +123,7 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 		if err != nil {
 			return nil, fmt.Errorf("Could not encode input data: %w", err)
 		}
-		_, safeGasLimit, err := c.estimateGasLimit(opts, input)
+		_, safeGasLimit, err := c.estimateGasLimit(ctx, opts, input)
 		if err != nil {
 			return nil, err
 		}
@@ -85,9 +131,16 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 	}
 
 	// Send transaction
-	tx, err := c.Contract.Transact(opts, method, params...)
+	txOpts := *opts
+	txOpts.Context = ctx
+	if err := c.setTransactionFees(ctx, &txOpts); err != nil {
+		return nil, err
+	}
+	tx, err := c.sendTransaction(ctx, &txOpts, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return c.Contract.Transact(opts, method, params...)
+	})
 	if err != nil {
-		return nil, c.normalizeErrorMessage(err)
+		return nil, c.decodeRevertError(err, method)
 	}
 
 	return tx, nil
@@ -96,26 +149,41 @@ func (c *Contract) Transact(opts *bind.TransactOpts, method string, params ...in
 
 // Get gas limit for a transfer call
 func (c *Contract) GetTransferGasInfo(opts *bind.TransactOpts) (GasInfo, error) {
+	return c.GetTransferGasInfoContext(GetTransactContext(opts), opts)
+}
+
+// Get gas limit for a transfer call, using the provided context for the network call
+func (c *Contract) GetTransferGasInfoContext(ctx context.Context, opts *bind.TransactOpts) (GasInfo, error) {
 
 	response := GasInfo{}
 
 	// Estimate gas limit
-	estGasLimit, safeGasLimit, err := c.estimateGasLimit(opts, []byte{})
+	estGasLimit, safeGasLimit, err := c.estimateGasLimit(ctx, opts, []byte{})
 	if err != nil {
 		return response, fmt.Errorf("Error getting transfer gas info: could not estimate gas limit: %w", err)
 	}
 	response.EstGasLimit = estGasLimit
 	response.SafeGasLimit = safeGasLimit
 
+	// Get fees
+	if err := c.setGasInfoFees(ctx, opts, &response); err != nil {
+		return response, fmt.Errorf("Error getting transfer gas info: %w", err)
+	}
+
 	return response, nil
 }
 
 // Transfer ETH to a contract and wait for a receipt
 func (c *Contract) Transfer(opts *bind.TransactOpts) (common.Hash, error) {
+	return c.TransferContext(GetTransactContext(opts), opts)
+}
+
+// Transfer ETH to a contract, using the provided context for gas estimation and submission
+func (c *Contract) TransferContext(ctx context.Context, opts *bind.TransactOpts) (common.Hash, error) {
 
 	// Estimate gas limit
 	if opts.GasLimit == 0 {
-		_, safeGasLimit, err := c.estimateGasLimit(opts, []byte{})
+		_, safeGasLimit, err := c.estimateGasLimit(ctx, opts, []byte{})
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -123,20 +191,68 @@ func (c *Contract) Transfer(opts *bind.TransactOpts) (common.Hash, error) {
 	}
 
 	// Send transaction
-	tx, err := c.Contract.Transfer(opts)
+	txOpts := *opts
+	txOpts.Context = ctx
+	if err := c.setTransactionFees(ctx, &txOpts); err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := c.sendTransaction(ctx, &txOpts, c.Contract.Transfer)
 	if err != nil {
-		return common.Hash{}, c.normalizeErrorMessage(err)
+		return common.Hash{}, c.decodeRevertError(err, "")
 	}
 
 	return tx.Hash(), nil
 
 }
 
+// Send a transaction through the transaction manager if there is one, or directly otherwise
+func (c *Contract) sendTransaction(ctx context.Context, opts *bind.TransactOpts, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	if c.TransactionManager == nil {
+		return send(opts)
+	}
+	return c.TransactionManager.Send(ctx, opts, send)
+}
+
+// Fill in the fees for a transaction from the fee oracle, if there is one and the transaction doesn't specify its own
+func (c *Contract) setTransactionFees(ctx context.Context, opts *bind.TransactOpts) error {
+	oracle := c.GasSettings.getFeeOracle()
+	if oracle == nil || opts.GasPrice != nil || opts.GasFeeCap != nil || opts.GasTipCap != nil {
+		return nil
+	}
+	fees, err := oracle.EstimateFees(ctx)
+	if err != nil {
+		return fmt.Errorf("Could not estimate transaction fees: %w", err)
+	}
+	opts.GasFeeCap = fees.GasFeeCap
+	opts.GasTipCap = fees.GasTipCap
+	return nil
+}
+
+// Add the fees and costs of a transaction to its gas info if there is a fee oracle; fees specified by the transaction take priority
+func (c *Contract) setGasInfoFees(ctx context.Context, opts *bind.TransactOpts, response *GasInfo) error {
+	oracle := c.GasSettings.getFeeOracle()
+	if oracle == nil {
+		return nil
+	}
+	fees, err := oracle.EstimateFees(ctx)
+	if err != nil {
+		return fmt.Errorf("could not estimate transaction fees: %w", err)
+	}
+	if opts.GasFeeCap != nil {
+		fees.GasFeeCap = opts.GasFeeCap
+	}
+	if opts.GasTipCap != nil {
+		fees.GasTipCap = opts.GasTipCap
+	}
+	response.setFees(fees)
+	return nil
+}
+
 // Estimate the expected and safe gas limits for a contract transaction
-func (c *Contract) estimateGasLimit(opts *bind.TransactOpts, input []byte) (uint64, uint64, error) {
+func (c *Contract) estimateGasLimit(ctx context.Context, opts *bind.TransactOpts, input []byte) (uint64, uint64, error) {
 
 	// Estimate gas limit
-	gasLimit, err := c.Client.EstimateGas(context.Background(), ethereum.CallMsg{
+	gasLimit, err := c.Client.EstimateGas(ctx, ethereum.CallMsg{
 		From:     opts.From,
 		To:       c.Address,
 		GasPrice: big.NewInt(0), // use 0 gwei for simulation
@@ -145,26 +261,27 @@ func (c *Contract) estimateGasLimit(opts *bind.TransactOpts, input []byte) (uint
 	})
 
 	if err != nil {
-		return 0, 0, fmt.Errorf("Could not estimate gas needed: %w", c.normalizeErrorMessage(err))
+		return 0, 0, fmt.Errorf("Could not estimate gas needed: %w", c.decodeRevertError(err, c.getMethodName(input)))
 	}
 
 	// Pad and return gas limit
-	safeGasLimit := uint64(float64(gasLimit) * GasLimitMultiplier)
-	if gasLimit > MaxGasLimit {
-		return 0, 0, fmt.Errorf("estimated gas of %d is greater than the max gas limit of %d", gasLimit, MaxGasLimit)
+	maxGasLimit := c.GasSettings.getMaxGasLimit()
+	safeGasLimit := uint64(float64(gasLimit) * c.GasSettings.getGasLimitMultiplier())
+	if gasLimit > maxGasLimit {
+		return 0, 0, fmt.Errorf("estimated gas of %d is greater than the max gas limit of %d", gasLimit, maxGasLimit)
 	}
-	if safeGasLimit > MaxGasLimit {
-		safeGasLimit = MaxGasLimit
+	if safeGasLimit > maxGasLimit {
+		safeGasLimit = maxGasLimit
 	}
 	return gasLimit, safeGasLimit, nil
 
 }
 
 // Wait for a transaction to be mined and get a tx receipt
-func (c *Contract) getTransactionReceipt(tx *types.Transaction) (*types.Receipt, error) {
+func (c *Contract) getTransactionReceipt(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
 
 	// Wait for transaction to be mined
-	txReceipt, err := bind.WaitMined(context.Background(), c.Client, tx)
+	txReceipt, err := bind.WaitMined(ctx, c.Client, tx)
 	if err != nil {
 		return nil, err
 	}
@@ -224,29 +341,19 @@ func (c *Contract) GetTransactionEvents(txReceipt *types.Receipt, eventName stri
 
 }
 
-// Normalize error messages so they're all in ASCII format
-func (c *Contract) normalizeErrorMessage(err error) error {
-	if err == nil {
-		return err
-	}
+// Convert a revert from the execution client into a *RevertError for this contract
+func (c *Contract) decodeRevertError(err error, method string) error {
+	return DecodeRevertError(err, c.Name, method, c.ABI)
+}
 
-	// Get the message in hex format, if it exists
-	reg := regexp.MustCompile(NethermindRevertRegex)
-	matches := reg.FindStringSubmatch(err.Error())
-	if matches == nil {
-		return err
-	}
-	messageIndex := reg.SubexpIndex("message")
-	if messageIndex == -1 {
-		return err
+// Get the name of the method called by some transaction input data, or an empty string for plain transfers
+func (c *Contract) getMethodName(input []byte) string {
+	if len(input) < 4 || c.ABI == nil {
+		return ""
 	}
-	message := matches[messageIndex]
-
-	// Convert the hex message to ASCII
-	bytes, err2 := hex.DecodeString(message)
-	if err2 != nil {
-		return err // Return the original error if decoding failed somehow
+	method, err := c.ABI.MethodById(input[:4])
+	if err != nil {
+		return ""
 	}
-
-	return fmt.Errorf("Reverted: %s", string(bytes))
+	return method.Name
 }
@@ -0,0 +1,224 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// Fee oracle settings
+const (
+	DefaultFeeHistoryBlocks uint64  = 20
+	DefaultFeePercentile    float64 = 50
+)
+
+// A strategy for picking transaction fees
+type FeeStrategy string
+
+const (
+	FeeStrategySlow       FeeStrategy = "slow"       // Half the suggested priority fee, with enough headroom for one full block of base fee increase
+	FeeStrategyNormal     FeeStrategy = "normal"     // The suggested priority fee, with the max fee at twice the base fee
+	FeeStrategyFast       FeeStrategy = "fast"       // Twice the suggested priority fee, with the max fee at three times the base fee
+	FeeStrategyPercentile FeeStrategy = "percentile" // A percentile of the priority fees paid in recent blocks, with the max fee at twice the base fee
+)
+
+// Base fee multipliers (numerator / 1000) and priority fee multipliers (percent) for each strategy
+var feeStrategyMultipliers = map[FeeStrategy]struct {
+	baseFeePermille int64
+	tipPercent      int64
+}{
+	FeeStrategySlow:       {baseFeePermille: 1125, tipPercent: 50},
+	FeeStrategyNormal:     {baseFeePermille: 2000, tipPercent: 100},
+	FeeStrategyFast:       {baseFeePermille: 3000, tipPercent: 200},
+	FeeStrategyPercentile: {baseFeePermille: 2000, tipPercent: 100},
+}
+
+// Returned when the percentile strategy is used with a client that can't provide the fee history
+var ErrFeeHistoryNotSupported = errors.New("the execution client does not support fee history requests")
+
+// Implemented by execution clients that support eth_feeHistory, such as ethclient.Client
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// Fee oracle settings; zero values are replaced with the defaults
+type FeeOracleSettings struct {
+	Strategy         FeeStrategy // How fees are picked; defaults to normal
+	MaxFeeCap        *big.Int    // If set, the max fee is never above this (in wei)
+	Percentile       float64     // The priority fee percentile used by the percentile strategy, from 0 to 100
+	FeeHistoryBlocks uint64      // The number of recent blocks sampled by the percentile strategy
+}
+
+// The fees picked for a transaction, in wei
+type FeeEstimate struct {
+	BaseFee   *big.Int `json:"baseFee"`
+	GasTipCap *big.Int `json:"gasTipCap"`
+	GasFeeCap *big.Int `json:"gasFeeCap"`
+}
+
+// Derives EIP-1559 transaction fees from the latest base fee and the network's priority fee suggestions
+type FeeOracle struct {
+	client   ExecutionClient
+	settings FeeOracleSettings
+}
+
+// Create a new fee oracle
+func NewFeeOracle(client ExecutionClient, settings FeeOracleSettings) (*FeeOracle, error) {
+	if settings.Strategy == "" {
+		settings.Strategy = FeeStrategyNormal
+	}
+	if _, exists := feeStrategyMultipliers[settings.Strategy]; !exists {
+		return nil, fmt.Errorf("Unknown fee strategy '%s'", settings.Strategy)
+	}
+	if settings.Percentile == 0 {
+		settings.Percentile = DefaultFeePercentile
+	}
+	if settings.Percentile < 0 || settings.Percentile > 100 {
+		return nil, fmt.Errorf("Fee percentile %f must be between 0 and 100", settings.Percentile)
+	}
+	if settings.FeeHistoryBlocks == 0 {
+		settings.FeeHistoryBlocks = DefaultFeeHistoryBlocks
+	}
+	if settings.MaxFeeCap != nil && settings.MaxFeeCap.Sign() <= 0 {
+		return nil, errors.New("Max fee cap must be positive")
+	}
+	return &FeeOracle{
+		client:   client,
+		settings: settings,
+	}, nil
+}
+
+// Get the oracle's settings
+func (o *FeeOracle) GetSettings() FeeOracleSettings {
+	return o.settings
+}
+
+// Estimate the fees for a transaction sent now
+func (o *FeeOracle) EstimateFees(ctx context.Context) (FeeEstimate, error) {
+
+	// Get the latest base fee
+	header, err := o.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return FeeEstimate{}, fmt.Errorf("Could not get latest block header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return FeeEstimate{}, errors.New("Latest block has no base fee; the network does not support EIP-1559")
+	}
+
+	// Get the base priority fee
+	tip, err := o.getPriorityFee(ctx, header.Number)
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+
+	// Apply the strategy
+	multipliers := feeStrategyMultipliers[o.settings.Strategy]
+	tip.Mul(tip, big.NewInt(multipliers.tipPercent))
+	tip.Div(tip, big.NewInt(100))
+	feeCap := new(big.Int).Mul(header.BaseFee, big.NewInt(multipliers.baseFeePermille))
+	feeCap.Div(feeCap, big.NewInt(1000))
+	feeCap.Add(feeCap, tip)
+
+	// Apply the ceiling
+	if o.settings.MaxFeeCap != nil && feeCap.Cmp(o.settings.MaxFeeCap) > 0 {
+		feeCap.Set(o.settings.MaxFeeCap)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip.Set(feeCap)
+	}
+
+	return FeeEstimate{
+		BaseFee:   new(big.Int).Set(header.BaseFee),
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+	}, nil
+
+}
+
+// Get the priority fee to apply the strategy to.
+// The percentile strategy requires a client with fee history support, and only falls back to the client's suggestion when
+// none of the recent blocks had any transactions to take a percentile of.
+func (o *FeeOracle) getPriorityFee(ctx context.Context, latestBlock *big.Int) (*big.Int, error) {
+	if o.settings.Strategy == FeeStrategyPercentile {
+		historyReader, ok := o.client.(FeeHistoryReader)
+		if !ok {
+			return nil, fmt.Errorf("Could not use the %s fee strategy: %w", FeeStrategyPercentile, ErrFeeHistoryNotSupported)
+		}
+		history, err := historyReader.FeeHistory(ctx, o.settings.FeeHistoryBlocks, latestBlock, []float64{o.settings.Percentile})
+		if err != nil {
+			return nil, fmt.Errorf("Could not get fee history: %w", err)
+		}
+		if tip := getMedianReward(history); tip != nil {
+			return tip, nil
+		}
+	}
+	tip, err := o.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get suggested priority fee: %w", err)
+	}
+	return new(big.Int).Set(tip), nil
+}
+
+// Get the median of the per-block rewards in a fee history, ignoring empty blocks; returns nil if there are none
+func getMedianReward(history *ethereum.FeeHistory) *big.Int {
+	rewards := []*big.Int{}
+	for i, blockRewards := range history.Reward {
+		if len(blockRewards) == 0 || blockRewards[0] == nil {
+			continue
+		}
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		rewards = append(rewards, blockRewards[0])
+	}
+	if len(rewards) == 0 {
+		return nil
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].Cmp(rewards[j]) < 0
+	})
+	return new(big.Int).Set(rewards[len(rewards)/2])
+}
+
+// Transaction gas settings for a RocketPool instance and the contracts it creates
+type GasSettings struct {
+	GasLimitMultiplier float64    // The safe gas limit is the estimated gas limit multiplied by this
+	MaxGasLimit        uint64     // The largest gas limit a transaction can use
+	FeeOracle          *FeeOracle // If set, used to fill in fees for transactions that don't specify them and to report them in gas info
+}
+
+// Create gas settings with the default gas limit multiplier and max gas limit, and no fee oracle
+func NewDefaultGasSettings() *GasSettings {
+	return &GasSettings{
+		GasLimitMultiplier: GasLimitMultiplier,
+		MaxGasLimit:        MaxGasLimit,
+	}
+}
+
+// Get the gas limit multiplier, falling back to the default
+func (s *GasSettings) getGasLimitMultiplier() float64 {
+	if s == nil || s.GasLimitMultiplier == 0 {
+		return GasLimitMultiplier
+	}
+	return s.GasLimitMultiplier
+}
+
+// Get the max gas limit, falling back to the default
+func (s *GasSettings) getMaxGasLimit() uint64 {
+	if s == nil || s.MaxGasLimit == 0 {
+		return MaxGasLimit
+	}
+	return s.MaxGasLimit
+}
+
+// Get the fee oracle, if there is one
+func (s *GasSettings) getFeeOracle() *FeeOracle {
+	if s == nil {
+		return nil
+	}
+	return s.FeeOracle
+}
@@ -0,0 +1,113 @@
+//go:build ignore
+
+// Generates the contract bundle for a network in bundles/ from its RocketStorage contract.
+// Run from the rocketpool directory with an execution client for the network, e.g.:
+//
+//	go run gen-bundle.go -rpc http://localhost:8545 -storage <RocketStorage address> -network mainnet -version 1.2.0
+//
+// Regenerate a network's bundle whenever its contracts are upgraded; the library checks bundled contracts against RocketStorage
+// when they're used and falls back to the chain for any that don't match, so a stale bundle is slower but not wrong.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// The directory the bundles are written to
+const outputDir = "bundles"
+
+// The contracts the library resolves by name
+var contractNames = []string{
+	"poolseaAuctionManager",
+	"poolseaClaimDAO",
+	"poolseaDAONodeTrusted",
+	"poolseaDAONodeTrustedActions",
+	"poolseaDAONodeTrustedProposals",
+	"poolseaDAONodeTrustedSettingsMembers",
+	"poolseaDAONodeTrustedSettingsMinipool",
+	"poolseaDAONodeTrustedSettingsProposals",
+	"poolseaDAONodeTrustedSettingsRewards",
+	"poolseaDAONodeTrustedUpgrade",
+	"poolseaDAOProposal",
+	"poolseaDAOProtocol",
+	"poolseaDAOProtocolSettingsAuction",
+	"poolseaDAOProtocolSettingsDeposit",
+	"poolseaDAOProtocolSettingsInflation",
+	"poolseaDAOProtocolSettingsMinipool",
+	"poolseaDAOProtocolSettingsNetwork",
+	"poolseaDAOProtocolSettingsNode",
+	"poolseaDAOProtocolSettingsRewards",
+	"poolseaDepositPool",
+	"poolseaMerkleDistributorMainnet",
+	"poolseaMinipoolBondReducer",
+	"poolseaMinipoolFactory",
+	"poolseaMinipoolManager",
+	"poolseaMinipoolQueue",
+	"poolseaMinipoolStatus",
+	"poolseaNetworkBalances",
+	"poolseaNetworkFees",
+	"poolseaNetworkPenalties",
+	"poolseaNetworkPrices",
+	"poolseaNodeDeposit",
+	"poolseaNodeDistributorDelegate",
+	"poolseaNodeDistributorFactory",
+	"poolseaNodeManager",
+	"poolseaNodeStaking",
+	"poolseaRewardsPool",
+	"poolseaSmoothingPool",
+	"poolseaTokenRETH",
+	"poolseaTokenRPL",
+	"poolseaTokenRPLFixedSupply",
+}
+
+func main() {
+
+	// Parse the flags
+	rpc := flag.String("rpc", "http://localhost:8545", "The URL of an execution client for the network")
+	storage := flag.String("storage", "", "The address of the network's RocketStorage contract")
+	network := flag.String("network", "", "The name of the network, used as the bundle's filename")
+	version := flag.String("version", "", "The version of the deployed contracts")
+	contracts := flag.String("contracts", strings.Join(contractNames, ","), "The comma-separated names of the contracts to bundle")
+	flag.Parse()
+	if !common.IsHexAddress(*storage) || *network == "" || *version == "" {
+		flag.Usage()
+		log.Fatal("-storage, -network and -version are required")
+	}
+
+	// Connect to the network
+	ctx := context.Background()
+	client, err := ethclient.Dial(*rpc)
+	if err != nil {
+		log.Fatalf("Could not connect to %s: %s", *rpc, err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		log.Fatalf("Could not get chain ID: %s", err)
+	}
+	rp, err := rocketpool.NewRocketPool(client, common.HexToAddress(*storage))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create and save the bundle
+	bundle, err := rocketpool.CreateContractBundle(ctx, rp, *network, chainID.Uint64(), *version, strings.Split(*contracts, ",")...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bundlePath := filepath.Join(outputDir, *network+".json")
+	if err := bundle.Save(bundlePath); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %d contracts to %s\n", len(bundle.Contracts), bundlePath)
+
+}
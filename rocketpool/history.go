@@ -0,0 +1,305 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Returned when a contract didn't exist at the requested block
+var ErrContractNotDeployed = errors.New("contract was not deployed at the requested block")
+
+// An upgrade or addition of a contract, from the upgrade contract's events
+type contractHistoryEvent struct {
+	added       bool
+	oldAddress  common.Address
+	newAddress  common.Address
+	blockNumber uint64
+	logIndex    uint
+}
+
+// The upgrade history of a contract, up to the latest block that has been scanned
+type contractHistory struct {
+	events    []contractHistoryEvent
+	scannedTo uint64
+	lock      sync.Mutex // Held while the history is scanned, so concurrent lookups of the same contract scan it once
+}
+
+// Load a Rocket Pool contract as it was at a block.
+// The address is found from the upgrade contract's history; the ABI comes from the current contract if it hasn't been upgraded since,
+// from the legacy version wrappers for upgraded contracts, or from RocketStorage at the block (which requires an archive node) otherwise.
+// The upgrade events are scanned in batches of the event log interval set with SetEventLogInterval.
+func (rp *RocketPool) GetContractAt(contractName string, block uint64) (*Contract, error) {
+	return rp.GetContractAtContext(context.Background(), contractName, block)
+}
+
+// Load a Rocket Pool contract as it was at a block, using the provided context for network calls
+func (rp *RocketPool) GetContractAtContext(ctx context.Context, contractName string, block uint64) (*Contract, error) {
+
+	// Get the address
+	address, err := rp.getHistoricalAddress(ctx, contractName, block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the ABI
+	contractAbi, err := rp.getHistoricalABI(ctx, contractName, *address, block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create and return
+	return &Contract{
+		Contract:           bind.NewBoundContract(*address, *contractAbi, rp.Client, rp.Client, rp.Client),
+		Address:            address,
+		ABI:                contractAbi,
+		Client:             rp.Client,
+		Name:               contractName,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
+	}, nil
+
+}
+
+// Load a Rocket Pool contract for a call; if the call options have a block number, the contract is loaded as it was at that block
+func (rp *RocketPool) GetContractForCall(contractName string, opts *bind.CallOpts) (*Contract, error) {
+	if opts == nil || opts.BlockNumber == nil {
+		return rp.GetContract(contractName, opts)
+	}
+	return rp.GetContractAtContext(GetCallContext(opts), contractName, opts.BlockNumber.Uint64())
+}
+
+// Set the number of blocks scanned per request when searching for events, like contract upgrades; nil scans any range in one request
+func (rp *RocketPool) SetEventLogInterval(interval *big.Int) {
+	rp.historiesLock.Lock()
+	defer rp.historiesLock.Unlock()
+	rp.eventLogInterval = interval
+}
+
+// Get the number of blocks scanned per request when searching for events
+func (rp *RocketPool) GetEventLogInterval() *big.Int {
+	rp.historiesLock.Lock()
+	defer rp.historiesLock.Unlock()
+	return rp.eventLogInterval
+}
+
+// Get the address a contract had at a block
+func (rp *RocketPool) getHistoricalAddress(ctx context.Context, contractName string, block uint64) (*common.Address, error) {
+
+	// Get the history
+	events, hasUpgradeContract, err := rp.getContractHistory(ctx, contractName)
+	if err != nil {
+		return nil, err
+	}
+	if !hasUpgradeContract {
+		// There's no upgrade contract, so the address can only come from RocketStorage at the block
+		address, err := rp.GetAddressContext(ctx, contractName, &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)})
+		if err != nil {
+			return nil, err
+		}
+		if *address == (common.Address{}) {
+			return nil, fmt.Errorf("%w: %s at block %d", ErrContractNotDeployed, contractName, block)
+		}
+		return address, nil
+	}
+
+	// Find the first change after the block
+	for _, event := range events {
+		if event.blockNumber <= block {
+			continue
+		}
+		if event.added {
+			return nil, fmt.Errorf("%w: %s was added at block %d, after block %d", ErrContractNotDeployed, contractName, event.blockNumber, block)
+		}
+		address := event.oldAddress
+		return &address, nil
+	}
+
+	// It hasn't changed since, so use the current address
+	address, err := rp.GetAddressContext(ctx, contractName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if *address == (common.Address{}) {
+		return nil, fmt.Errorf("%w: %s at block %d", ErrContractNotDeployed, contractName, block)
+	}
+	return address, nil
+
+}
+
+// Get the ABI of a contract that was live at an address
+func (rp *RocketPool) getHistoricalABI(ctx context.Context, contractName string, address common.Address, block uint64) (*abi.ABI, error) {
+
+	// Use the current ABI if it's the current contract
+	currentAddress, err := rp.GetAddressContext(ctx, contractName, nil)
+	if err != nil {
+		return nil, err
+	}
+	if *currentAddress == address {
+		return rp.GetABIContext(ctx, contractName, nil)
+	}
+
+	// Use the ABI of the legacy version that was deployed at the address
+	for _, wrapper := range rp.VersionManager.GetLegacyWrappers() {
+		legacyName, exists := wrapper.GetVersionedContractName(contractName)
+		if !exists {
+			continue
+		}
+		legacyAddress, err := rp.GetAddressContext(ctx, legacyName, nil)
+		if err != nil {
+			return nil, err
+		}
+		if *legacyAddress != address {
+			continue
+		}
+		contractAbi, err := DecodeAbi(wrapper.GetEncodedABI(contractName))
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode v%s contract %s ABI: %w", wrapper.GetVersion().String(), contractName, err)
+		}
+		return contractAbi, nil
+	}
+
+	// Fall back to the ABI in RocketStorage at the block
+	return rp.GetABIContext(ctx, contractName, &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)})
+
+}
+
+// Get the upgrade events of a contract up to the latest block, and whether there's an upgrade contract to get them from
+func (rp *RocketPool) getContractHistory(ctx context.Context, contractName string) ([]contractHistoryEvent, bool, error) {
+
+	// Get the upgrade contract
+	upgradeAddress, err := rp.GetAddressContext(ctx, UpgradeContractName, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if *upgradeAddress == (common.Address{}) {
+		return nil, false, nil
+	}
+	upgradeAbi, err := rp.GetABIContext(ctx, UpgradeContractName, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	upgradedEvent, exists := upgradeAbi.Events[string(ContractChangeUpgraded)]
+	if !exists {
+		return nil, false, fmt.Errorf("Upgrade contract has no %s event", ContractChangeUpgraded)
+	}
+	addedEvent, exists := upgradeAbi.Events[string(ContractChangeAdded)]
+	if !exists {
+		return nil, false, fmt.Errorf("Upgrade contract has no %s event", ContractChangeAdded)
+	}
+
+	// Get the range of blocks that haven't been scanned yet
+	history := rp.getContractHistoryEntry(contractName)
+	history.lock.Lock()
+	defer history.lock.Unlock()
+	latestBlock, err := rp.Client.BlockNumber(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not get latest block number: %w", err)
+	}
+	fromBlock := history.scannedTo + 1
+	if history.scannedTo == 0 {
+		deployBlock, err := rp.RocketStorage.GetUint(WithCallContext(ctx, nil), crypto.Keccak256Hash([]byte("deploy.block")))
+		if err != nil {
+			return nil, false, fmt.Errorf("Could not get deployment block: %w", err)
+		}
+		fromBlock = deployBlock.Uint64()
+	}
+	if fromBlock > latestBlock {
+		return history.events, true, nil
+	}
+
+	// Get the contract's events from every version of the upgrade contract
+	upgradeAddresses, err := rp.getUpgradeContractAddresses(ctx, *upgradeAddress)
+	if err != nil {
+		return nil, false, err
+	}
+	topics := [][]common.Hash{{upgradedEvent.ID, addedEvent.ID}, {crypto.Keccak256Hash([]byte(contractName))}}
+	logs, err := rp.GetLogsContext(ctx, upgradeAddresses, topics, rp.GetEventLogInterval(), new(big.Int).SetUint64(fromBlock), new(big.Int).SetUint64(latestBlock), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not get contract %s upgrade events: %w", contractName, err)
+	}
+
+	// Add them to the history; the events are replaced rather than modified, so callers can keep using the old ones
+	events := append([]contractHistoryEvent{}, history.events...)
+	for _, log := range logs {
+		if event, ok := getContractHistoryEvent(log, upgradedEvent.ID); ok {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].blockNumber != events[j].blockNumber {
+			return events[i].blockNumber < events[j].blockNumber
+		}
+		return events[i].logIndex < events[j].logIndex
+	})
+	history.events = events
+	history.scannedTo = latestBlock
+	return events, true, nil
+
+}
+
+// Get the history entry of a contract, creating it if it doesn't exist yet
+func (rp *RocketPool) getContractHistoryEntry(contractName string) *contractHistory {
+	rp.historiesLock.Lock()
+	defer rp.historiesLock.Unlock()
+	history, exists := rp.histories[contractName]
+	if !exists {
+		history = &contractHistory{}
+		rp.histories[contractName] = history
+	}
+	return history
+}
+
+// Get every address the upgrade contract has had that is recorded in RocketStorage, since older ones emitted the earlier events.
+// Previous addresses are found from the versioned names in the legacy version wrappers.
+func (rp *RocketPool) getUpgradeContractAddresses(ctx context.Context, currentAddress common.Address) ([]common.Address, error) {
+	addresses := []common.Address{currentAddress}
+	for _, wrapper := range rp.VersionManager.GetLegacyWrappers() {
+		legacyName, exists := wrapper.GetVersionedContractName(UpgradeContractName)
+		if !exists {
+			continue
+		}
+		legacyAddress, err := rp.GetAddressContext(ctx, legacyName, nil)
+		if err != nil {
+			return nil, err
+		}
+		if *legacyAddress != (common.Address{}) {
+			addresses = append(addresses, *legacyAddress)
+		}
+	}
+	return addresses, nil
+}
+
+// Parse a ContractUpgraded or ContractAdded event
+func getContractHistoryEvent(log types.Log, upgradedEventID common.Hash) (contractHistoryEvent, bool) {
+	if log.Removed {
+		return contractHistoryEvent{}, false
+	}
+	event := contractHistoryEvent{
+		added:       log.Topics[0] != upgradedEventID,
+		blockNumber: log.BlockNumber,
+		logIndex:    log.Index,
+	}
+	if event.added {
+		if len(log.Topics) < 3 {
+			return contractHistoryEvent{}, false
+		}
+		event.newAddress = common.BytesToAddress(log.Topics[2].Bytes())
+	} else {
+		if len(log.Topics) < 4 {
+			return contractHistoryEvent{}, false
+		}
+		event.oldAddress = common.BytesToAddress(log.Topics[2].Bytes())
+		event.newAddress = common.BytesToAddress(log.Topics[3].Bytes())
+	}
+	return event, true
+}
@@ -0,0 +1,88 @@
+package rocketpool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Gets the logs for a particular log request using the provided context, breaking the calls into batches of intervalSize blocks if it's set.
+// The block Rocket Pool was deployed on is used as the lower bound if fromBlock isn't set.
+func (rp *RocketPool) GetLogsContext(ctx context.Context, addressFilter []common.Address, topicFilter [][]common.Hash, intervalSize, fromBlock, toBlock *big.Int, blockHash *common.Hash) ([]types.Log, error) {
+	var logs []types.Log
+
+	// Get the block that Rocket Pool was deployed on as the lower bound if one wasn't specified
+	if fromBlock == nil {
+		var err error
+		deployBlockHash := crypto.Keccak256Hash([]byte("deploy.block"))
+		fromBlock, err = rp.RocketStorage.GetUint(WithCallContext(ctx, nil), deployBlockHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if intervalSize == nil {
+		// Handle unlimited intervals with a single call
+		logs, err := rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
+			Addresses: addressFilter,
+			Topics:    topicFilter,
+			FromBlock: fromBlock,
+			ToBlock:   toBlock,
+			BlockHash: blockHash,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return logs, nil
+	} else {
+		// Get the latest block
+		if toBlock == nil {
+			latestBlock, err := rp.Client.BlockNumber(ctx)
+			if err != nil {
+				return nil, err
+			}
+			toBlock = big.NewInt(0)
+			toBlock.SetUint64(latestBlock)
+		}
+
+		// Set the start and end, clamping on the latest block
+		intervalSize := big.NewInt(0).Sub(intervalSize, big.NewInt(1))
+		start := big.NewInt(0).Set(fromBlock)
+		end := big.NewInt(0).Add(start, intervalSize)
+		if end.Cmp(toBlock) == 1 {
+			end.Set(toBlock)
+		}
+		for {
+			// Get the logs using the current interval
+			newLogs, err := rp.Client.FilterLogs(ctx, ethereum.FilterQuery{
+				Addresses: addressFilter,
+				Topics:    topicFilter,
+				FromBlock: start,
+				ToBlock:   end,
+				BlockHash: blockHash,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Append the logs to the total list
+			logs = append(logs, newLogs...)
+
+			// Return once we've finished iterating
+			if end.Cmp(toBlock) == 0 {
+				return logs, nil
+			}
+
+			// Update to the next interval (end+1 : that + interval - 1)
+			start.Add(end, big.NewInt(1))
+			end.Add(start, intervalSize)
+			if end.Cmp(toBlock) == 1 {
+				end.Set(toBlock)
+			}
+		}
+	}
+}
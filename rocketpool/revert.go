@@ -0,0 +1,252 @@
+package rocketpool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Revert data selectors
+var (
+	errorStringSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector       = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// Names of the built-in Solidity revert errors
+const (
+	ErrorStringName string = "Error"
+	PanicName       string = "Panic"
+)
+
+// Descriptions of the Solidity panic codes
+var panicReasons = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop from an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an uninitialized internal function",
+}
+
+// The arguments for a Solidity panic
+var panicArgs = abi.Arguments{{Type: mustNewType("uint256")}}
+
+// A contract call or transaction that was reverted by the EVM
+type RevertError struct {
+	ContractName string        // The name of the contract, if known
+	Method       string        // The name of the method called, if known
+	ErrorName    string        // "Error" for revert strings, "Panic" for panics, or the name of a custom error
+	Reason       string        // A human-readable reason for the revert
+	Args         []interface{} // The decoded arguments of a custom error
+	PanicCode    *big.Int      // The code of a panic
+	Data         []byte        // The raw revert data
+	Err          error         // The error returned by the execution client
+}
+
+func (e *RevertError) Error() string {
+	var message strings.Builder
+	if e.ContractName != "" {
+		message.WriteString(e.ContractName)
+		if e.Method != "" {
+			message.WriteString(".")
+			message.WriteString(e.Method)
+		}
+		message.WriteString(": ")
+	} else if e.Method != "" {
+		message.WriteString(e.Method)
+		message.WriteString(": ")
+	}
+	message.WriteString("execution reverted")
+	if e.Reason != "" {
+		message.WriteString(": ")
+		message.WriteString(e.Reason)
+	}
+	return message.String()
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// Convert an execution client error into a *RevertError if it's a revert, decoding the revert data with the contract's ABI.
+// Errors that aren't reverts are returned unchanged.
+func DecodeRevertError(err error, contractName string, method string, contractAbi *abi.ABI) error {
+	if err == nil {
+		return nil
+	}
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) {
+		return err
+	}
+
+	// Get the revert data
+	data, hasData := getRevertData(err)
+	if !hasData && !isRevertMessage(err.Error()) {
+		return err
+	}
+	revertErr = &RevertError{
+		ContractName: contractName,
+		Method:       method,
+		Data:         data,
+		Err:          err,
+	}
+	decodeRevertData(revertErr, contractAbi)
+	return revertErr
+}
+
+// Create a *RevertError from raw revert data, such as the return data of a failed call made by another contract
+func NewRevertError(data []byte, contractName string, method string, contractAbi *abi.ABI) *RevertError {
+	revertErr := &RevertError{
+		ContractName: contractName,
+		Method:       method,
+		Data:         data,
+	}
+	decodeRevertData(revertErr, contractAbi)
+	return revertErr
+}
+
+// Decode revert data into a reason
+func decodeRevertData(revertErr *RevertError, contractAbi *abi.ABI) {
+	data := revertErr.Data
+
+	// Handle reverts without data
+	if len(data) == 0 {
+		return
+	}
+
+	// Handle data without a selector, which some clients return as the plain reason
+	if len(data) < 4 {
+		revertErr.Reason = getPrintableReason(data)
+		return
+	}
+
+	// Error(string)
+	selector := data[:4]
+	if bytes.Equal(selector, errorStringSelector) {
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revertErr.ErrorName = ErrorStringName
+			revertErr.Reason = reason
+			return
+		}
+	}
+
+	// Panic(uint256)
+	if bytes.Equal(selector, panicSelector) {
+		if values, err := panicArgs.Unpack(data[4:]); err == nil && len(values) == 1 {
+			code := values[0].(*big.Int)
+			revertErr.ErrorName = PanicName
+			revertErr.PanicCode = code
+			if description, exists := panicReasons[code.Uint64()]; code.IsUint64() && exists {
+				revertErr.Reason = fmt.Sprintf("panic: %s (0x%x)", description, code)
+			} else {
+				revertErr.Reason = fmt.Sprintf("panic: unknown code 0x%x", code)
+			}
+			return
+		}
+	}
+
+	// Custom errors
+	if contractAbi != nil {
+		for _, abiError := range contractAbi.Errors {
+			if !bytes.Equal(selector, abiError.ID[:4]) {
+				continue
+			}
+			values, err := abiError.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			revertErr.ErrorName = abiError.Name
+			revertErr.Args = values
+			revertErr.Reason = formatCustomError(abiError, values)
+			return
+		}
+	}
+
+	// Unknown data
+	revertErr.Reason = getPrintableReason(data)
+
+}
+
+// Get the revert data from an execution client error, if it has any
+func getRevertData(err error) ([]byte, bool) {
+
+	// Standard JSON-RPC error data
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		switch data := dataErr.ErrorData().(type) {
+		case string:
+			if decoded, err := hexutil.Decode(data); err == nil {
+				return decoded, true
+			}
+		case []byte:
+			return data, true
+		}
+	}
+
+	// Nethermind puts the revert data in the error message
+	reg := regexp.MustCompile(NethermindRevertRegex)
+	matches := reg.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return nil, false
+	}
+	messageIndex := reg.SubexpIndex("message")
+	if messageIndex == -1 {
+		return nil, false
+	}
+	decoded, decodeErr := hex.DecodeString(matches[messageIndex])
+	if decodeErr != nil {
+		return nil, false
+	}
+	return decoded, true
+
+}
+
+// Check whether an error message describes a revert
+func isRevertMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "execution reverted") ||
+		strings.Contains(message, "vm execution error") ||
+		strings.HasPrefix(message, "reverted")
+}
+
+// Format a decoded custom error like a Solidity call, e.g. "InsufficientBalance(100, 200)"
+func formatCustomError(abiError abi.Error, values []interface{}) string {
+	args := make([]string, len(values))
+	for i, value := range values {
+		args[i] = fmt.Sprint(value)
+	}
+	return fmt.Sprintf("%s(%s)", abiError.Name, strings.Join(args, ", "))
+}
+
+// Get a reason from revert data that couldn't be decoded, as text if it's printable or hex otherwise
+func getPrintableReason(data []byte) string {
+	text := strings.TrimRight(string(data), "\x00")
+	for _, r := range text {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return hexutil.Encode(data)
+		}
+	}
+	return text
+}
+
+// Create an ABI type, panicking if it's invalid
+func mustNewType(typeName string) abi.Type {
+	abiType, err := abi.NewType(typeName, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return abiType
+}
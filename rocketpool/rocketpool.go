@@ -1,9 +1,12 @@
 package rocketpool
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -16,7 +19,7 @@ import (
 )
 
 // Cache settings
-const CacheTTL = 300 // 5 minutes
+const CacheTTL = 300 // 5 minutes, by default
 
 // Cached data types
 type cachedAddress struct {
@@ -38,12 +41,20 @@ type RocketPool struct {
 	RocketStorage         *contracts.RocketStorage
 	RocketStorageContract *Contract
 	VersionManager        *VersionManager
+	transactionManager    TransactionManager
+	gasSettings           *GasSettings
+	bundle                *contractBundleState
+	cacheTTL              int64
+	histories             map[string]*contractHistory
+	eventLogInterval      *big.Int
 	addresses             map[string]cachedAddress
 	abis                  map[string]cachedABI
 	contracts             map[string]cachedContract
 	addressesLock         sync.RWMutex
 	abisLock              sync.RWMutex
 	contractsLock         sync.RWMutex
+	bundleLock            sync.RWMutex
+	historiesLock         sync.Mutex
 }
 
 // Create new contract manager
@@ -65,13 +76,19 @@ func NewRocketPool(client ExecutionClient, rocketStorageAddress common.Address)
 		Address:  &rocketStorageAddress,
 		ABI:      &rsAbi,
 		Client:   client,
+		Name:     "poolseaStorage",
 	}
+	gasSettings := NewDefaultGasSettings()
+	contract.GasSettings = gasSettings
 
 	// Create and return
 	rp := &RocketPool{
 		Client:                client,
 		RocketStorage:         rocketStorage,
 		RocketStorageContract: contract,
+		gasSettings:           gasSettings,
+		cacheTTL:              CacheTTL,
+		histories:             make(map[string]*contractHistory),
 		addresses:             make(map[string]cachedAddress),
 		abis:                  make(map[string]cachedABI),
 		contracts:             make(map[string]cachedContract),
@@ -84,11 +101,16 @@ func NewRocketPool(client ExecutionClient, rocketStorageAddress common.Address)
 
 // Load Rocket Pool contract addresses
 func (rp *RocketPool) GetAddress(contractName string, opts *bind.CallOpts) (*common.Address, error) {
+	return rp.GetAddressContext(GetCallContext(opts), contractName, opts)
+}
+
+// Load Rocket Pool contract addresses, using the provided context for network calls
+func (rp *RocketPool) GetAddressContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*common.Address, error) {
 
 	// Check for cached address
 	if opts == nil {
 		if cached, ok := rp.getCachedAddress(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
+			if time.Now().Unix()-cached.time <= rp.getCacheTTL() {
 				return cached.address, nil
 			} else {
 				rp.deleteCachedAddress(contractName)
@@ -96,8 +118,15 @@ func (rp *RocketPool) GetAddress(contractName string, opts *bind.CallOpts) (*com
 		}
 	}
 
+	// Check the contract bundle
+	if opts == nil {
+		if address, ok := rp.getBundledAddress(contractName); ok {
+			return address, nil
+		}
+	}
+
 	// Get address
-	address, err := rp.RocketStorage.GetAddress(opts, crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)))
+	address, err := rp.RocketStorage.GetAddress(WithCallContext(ctx, opts), crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)))
 	if err != nil {
 		return nil, fmt.Errorf("Could not load contract %s address: %w", contractName, err)
 	}
@@ -116,6 +145,9 @@ func (rp *RocketPool) GetAddress(contractName string, opts *bind.CallOpts) (*com
 }
 
 func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string) ([]*common.Address, error) {
+	return rp.GetAddressesContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetAddressesContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*common.Address, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -125,7 +157,7 @@ func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string)
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			address, err := rp.GetAddress(contractName, opts)
+			address, err := rp.GetAddressContext(ctx, contractName, opts)
 			if err == nil {
 				addresses[ci] = address
 			}
@@ -145,11 +177,16 @@ func (rp *RocketPool) GetAddresses(opts *bind.CallOpts, contractNames ...string)
 
 // Load Rocket Pool contract ABIs
 func (rp *RocketPool) GetABI(contractName string, opts *bind.CallOpts) (*abi.ABI, error) {
+	return rp.GetABIContext(GetCallContext(opts), contractName, opts)
+}
+
+// Load Rocket Pool contract ABIs, using the provided context for network calls
+func (rp *RocketPool) GetABIContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*abi.ABI, error) {
 
 	// Check for cached ABI
 	if opts == nil {
 		if cached, ok := rp.getCachedABI(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
+			if time.Now().Unix()-cached.time <= rp.getCacheTTL() {
 				return cached.abi, nil
 			} else {
 				rp.deleteCachedABI(contractName)
@@ -157,8 +194,15 @@ func (rp *RocketPool) GetABI(contractName string, opts *bind.CallOpts) (*abi.ABI
 		}
 	}
 
+	// Check the contract bundle
+	if opts == nil {
+		if abi, ok, err := rp.getBundledABI(contractName); ok {
+			return abi, err
+		}
+	}
+
 	// Get ABI
-	abiEncoded, err := rp.RocketStorage.GetString(opts, crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)))
+	abiEncoded, err := rp.RocketStorage.GetString(WithCallContext(ctx, opts), crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)))
 	if err != nil {
 		return nil, fmt.Errorf("Could not load contract %s ABI: %w", contractName, err)
 	}
@@ -182,6 +226,9 @@ func (rp *RocketPool) GetABI(contractName string, opts *bind.CallOpts) (*abi.ABI
 
 }
 func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*abi.ABI, error) {
+	return rp.GetABIsContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetABIsContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*abi.ABI, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -191,7 +238,7 @@ func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			abi, err := rp.GetABI(contractName, opts)
+			abi, err := rp.GetABIContext(ctx, contractName, opts)
 			if err == nil {
 				abis[ci] = abi
 			}
@@ -211,11 +258,16 @@ func (rp *RocketPool) GetABIs(opts *bind.CallOpts, contractNames ...string) ([]*
 
 // Load Rocket Pool contracts
 func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Contract, error) {
+	return rp.GetContractContext(GetCallContext(opts), contractName, opts)
+}
+
+// Load Rocket Pool contracts, using the provided context for network calls
+func (rp *RocketPool) GetContractContext(ctx context.Context, contractName string, opts *bind.CallOpts) (*Contract, error) {
 
 	// Check for cached contract
 	if opts == nil {
 		if cached, ok := rp.getCachedContract(contractName); ok {
-			if time.Now().Unix()-cached.time <= CacheTTL {
+			if time.Now().Unix()-cached.time <= rp.getCacheTTL() {
 				return cached.contract, nil
 			} else {
 				rp.deleteCachedContract(contractName)
@@ -231,12 +283,12 @@ func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Co
 	// Load data
 	wg.Go(func() error {
 		var err error
-		address, err = rp.GetAddress(contractName, opts)
+		address, err = rp.GetAddressContext(ctx, contractName, opts)
 		return err
 	})
 	wg.Go(func() error {
 		var err error
-		abi, err = rp.GetABI(contractName, opts)
+		abi, err = rp.GetABIContext(ctx, contractName, opts)
 		return err
 	})
 
@@ -247,14 +299,17 @@ func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Co
 
 	// Create contract
 	contract := &Contract{
-		Contract: bind.NewBoundContract(*address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(*address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               contractName,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}
 
 	// Cache contract
-	rp.setCachedContract(contractName, cachedContract{
+	rp.setCachedResolvedContract(contractName, cachedContract{
 		contract: contract,
 		time:     time.Now().Unix(),
 	})
@@ -264,6 +319,9 @@ func (rp *RocketPool) GetContract(contractName string, opts *bind.CallOpts) (*Co
 
 }
 func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string) ([]*Contract, error) {
+	return rp.GetContractsContext(GetCallContext(opts), opts, contractNames...)
+}
+func (rp *RocketPool) GetContractsContext(ctx context.Context, opts *bind.CallOpts, contractNames ...string) ([]*Contract, error) {
 
 	// Data
 	var wg errgroup.Group
@@ -273,7 +331,7 @@ func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string)
 	for ci, contractName := range contractNames {
 		ci, contractName := ci, contractName
 		wg.Go(func() error {
-			contract, err := rp.GetContract(contractName, opts)
+			contract, err := rp.GetContractContext(ctx, contractName, opts)
 			if err == nil {
 				contracts[ci] = contract
 			}
@@ -293,23 +351,119 @@ func (rp *RocketPool) GetContracts(opts *bind.CallOpts, contractNames ...string)
 
 // Create a Rocket Pool contract instance
 func (rp *RocketPool) MakeContract(contractName string, address common.Address, opts *bind.CallOpts) (*Contract, error) {
+	return rp.MakeContractContext(GetCallContext(opts), contractName, address, opts)
+}
+
+// Create a Rocket Pool contract instance, using the provided context for network calls
+func (rp *RocketPool) MakeContractContext(ctx context.Context, contractName string, address common.Address, opts *bind.CallOpts) (*Contract, error) {
 
 	// Load ABI
-	abi, err := rp.GetABI(contractName, opts)
+	abi, err := rp.GetABIContext(ctx, contractName, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create and return
 	return &Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               contractName,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}, nil
 
 }
 
+// Route every transaction sent by contracts created from now on through a transaction manager; nil sends them directly.
+// Contracts that were created before this call keep their previous routing, so cached contracts are cleared.
+func (rp *RocketPool) SetTransactionManager(manager TransactionManager) {
+	rp.transactionManager = manager
+	rp.RocketStorageContract.TransactionManager = manager
+	rp.contractsLock.Lock()
+	defer rp.contractsLock.Unlock()
+	rp.contracts = map[string]cachedContract{}
+}
+
+// Get the transaction manager that contracts route their transactions through, if any
+func (rp *RocketPool) GetTransactionManager() TransactionManager {
+	return rp.transactionManager
+}
+
+// Use new gas settings for contracts created from now on; nil uses the defaults.
+// The returned settings can also be modified in place, which affects every contract that uses them.
+// Contracts that were created before this call keep their previous settings, so cached contracts are cleared.
+func (rp *RocketPool) SetGasSettings(settings *GasSettings) {
+	if settings == nil {
+		settings = NewDefaultGasSettings()
+	}
+	rp.gasSettings = settings
+	rp.RocketStorageContract.GasSettings = settings
+	rp.contractsLock.Lock()
+	defer rp.contractsLock.Unlock()
+	rp.contracts = map[string]cachedContract{}
+}
+
+// Get the gas settings used by contracts
+func (rp *RocketPool) GetGasSettings() *GasSettings {
+	return rp.gasSettings
+}
+
+// Set how long contract addresses, ABIs and contracts are cached for
+func (rp *RocketPool) SetCacheTTL(ttl time.Duration) {
+	atomic.StoreInt64(&rp.cacheTTL, int64(ttl/time.Second))
+}
+
+// Get how long contract addresses, ABIs and contracts are cached for
+func (rp *RocketPool) GetCacheTTL() time.Duration {
+	return time.Duration(rp.getCacheTTL()) * time.Second
+}
+
+// Remove a contract's address, ABI and contract from the caches, so they're reloaded the next time they're used.
+// If the contract is in a bundle, it is resolved from the chain from then on.
+func (rp *RocketPool) InvalidateContract(contractName string) {
+	rp.dropBundledContract(contractName)
+	rp.deleteCachedAddress(contractName)
+	rp.deleteCachedABI(contractName)
+	rp.deleteCachedContract(contractName)
+}
+
+// Get the names of every contract with a cached address, ABI or contract, or in the bundle
+func (rp *RocketPool) getKnownContractNames() []string {
+	names := map[string]bool{}
+	rp.addressesLock.RLock()
+	for name := range rp.addresses {
+		names[name] = true
+	}
+	rp.addressesLock.RUnlock()
+	rp.abisLock.RLock()
+	for name := range rp.abis {
+		names[name] = true
+	}
+	rp.abisLock.RUnlock()
+	rp.contractsLock.RLock()
+	for name := range rp.contracts {
+		names[name] = true
+	}
+	rp.contractsLock.RUnlock()
+	if bundle := rp.GetContractBundle(); bundle != nil {
+		for name := range bundle.Contracts {
+			names[name] = true
+		}
+	}
+	nameList := make([]string, 0, len(names))
+	for name := range names {
+		nameList = append(nameList, name)
+	}
+	return nameList
+}
+
+// Get the cache TTL in seconds
+func (rp *RocketPool) getCacheTTL() int64 {
+	return atomic.LoadInt64(&rp.cacheTTL)
+}
+
 // Address cache control
 func (rp *RocketPool) getCachedAddress(contractName string) (cachedAddress, bool) {
 	rp.addressesLock.RLock()
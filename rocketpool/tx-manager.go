@@ -0,0 +1,14 @@
+package rocketpool
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Sends contract transactions on behalf of a RocketPool instance, e.g. to allocate nonces and track them until they're confirmed
+type TransactionManager interface {
+	// Send a transaction; send signs and submits it with the provided options
+	Send(ctx context.Context, opts *bind.TransactOpts, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error)
+}
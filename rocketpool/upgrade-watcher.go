@@ -0,0 +1,401 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Upgrade watcher settings
+const (
+	UpgradeContractName               = "poolseaDAONodeTrustedUpgrade"
+	DefaultWatchedCacheTTL            = time.Hour
+	DefaultUpgradeWatcherPollInterval = 12 * time.Second
+)
+
+// The kind of change made to a contract
+type ContractChangeType string
+
+const (
+	ContractChangeUpgraded    ContractChangeType = "ContractUpgraded"
+	ContractChangeAdded       ContractChangeType = "ContractAdded"
+	ContractChangeABIUpgraded ContractChangeType = "ABIUpgraded"
+	ContractChangeABIAdded    ContractChangeType = "ABIAdded"
+)
+
+// A change made to a contract by the upgrade contract
+type ContractChange struct {
+	Type        ContractChangeType
+	Name        string      // Empty if the name couldn't be matched to its hash
+	NameHash    common.Hash // The hash of the contract name, as emitted by the event
+	OldAddress  common.Address
+	NewAddress  common.Address
+	BlockNumber uint64
+	TxHash      common.Hash
+}
+
+// Called when a contract changes
+type ContractChangeHook func(change ContractChange)
+
+// Upgrade watcher settings; zero values are replaced with the defaults
+type UpgradeWatcherSettings struct {
+	CacheTTL     time.Duration // The cache TTL used while the watcher is running; the original TTL is restored while it can't watch for upgrades
+	PollInterval time.Duration // How often to check for upgrades if the client doesn't support subscriptions, and how long to wait after errors
+	OnError      func(error)   // Called when watching fails; the watcher keeps retrying until it's stopped
+}
+
+// Identifies an upgrade event by its position in the chain
+type upgradeLogID struct {
+	blockNumber uint64
+	logIndex    uint
+}
+
+// Watches the upgrade contract for contract upgrades and additions, invalidating the RocketPool caches for the contracts that changed
+type UpgradeWatcher struct {
+	rp        *RocketPool
+	settings  UpgradeWatcherSettings
+	hooks     map[int]contractChangeHook
+	nextHook  int
+	lastBlock uint64
+	baseTTL   time.Duration
+	cancel    context.CancelFunc
+	done      chan struct{}
+	lock      sync.Mutex
+}
+
+// A hook and the contracts it's notified about
+type contractChangeHook struct {
+	hook          ContractChangeHook
+	contractNames map[string]bool
+}
+
+// Create a new upgrade watcher
+func NewUpgradeWatcher(rp *RocketPool, settings UpgradeWatcherSettings) *UpgradeWatcher {
+	if settings.CacheTTL == 0 {
+		settings.CacheTTL = DefaultWatchedCacheTTL
+	}
+	if settings.PollInterval == 0 {
+		settings.PollInterval = DefaultUpgradeWatcherPollInterval
+	}
+	return &UpgradeWatcher{
+		rp:       rp,
+		settings: settings,
+		hooks:    map[int]contractChangeHook{},
+	}
+}
+
+// Add a hook that is called when any of the contracts changes, or when any contract changes if none are provided.
+// Returns a function that removes the hook.
+func (w *UpgradeWatcher) AddHook(hook ContractChangeHook, contractNames ...string) func() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	names := map[string]bool{}
+	for _, name := range contractNames {
+		names[name] = true
+	}
+	id := w.nextHook
+	w.nextHook++
+	w.hooks[id] = contractChangeHook{
+		hook:          hook,
+		contractNames: names,
+	}
+	return func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		delete(w.hooks, id)
+	}
+}
+
+// Start watching for upgrades in the background, from the latest block
+func (w *UpgradeWatcher) Start(ctx context.Context) error {
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.cancel != nil {
+		return errors.New("Upgrade watcher is already running")
+	}
+
+	// Make sure the upgrade contract can be loaded
+	if _, err := w.rp.GetContractContext(ctx, UpgradeContractName, nil); err != nil {
+		return fmt.Errorf("Could not load upgrade contract: %w", err)
+	}
+	latestBlock, err := w.rp.Client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("Could not get latest block number: %w", err)
+	}
+	w.lastBlock = latestBlock
+	w.baseTTL = w.rp.GetCacheTTL()
+
+	// Start watching
+	watchCtx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(watchCtx, w.done)
+	return nil
+
+}
+
+// Stop watching for upgrades and restore the cache TTL used before the watcher started
+func (w *UpgradeWatcher) Stop() {
+	w.lock.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel = nil
+	w.lock.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	w.rp.SetCacheTTL(w.baseTTL)
+}
+
+// Get the latest block that has been checked for upgrades
+func (w *UpgradeWatcher) GetLastBlock() uint64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.lastBlock
+}
+
+// Watch for upgrades until the context is cancelled, retrying after errors
+func (w *UpgradeWatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		err := w.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// The upgrade contract itself changed, so start watching the new one
+			continue
+		}
+
+		// Fall back to the original TTL until watching works again
+		w.rp.SetCacheTTL(w.baseTTL)
+		if w.settings.OnError != nil {
+			w.settings.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.settings.PollInterval):
+		}
+	}
+}
+
+// Watch the current upgrade contract, with a subscription if the client supports it or by polling otherwise.
+// Returns nil if the upgrade contract changed.
+func (w *UpgradeWatcher) watch(ctx context.Context) error {
+
+	// Get the upgrade contract's events
+	contract, err := w.rp.GetContractContext(ctx, UpgradeContractName, nil)
+	if err != nil {
+		return fmt.Errorf("Could not load upgrade contract: %w", err)
+	}
+	eventIDs := []common.Hash{}
+	for _, changeType := range []ContractChangeType{ContractChangeUpgraded, ContractChangeAdded, ContractChangeABIUpgraded, ContractChangeABIAdded} {
+		if event, exists := contract.ABI.Events[string(changeType)]; exists {
+			eventIDs = append(eventIDs, event.ID)
+		}
+	}
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{*contract.Address},
+		Topics:    [][]common.Hash{eventIDs},
+	}
+
+	// Subscribe first if possible, so events mined while catching up aren't missed
+	logs := make(chan types.Log)
+	subscription, err := w.rp.Client.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		if changed, err := w.poll(ctx, contract, query, nil); err != nil || changed {
+			return err
+		}
+		return w.pollContinuously(ctx, contract, query)
+	}
+	defer subscription.Unsubscribe()
+
+	// Catch up on anything missed since the last check; events delivered by both the catch-up and the subscription are handled once
+	handled := map[upgradeLogID]bool{}
+	if changed, err := w.poll(ctx, contract, query, handled); err != nil || changed {
+		return err
+	}
+	w.rp.SetCacheTTL(w.settings.CacheTTL)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-subscription.Err():
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return fmt.Errorf("Upgrade event subscription failed: %w", err)
+		case log := <-logs:
+			if markHandled(handled, log) && w.handleLog(contract, log) {
+				return nil
+			}
+		}
+	}
+
+}
+
+// Poll for upgrade events until the context is cancelled or the upgrade contract changes
+func (w *UpgradeWatcher) pollContinuously(ctx context.Context, contract *Contract, query ethereum.FilterQuery) error {
+	w.rp.SetCacheTTL(w.settings.CacheTTL)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.settings.PollInterval):
+		}
+		if changed, err := w.poll(ctx, contract, query, nil); err != nil || changed {
+			return err
+		}
+	}
+}
+
+// Handle the upgrade events since the last checked block, skipping any already in the handled set if there is one.
+// Returns true if the upgrade contract changed.
+func (w *UpgradeWatcher) poll(ctx context.Context, contract *Contract, query ethereum.FilterQuery, handled map[upgradeLogID]bool) (bool, error) {
+
+	// Get the block range
+	latestBlock, err := w.rp.Client.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("Could not get latest block number: %w", err)
+	}
+	fromBlock := w.GetLastBlock() + 1
+	if latestBlock < fromBlock {
+		return false, nil
+	}
+
+	// Get the events
+	query.FromBlock = new(big.Int).SetUint64(fromBlock)
+	query.ToBlock = new(big.Int).SetUint64(latestBlock)
+	logs, err := w.rp.Client.FilterLogs(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("Could not get upgrade events: %w", err)
+	}
+	w.setLastBlock(latestBlock)
+
+	// Handle them
+	changed := false
+	for _, log := range logs {
+		if markHandled(handled, log) && w.handleLog(contract, log) {
+			changed = true
+		}
+	}
+	return changed, nil
+
+}
+
+// Invalidate the caches for the contract changed by an upgrade event and notify hooks.
+// Returns true if the upgrade contract itself changed.
+func (w *UpgradeWatcher) handleLog(contract *Contract, log types.Log) bool {
+
+	// Get the change
+	w.setLastBlock(log.BlockNumber)
+	if len(log.Topics) < 2 {
+		return false
+	}
+	event, err := contract.ABI.EventByID(log.Topics[0])
+	if err != nil {
+		return false
+	}
+	change := ContractChange{
+		Type:        ContractChangeType(event.Name),
+		NameHash:    log.Topics[1],
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+	}
+	switch change.Type {
+	case ContractChangeUpgraded:
+		if len(log.Topics) > 3 {
+			change.OldAddress = common.BytesToAddress(log.Topics[2].Bytes())
+			change.NewAddress = common.BytesToAddress(log.Topics[3].Bytes())
+		}
+	case ContractChangeAdded:
+		if len(log.Topics) > 2 {
+			change.NewAddress = common.BytesToAddress(log.Topics[2].Bytes())
+		}
+	}
+
+	// Find the contract name and invalidate it
+	change.Name = w.getContractName(change.NameHash)
+	if change.Name != "" {
+		w.rp.InvalidateContract(change.Name)
+	}
+
+	// Notify hooks
+	for _, hook := range w.getHooks(change.Name) {
+		hook(change)
+	}
+	return change.Name == UpgradeContractName
+
+}
+
+// Find the contract name with a hash among the contracts the RocketPool instance and the hooks know about
+func (w *UpgradeWatcher) getContractName(nameHash common.Hash) string {
+	names := w.rp.getKnownContractNames()
+	names = append(names, UpgradeContractName)
+	w.lock.Lock()
+	for _, hook := range w.hooks {
+		for name := range hook.contractNames {
+			names = append(names, name)
+		}
+	}
+	w.lock.Unlock()
+	for _, name := range names {
+		if crypto.Keccak256Hash([]byte(name)) == nameHash {
+			return name
+		}
+	}
+	return ""
+}
+
+// Get the hooks to notify about a change to a contract, in the order they were added
+func (w *UpgradeWatcher) getHooks(contractName string) []ContractChangeHook {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	ids := make([]int, 0, len(w.hooks))
+	for id := range w.hooks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	hooks := []ContractChangeHook{}
+	for _, id := range ids {
+		hook := w.hooks[id]
+		if len(hook.contractNames) == 0 || hook.contractNames[contractName] {
+			hooks = append(hooks, hook.hook)
+		}
+	}
+	return hooks
+}
+
+// Record that a block has been checked for upgrades
+func (w *UpgradeWatcher) setLastBlock(block uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if block > w.lastBlock {
+		w.lastBlock = block
+	}
+}
+
+// Add a log to a handled set, returning false if it was already there; a nil set handles every log
+func markHandled(handled map[upgradeLogID]bool, log types.Log) bool {
+	if handled == nil {
+		return true
+	}
+	id := upgradeLogID{blockNumber: log.BlockNumber, logIndex: log.Index}
+	if handled[id] {
+		return false
+	}
+	handled[id] = true
+	return true
+}
@@ -42,10 +42,12 @@ func GetContractVersion(rp *RocketPool, contractAddress common.Address, opts *bi
 
 	// Create contract
 	contract := &Contract{
-		Contract: bind.NewBoundContract(contractAddress, *versionAbi, rp.Client, rp.Client, rp.Client),
-		Address:  &contractAddress,
-		ABI:      versionAbi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(contractAddress, *versionAbi, rp.Client, rp.Client, rp.Client),
+		Address:            &contractAddress,
+		ABI:                versionAbi,
+		Client:             rp.Client,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}
 
 	// Get the contract version
@@ -69,9 +71,11 @@ func GetRocketVersionContractForAddress(rp *RocketPool, address common.Address)
 	}
 
 	return &Contract{
-		Contract: bind.NewBoundContract(address, *versionAbi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      versionAbi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *versionAbi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                versionAbi,
+		Client:             rp.Client,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}, nil
 }
@@ -2,6 +2,8 @@ package rocketpool
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -24,7 +26,9 @@ type VersionManager struct {
 	V1_1_0_RC1 LegacyVersionWrapper
 	V1_1_0     LegacyVersionWrapper
 
-	rp *RocketPool
+	rp        *RocketPool
+	extra     []LegacyVersionWrapper
+	extraLock sync.RWMutex
 }
 
 func NewVersionManager(rp *RocketPool) *VersionManager {
@@ -36,6 +40,25 @@ func NewVersionManager(rp *RocketPool) *VersionManager {
 	}
 }
 
+// Add a wrapper for another legacy version, so its ABIs can be used to resolve historical contracts
+func (m *VersionManager) RegisterLegacyWrapper(wrapper LegacyVersionWrapper) {
+	m.extraLock.Lock()
+	defer m.extraLock.Unlock()
+	m.extra = append(m.extra, wrapper)
+}
+
+// Get every legacy version wrapper, oldest version first
+func (m *VersionManager) GetLegacyWrappers() []LegacyVersionWrapper {
+	m.extraLock.RLock()
+	wrappers := []LegacyVersionWrapper{m.V1_0_0, m.V1_1_0_RC1, m.V1_1_0}
+	wrappers = append(wrappers, m.extra...)
+	m.extraLock.RUnlock()
+	sort.SliceStable(wrappers, func(i, j int) bool {
+		return wrappers[i].GetVersion().LessThan(wrappers[j].GetVersion())
+	})
+	return wrappers
+}
+
 // Get the contract with the provided name and version wrapper
 func getLegacyContract(rp *RocketPool, contractName string, m LegacyVersionWrapper, opts *bind.CallOpts) (*Contract, error) {
 
@@ -47,7 +70,7 @@ func getLegacyContract(rp *RocketPool, contractName string, m LegacyVersionWrapp
 
 	// Check for cached contract
 	if cached, ok := rp.getCachedContract(legacyName); ok {
-		if time.Now().Unix()-cached.time <= CacheTTL {
+		if time.Now().Unix()-cached.time <= rp.getCacheTTL() {
 			return cached.contract, nil
 		} else {
 			rp.deleteCachedContract(legacyName)
@@ -74,10 +97,13 @@ func getLegacyContract(rp *RocketPool, contractName string, m LegacyVersionWrapp
 	}
 
 	contract := &Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               contractName,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}
 
 	return contract, nil
@@ -94,10 +120,13 @@ func getLegacyContractWithAddress(rp *RocketPool, contractName string, address c
 	}
 
 	contract := &Contract{
-		Contract: bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
-		Address:  &address,
-		ABI:      abi,
-		Client:   rp.Client,
+		Contract:           bind.NewBoundContract(address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               contractName,
+		TransactionManager: rp.transactionManager,
+		GasSettings:        rp.gasSettings,
 	}
 
 	return contract, nil
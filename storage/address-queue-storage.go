@@ -19,8 +19,8 @@ func GetAddressQueueLength(rp *rocketpool.RocketPool, opts *bind.CallOpts, key [
 		return 0, err
 	}
 	length := new(*big.Int)
-	if err := addressQueueStorage.Call(opts, length, "getIndexOf", key); err != nil {
-		return 0, fmt.Errorf("Could not get address queue length for key: %w", key, err)
+	if err := addressQueueStorage.Call(opts, length, "getLength", key); err != nil {
+		return 0, fmt.Errorf("Could not get address queue length for key %x: %w", key, err)
 	}
 	return (*length).Uint64(), nil
 }
@@ -33,7 +33,7 @@ func GetAddressQueueItem(rp *rocketpool.RocketPool, opts *bind.CallOpts, key [32
 	}
 	address := new(common.Address)
 	if err := addressQueueStorage.Call(opts, address, "getItem", key, index); err != nil {
-		return common.Address{}, fmt.Errorf("Could not get address item at index %d: %w", index, key, err)
+		return common.Address{}, fmt.Errorf("Could not get address item at index %d for key %x: %w", index, key, err)
 	}
 	return *address, nil
 }
@@ -154,10 +154,10 @@ func TestLotDetails(t *testing.T) {
 	}
 
 	// Set network parameters
-	if _, err := network.SubmitPrices(rp, 1, eth.EthToWei(1), eth.EthToWei(24), trustedNodeAccount1.GetTransactor()); err != nil {
+	if _, err := network.SubmitPrices(rp, 1, eth.EthToWei(1), trustedNodeAccount1.GetTransactor()); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := network.SubmitPrices(rp, 1, eth.EthToWei(1), eth.EthToWei(24), trustedNodeAccount2.GetTransactor()); err != nil {
+	if _, err := network.SubmitPrices(rp, 1, eth.EthToWei(1), trustedNodeAccount2.GetTransactor()); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := protocol.BootstrapLotStartingPriceRatio(rp, 1.0, ownerAccount.GetTransactor()); err != nil {
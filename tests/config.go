@@ -1,13 +1,29 @@
 package tests
 
-// Contract addresses and account private keys are based on the following mnemonic:
+// Account private keys are based on the following mnemonic:
 // jungle neck govern chief unaware rubber frequent tissue service license alcohol velvet
 
+// The chain ID of the simulated chain the tests run against
+const ChainID = 1337
+
+// The RocketStorage address used by tests that stub or record the execution client
+const RocketStorageAddress = "0x70a5F2eB9e4C003B105399b471DAeDbC8d00B1c5"
+
+// The environment variable holding the path to a directory of compiled Poolsea contract artifacts,
+// used to deploy the contracts to an in-process simulated chain
+const ContractBundlePathEnvVar = "POOLSEA_CONTRACT_BUNDLE"
+
+// The environment variables naming an execution client, RocketStorage address and comma-separated legacy minipool addresses
+// to record the legacy minipool delegate shares from
 const (
-	Eth1ProviderAddress  = "http://127.0.0.1:8545"
-	RocketStorageAddress = "0x70a5F2eB9e4C003B105399b471DAeDbC8d00B1c5"
+	ShareProviderEnvVar       = "POOLSEA_SHARE_PROVIDER"
+	ShareStorageAddressEnvVar = "POOLSEA_SHARE_STORAGE_ADDRESS"
+	ShareMinipoolsEnvVar      = "POOLSEA_SHARE_MINIPOOLS"
 )
 
+// The environment variable that makes tests re-record their execution client fixtures before replaying them
+const RecordFixturesEnvVar = "POOLSEA_RECORD_FIXTURES"
+
 const (
 	ValidatorPubkey     = "968bcf4081af4a10d054c1cde1dadfd6e85a120a397174173ca869f66bdc72835f9918ea251930778e5ba67a7907e30e"
 	ValidatorPubkey2    = "968bcf4081af4a10d054c1cde1dadfd6e85a120a397174173ca869f66bdc72835f9918ea251930778e5ba67a7907e30d"
@@ -1,21 +1,20 @@
 package minipool
 
 import (
+	"errors"
 	"log"
 	"os"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-
 	"github.com/RedDuck-Software/poolsea-go/rocketpool"
 
-	"github.com/RedDuck-Software/poolsea-go/tests"
 	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/evm"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
 )
 
 var (
-	client *ethclient.Client
+	client *simulated.Client
 	rp     *rocketpool.RocketPool
 
 	ownerAccount       *accounts.Account
@@ -28,17 +27,17 @@ var (
 func TestMain(m *testing.M) {
 	var err error
 
-	// Initialize eth client
-	client, err = ethclient.Dial(tests.Eth1ProviderAddress)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Initialize contract manager
-	rp, err = rocketpool.NewRocketPool(client, common.HexToAddress(tests.RocketStorageAddress))
-	if err != nil {
+	// Start a simulated chain with the compiled Poolsea contracts
+	chain, err := simulated.NewChainFromEnv()
+	if errors.Is(err, simulated.ErrNoContractBundle) {
+		log.Printf("Skipping tests: %s", err)
+		os.Exit(0)
+	} else if err != nil {
 		log.Fatal(err)
 	}
+	evm.UseChain(chain)
+	client = chain.Client
+	rp = chain.RocketPool
 
 	// Initialize accounts
 	ownerAccount, err = accounts.GetAccount(0)
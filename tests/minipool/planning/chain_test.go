@@ -0,0 +1,141 @@
+package planning
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// The address of the fake RocketStorage
+var storageAddress = common.HexToAddress("0x1000000000000000000000000000000000000000")
+
+// A method handler of a fake contract, which returns the method's outputs for its inputs
+type fakeMethod func(args []interface{}) []interface{}
+
+// A contract served by the fake chain
+type fakeContract struct {
+	abi     abi.ABI
+	methods map[string]fakeMethod
+}
+
+// A chain of fake contracts served by a stub client, with a RocketStorage that resolves them by name
+type fakeChain struct {
+	storageAbi abi.ABI
+	addresses  map[common.Hash]common.Address
+	strings    map[common.Hash]string
+	contracts  map[common.Address]*fakeContract
+	lock       sync.Mutex
+}
+
+// Create a fake chain
+func newFakeChain(t *testing.T) *fakeChain {
+	storageAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeChain{
+		storageAbi: storageAbi,
+		addresses:  map[common.Hash]common.Address{},
+		strings:    map[common.Hash]string{},
+		contracts:  map[common.Address]*fakeContract{},
+	}
+}
+
+// Add a contract to the chain, registering it with the storage if it has a name
+func (c *fakeChain) deploy(t *testing.T, contractName string, address common.Address, abiString string, methods map[string]fakeMethod) {
+	t.Helper()
+	contractAbi, err := abi.JSON(strings.NewReader(abiString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.contracts[address] = &fakeContract{abi: contractAbi, methods: methods}
+	if contractName == "" {
+		return
+	}
+	encodedAbi, err := rocketpool.EncodeAbiStr(abiString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.addresses[crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName))] = address
+	c.strings[crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName))] = encodedAbi
+}
+
+// Create a contract manager for the chain
+func (c *fakeChain) rocketPool(t *testing.T) *rocketpool.RocketPool {
+	t.Helper()
+	rp, err := rocketpool.NewRocketPool(c.client(), storageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// Get a client that serves the chain
+func (c *fakeChain) client() *stub.Client {
+	return &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if call.To == nil || len(call.Data) < 4 {
+				return nil, stub.ErrNotImplemented
+			}
+
+			// Resolve contracts from the storage
+			if *call.To == storageAddress {
+				method, err := c.storageAbi.MethodById(call.Data[:4])
+				if err != nil {
+					return nil, err
+				}
+				var key common.Hash
+				copy(key[:], call.Data[4:36])
+				switch method.Name {
+				case "getAddress":
+					return method.Outputs.Pack(c.addresses[key])
+				case "getString":
+					return method.Outputs.Pack(c.strings[key])
+				}
+				return nil, stub.ErrNotImplemented
+			}
+
+			// Call the contract's handler
+			contract, exists := c.contracts[*call.To]
+			if !exists {
+				return nil, nil
+			}
+			method, err := contract.abi.MethodById(call.Data[:4])
+			if err != nil {
+				return nil, err
+			}
+			handler, exists := contract.methods[method.Name]
+			if !exists {
+				return nil, stub.ErrNotImplemented
+			}
+			args, err := method.Inputs.Unpack(call.Data[4:])
+			if err != nil {
+				return nil, err
+			}
+			return method.Outputs.Pack(handler(args)...)
+		},
+	}
+}
+
+// Get a method handler that always returns the given values
+func returns(values ...interface{}) fakeMethod {
+	return func(args []interface{}) []interface{} {
+		return values
+	}
+}
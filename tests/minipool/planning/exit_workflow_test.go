@@ -0,0 +1,177 @@
+package planning
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// Check the planned steps of an exit workflow
+func checkExitSteps(t *testing.T, name string, workflow minipool.ExitWorkflow, complete bool, expected ...minipool.ExitStepType) {
+	t.Helper()
+	if workflow.Complete != complete {
+		t.Errorf("%s: incorrect completeness %t (%s)", name, workflow.Complete, workflow.WaitingReason)
+	}
+	if len(workflow.Steps) != len(expected) {
+		t.Errorf("%s: incorrect step count %d, expected %v", name, len(workflow.Steps), expected)
+		return
+	}
+	for i, step := range workflow.Steps {
+		if step.Type != expected[i] {
+			t.Errorf("%s: incorrect step %d %s, expected %s", name, i, step.Type, expected[i])
+		}
+	}
+}
+
+func TestPlanExitSteps(t *testing.T) {
+
+	mpAddress := common.HexToAddress("0x0000000000000000000000000000000000000011")
+	distributorAddress := common.HexToAddress("0x0000000000000000000000000000000000000021")
+	state := minipool.ExitWorkflowState{
+		MinipoolAddress:    mpAddress,
+		Version:            3,
+		Status:             types.Staking,
+		Balance:            eth.EthToWei(32.5),
+		NodeRefundBalance:  big.NewInt(0),
+		DistributorAddress: distributorAddress,
+		DistributorBalance: eth.EthToWei(0.2),
+	}
+
+	// A v3 minipool with a full withdrawal distributes its balance, then the fee distributor is distributed
+	workflow := minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v3 full withdrawal", workflow, true, minipool.ExitStepDistributeBalance, minipool.ExitStepDistributeFees)
+	if workflow.Steps[0].RewardsOnly || workflow.Steps[0].Target != mpAddress || workflow.Steps[1].Target != distributorAddress {
+		t.Errorf("Incorrect full withdrawal steps %+v, %+v", workflow.Steps[0], workflow.Steps[1])
+	}
+
+	// Below the threshold, only the rewards can be distributed
+	state.Balance = eth.EthToWei(1.5)
+	state.NodeRefundBalance = eth.EthToWei(1)
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v3 rewards", workflow, false, minipool.ExitStepDistributeBalance, minipool.ExitStepDistributeFees)
+	if !workflow.Steps[0].RewardsOnly {
+		t.Error("Rewards distribution isn't rewards-only")
+	}
+	state.Balance = eth.EthToWei(1)
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v3 refund", workflow, false, minipool.ExitStepRefund, minipool.ExitStepDistributeFees)
+
+	// User distributions leave the node's share to finalise
+	state.UserDistributed = true
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v3 user distributed", workflow, true, minipool.ExitStepFinalise, minipool.ExitStepDistributeFees)
+
+	// Finalised minipools only have the fee distributor left, and an empty distributor is skipped
+	state.Finalised = true
+	state.DistributorBalance = big.NewInt(0)
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v3 finalised", workflow, true)
+
+	// Dissolved minipools are closed
+	state = minipool.ExitWorkflowState{
+		MinipoolAddress: mpAddress,
+		Version:         3,
+		Status:          types.Dissolved,
+		Balance:         eth.EthToWei(1),
+	}
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "dissolved", workflow, true, minipool.ExitStepClose)
+
+	// v2 minipools have to be marked withdrawable first
+	state = minipool.ExitWorkflowState{
+		MinipoolAddress: mpAddress,
+		Version:         2,
+		Status:          types.Staking,
+		Balance:         eth.EthToWei(32),
+	}
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v2 staking", workflow, false)
+	state.Status = types.Withdrawable
+	workflow = minipool.PlanExitSteps(state)
+	checkExitSteps(t, "v2 withdrawable", workflow, true, minipool.ExitStepDistributeBalanceAndFinalise)
+
+}
+
+func TestExecuteExitWorkflowWithoutTransactor(t *testing.T) {
+
+	// Workflows can't be run without a transactor, and nothing is reported as sent
+	workflow := minipool.PlanExitSteps(minipool.ExitWorkflowState{
+		MinipoolAddress: common.HexToAddress("0x0000000000000000000000000000000000000011"),
+		Version:         3,
+		Status:          types.Dissolved,
+		Balance:         eth.EthToWei(1),
+	})
+	reported := false
+	err := workflow.Execute(nil, nil, func(*minipool.ExitWorkflow) error {
+		reported = true
+		return nil
+	})
+	if err == nil || reported || workflow.Steps[0].TxHash != (common.Hash{}) {
+		t.Errorf("Expected error without a transactor, got %v (reported %t)", err, reported)
+	}
+
+}
+
+func TestExecuteExitWorkflowFailedStep(t *testing.T) {
+
+	// Resume a workflow whose step was sent, on a chain where its transaction failed
+	workflow := minipool.PlanExitSteps(minipool.ExitWorkflowState{
+		MinipoolAddress: common.HexToAddress("0x0000000000000000000000000000000000000011"),
+		Version:         3,
+		Status:          types.Dissolved,
+		Balance:         eth.EthToWei(1),
+	})
+	tx := ethtypes.NewTx(&ethtypes.DynamicFeeTx{Nonce: 4})
+	nonce := tx.Nonce()
+	workflow.Steps[0].TxHash = tx.Hash()
+	workflow.Steps[0].Nonce = &nonce
+	client := &stub.Client{
+		TransactionByHashFunc: func(ctx context.Context, hash common.Hash) (*ethtypes.Transaction, bool, error) {
+			return tx, false, nil
+		},
+		TransactionReceiptFunc: func(ctx context.Context, hash common.Hash) (*ethtypes.Receipt, error) {
+			return &ethtypes.Receipt{Status: ethtypes.ReceiptStatusFailed, TxHash: hash, BlockNumber: big.NewInt(1)}, nil
+		},
+	}
+	rp, err := rocketpool.NewRocketPool(client, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &bind.TransactOpts{From: common.HexToAddress("0x0000000000000000000000000000000000000022")}
+
+	// The failure is recorded on the step and reported
+	reported := 0
+	progress := func(*minipool.ExitWorkflow) error {
+		reported++
+		return nil
+	}
+	if err := workflow.Execute(rp, opts, progress); err == nil {
+		t.Error("Expected error for a failed step")
+	}
+	step := workflow.Steps[0]
+	if !step.Failed || step.Done || step.TxHash != tx.Hash() || reported != 1 {
+		t.Errorf("Incorrect failed step %+v (reported %d times)", step, reported)
+	}
+
+	// Failed steps aren't sent again
+	client.TransactionByHashFunc = nil
+	if err := workflow.Execute(rp, opts, progress); err == nil {
+		t.Error("Expected error for a failed step on resume")
+	}
+	if step.TxHash != tx.Hash() || reported != 1 {
+		t.Errorf("Failed step was changed on resume: %+v", step)
+	}
+
+}
@@ -0,0 +1,186 @@
+package planning
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/settings/protocol"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+)
+
+// Check the estimated assignments of a queue simulation
+func checkQueueEstimates(t *testing.T, name string, estimates []minipool.QueueAssignmentEstimate, currentTime time.Time, interval time.Duration, expectedDeposits ...int) {
+	t.Helper()
+	if len(estimates) != len(expectedDeposits) {
+		t.Fatalf("%s: incorrect estimate count %d, expected %d", name, len(estimates), len(expectedDeposits))
+	}
+	for i, estimate := range estimates {
+		if expectedDeposits[i] < 0 {
+			if estimate.Assignable {
+				t.Errorf("%s: position %d shouldn't be assignable, but is assigned after %d deposits", name, estimate.Position, estimate.DepositsRequired)
+			}
+			continue
+		}
+		expectedTime := currentTime.Add(time.Duration(expectedDeposits[i]) * interval)
+		if !estimate.Assignable || estimate.DepositsRequired != uint64(expectedDeposits[i]) || !estimate.AssignmentTime.Equal(expectedTime) {
+			t.Errorf("%s: position %d assigned after %d deposits at %s (assignable %t), expected %d deposits", name, estimate.Position, estimate.DepositsRequired, estimate.AssignmentTime, estimate.Assignable, expectedDeposits[i])
+		}
+	}
+}
+
+func TestSimulateQueue(t *testing.T) {
+
+	// Two legacy minipools ahead of three Atlas minipools
+	newEntry := func(address string, depositType types.MinipoolDeposit, amount float64) minipool.QueueEntry {
+		return minipool.QueueEntry{
+			MinipoolAddress:   common.HexToAddress(address),
+			DepositType:       depositType,
+			UserDepositAmount: eth.EthToWei(amount),
+		}
+	}
+	currentTime := time.Unix(1_700_000_000, 0)
+	input := minipool.QueueSimulationInput{
+		Entries: []minipool.QueueEntry{
+			newEntry("0x0000000000000000000000000000000000000011", types.Half, 16),
+			newEntry("0x0000000000000000000000000000000000000012", types.Full, 16),
+			newEntry("0x0000000000000000000000000000000000000013", types.Variable, 24),
+			newEntry("0x0000000000000000000000000000000000000014", types.Variable, 24),
+			newEntry("0x0000000000000000000000000000000000000015", types.Variable, 24),
+		},
+		DepositPoolBalance:        eth.EthToWei(20),
+		AssignDepositsEnabled:     true,
+		MaximumDepositAssignments: 2,
+		Inflow: minipool.QueueInflowModel{
+			DepositAmount:   eth.EthToWei(10),
+			DepositInterval: time.Hour,
+		},
+	}
+
+	// The ETH required is the total ahead of each position minus the current balance
+	estimates := minipool.SimulateQueue(input, currentTime)
+	for i, expected := range []float64{0, 12, 36, 60, 84} {
+		if estimates[i].Position != uint64(i+1) || estimates[i].EthRequired.Cmp(eth.EthToWei(expected)) != 0 {
+			t.Errorf("Incorrect ETH required for position %d: %s, expected %.0f ETH", estimates[i].Position, estimates[i].EthRequired, expected)
+		}
+	}
+
+	// The legacy queues are cleared in their own rounds before the Atlas queue
+	checkQueueEstimates(t, "inflow", estimates, currentTime, time.Hour, 0, 2, 4, 6, 9)
+	if estimates[4].TimeUntilAssignment != 9*time.Hour {
+		t.Errorf("Incorrect time until assignment %s", estimates[4].TimeUntilAssignment)
+	}
+
+	// The assignment limit holds back minipools the balance already covers
+	input.Entries = input.Entries[2:]
+	input.DepositPoolBalance = eth.EthToWei(100)
+	input.Inflow.DepositAmount = eth.EthToWei(1)
+	checkQueueEstimates(t, "assignment limit", minipool.SimulateQueue(input, currentTime), currentTime, time.Hour, 0, 0, 1)
+
+	// Without inflow, only the minipools the balance covers are assigned
+	input.DepositPoolBalance = eth.EthToWei(50)
+	input.Inflow.DepositAmount = nil
+	checkQueueEstimates(t, "no inflow", minipool.SimulateQueue(input, currentTime), currentTime, time.Hour, 0, 0, -1)
+
+	// Nothing is assigned while assignments are disabled
+	input.AssignDepositsEnabled = false
+	checkQueueEstimates(t, "disabled", minipool.SimulateQueue(input, currentTime), currentTime, time.Hour, -1, -1, -1)
+
+	// Minipools loaded from the chain are only queued once, even though the Atlas queue contract's totals include the legacy queues
+	legacyEntries := []minipool.QueueEntry{
+		newEntry("0x0000000000000000000000000000000000000011", types.Half, 16),
+		newEntry("0x0000000000000000000000000000000000000012", types.Full, 16),
+	}
+	chain := newQueueChain(t, legacyEntries, []common.Address{
+		common.HexToAddress("0x0000000000000000000000000000000000000013"),
+		common.HexToAddress("0x0000000000000000000000000000000000000014"),
+	})
+	loaded, err := minipool.GetQueueSimulationInput(chain.rocketPool(t), legacyEntries, minipool.QueueInflowModel{DepositAmount: eth.EthToWei(10), DepositInterval: time.Hour}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Entries) != 4 {
+		t.Fatalf("Incorrect loaded queue length %d, expected 4", len(loaded.Entries))
+	}
+	for i, expected := range []minipool.QueueEntry{
+		legacyEntries[0],
+		legacyEntries[1],
+		newEntry("0x0000000000000000000000000000000000000013", types.Variable, 24),
+		newEntry("0x0000000000000000000000000000000000000014", types.Variable, 24),
+	} {
+		entry := loaded.Entries[i]
+		if entry.MinipoolAddress != expected.MinipoolAddress || entry.DepositType != expected.DepositType || entry.UserDepositAmount.Cmp(expected.UserDepositAmount) != 0 {
+			t.Errorf("Incorrect loaded entry %d %s (%s, %s), expected %s", i, entry.MinipoolAddress.Hex(), entry.DepositType, entry.UserDepositAmount, expected.MinipoolAddress.Hex())
+		}
+	}
+	checkQueueEstimates(t, "loaded", minipool.SimulateQueue(loaded, currentTime), currentTime, time.Hour, 0, 2, 4, 6)
+
+}
+
+// Create a chain with minipools in the legacy and variable queues, where the Atlas queue contract reports them all like it does on chain
+func newQueueChain(t *testing.T, legacyEntries []minipool.QueueEntry, variableQueue []common.Address) *fakeChain {
+	chain := newFakeChain(t)
+	queues := map[common.Hash][]common.Address{
+		crypto.Keccak256Hash([]byte("minipools.available.variable")): variableQueue,
+	}
+	all := []common.Address{}
+	for _, entry := range legacyEntries {
+		key := crypto.Keccak256Hash([]byte("minipools.available." + strings.ToLower(entry.DepositType.String())))
+		queues[key] = append(queues[key], entry.MinipoolAddress)
+		all = append(all, entry.MinipoolAddress)
+	}
+	all = append(all, variableQueue...)
+
+	// Queue contracts
+	chain.deploy(t, "addressQueueStorage", common.HexToAddress("0x2000000000000000000000000000000000000001"), addressQueueStorageAbi, map[string]fakeMethod{
+		"getLength": func(args []interface{}) []interface{} {
+			return []interface{}{big.NewInt(int64(len(queues[args[0].([32]byte)])))}
+		},
+		"getItem": func(args []interface{}) []interface{} {
+			return []interface{}{queues[args[0].([32]byte)][args[1].(*big.Int).Int64()]}
+		},
+	})
+	chain.deploy(t, "poolseaMinipoolQueue", common.HexToAddress("0x2000000000000000000000000000000000000002"), minipoolQueueAbi, map[string]fakeMethod{
+		"getTotalLength": returns(big.NewInt(int64(len(all)))),
+		"getMinipoolAt": func(args []interface{}) []interface{} {
+			return []interface{}{all[args[0].(*big.Int).Int64()]}
+		},
+	})
+
+	// Deposit pool and settings
+	chain.deploy(t, "poolseaDepositPool", common.HexToAddress("0x2000000000000000000000000000000000000003"), depositPoolAbi, map[string]fakeMethod{
+		"getUserBalance": returns(eth.EthToWei(20)),
+	})
+	chain.deploy(t, protocol.DepositSettingsContractName, common.HexToAddress("0x2000000000000000000000000000000000000004"), depositSettingsAbi, map[string]fakeMethod{
+		"getAssignDepositsEnabled":     returns(true),
+		"getMaximumDepositAssignments": returns(big.NewInt(2)),
+	})
+	chain.deploy(t, protocol.MinipoolSettingsContractName, common.HexToAddress("0x2000000000000000000000000000000000000005"), minipoolSettingsAbi, map[string]fakeMethod{
+		"getLaunchBalance": returns(eth.EthToWei(32)),
+	})
+
+	// The queued minipools, with an 8 ETH bond for the Atlas ones
+	for _, address := range all {
+		chain.deploy(t, "", address, queuedMinipoolAbi, map[string]fakeMethod{
+			"version":               returns(uint8(3)),
+			"getNodeDepositBalance": returns(eth.EthToWei(8)),
+		})
+	}
+	return chain
+}
+
+// The parts of the contract ABIs used to load the queue
+const (
+	addressQueueStorageAbi = `[{"type":"function","name":"getLength","inputs":[{"name":"_key","type":"bytes32"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},{"type":"function","name":"getItem","inputs":[{"name":"_key","type":"bytes32"},{"name":"_index","type":"uint256"}],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"}]`
+	minipoolQueueAbi       = `[{"type":"function","name":"getTotalLength","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},{"type":"function","name":"getMinipoolAt","inputs":[{"name":"_index","type":"uint256"}],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"}]`
+	depositPoolAbi         = `[{"type":"function","name":"getUserBalance","inputs":[],"outputs":[{"name":"","type":"int256"}],"stateMutability":"view"}]`
+	depositSettingsAbi     = `[{"type":"function","name":"getAssignDepositsEnabled","inputs":[],"outputs":[{"name":"","type":"bool"}],"stateMutability":"view"},{"type":"function","name":"getMaximumDepositAssignments","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+	minipoolSettingsAbi    = `[{"type":"function","name":"getLaunchBalance","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+	queuedMinipoolAbi      = `[{"type":"function","name":"version","inputs":[],"outputs":[{"name":"","type":"uint8"}],"stateMutability":"view"},{"type":"function","name":"getNodeDepositBalance","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+)
@@ -2,7 +2,7 @@ package rewards
 
 import (
 	"context"
-	"github.com/RedDuck-Software/poolsea-go/rewards"
+	legacyrewards "github.com/RedDuck-Software/poolsea-go/legacy/v1.0.0/rewards"
 	"github.com/RedDuck-Software/poolsea-go/settings/protocol"
 	"github.com/RedDuck-Software/poolsea-go/tokens"
 	"math/big"
@@ -39,14 +39,14 @@ func TestTrustedNodeRewards(t *testing.T) {
 	}
 
 	// Get & check trusted node claims enabled status
-	if claimsEnabled, err := rewards.GetTrustedNodeClaimsEnabled(rp, nil); err != nil {
+	if claimsEnabled, err := legacyrewards.GetTrustedNodeClaimsEnabled(rp, nil, nil); err != nil {
 		t.Error(err)
 	} else if !claimsEnabled {
 		t.Error("Incorrect trusted node claims enabled status")
 	}
 
 	// Get & check initial trusted node claim possible status
-	if nodeClaimPossible, err := rewards.GetTrustedNodeClaimPossible(rp, trustedNodeAccount.Address, nil); err != nil {
+	if nodeClaimPossible, err := legacyrewards.GetTrustedNodeClaimPossible(rp, trustedNodeAccount.Address, nil, nil); err != nil {
 		t.Error(err)
 	} else if nodeClaimPossible {
 		t.Error("Incorrect initial trusted node claim possible status")
@@ -58,21 +58,21 @@ func TestTrustedNodeRewards(t *testing.T) {
 	}
 
 	// Get & check updated trusted node claim possible status
-	if nodeClaimPossible, err := rewards.GetTrustedNodeClaimPossible(rp, trustedNodeAccount.Address, nil); err != nil {
+	if nodeClaimPossible, err := legacyrewards.GetTrustedNodeClaimPossible(rp, trustedNodeAccount.Address, nil, nil); err != nil {
 		t.Error(err)
 	} else if !nodeClaimPossible {
 		t.Error("Incorrect updated trusted node claim possible status")
 	}
 
 	// Get & check trusted node claim rewards percent
-	if rewardsPerc, err := rewards.GetTrustedNodeClaimRewardsPerc(rp, trustedNodeAccount.Address, nil); err != nil {
+	if rewardsPerc, err := legacyrewards.GetTrustedNodeClaimRewardsPerc(rp, trustedNodeAccount.Address, nil, nil); err != nil {
 		t.Error(err)
 	} else if rewardsPerc != 1 {
 		t.Errorf("Incorrect trusted node claim rewards perc %f", rewardsPerc)
 	}
 
 	// Get & check initial trusted node claim rewards amount
-	if rewardsAmount, err := rewards.GetTrustedNodeClaimRewardsAmount(rp, trustedNodeAccount.Address, nil); err != nil {
+	if rewardsAmount, err := legacyrewards.GetTrustedNodeClaimRewardsAmount(rp, trustedNodeAccount.Address, nil, nil); err != nil {
 		t.Error(err)
 	} else if rewardsAmount.Cmp(big.NewInt(0)) != 0 {
 		t.Errorf("Incorrect initial trusted node claim rewards amount %s", rewardsAmount.String())
@@ -91,7 +91,7 @@ func TestTrustedNodeRewards(t *testing.T) {
 	}
 
 	// Get & check updated trusted node claim rewards amount
-	if rewardsAmount, err := rewards.GetTrustedNodeClaimRewardsAmount(rp, trustedNodeAccount.Address, nil); err != nil {
+	if rewardsAmount, err := legacyrewards.GetTrustedNodeClaimRewardsAmount(rp, trustedNodeAccount.Address, nil, nil); err != nil {
 		t.Error(err)
 	} else if rewardsAmount.Cmp(big.NewInt(0)) != 1 {
 		t.Errorf("Incorrect updated trusted node claim rewards amount %s", rewardsAmount.String())
@@ -105,7 +105,7 @@ func TestTrustedNodeRewards(t *testing.T) {
 	}
 
 	// Claim node rewards
-	if _, err := rewards.ClaimTrustedNodeRewards(rp, trustedNodeAccount.GetTransactor()); err != nil {
+	if _, err := legacyrewards.ClaimTrustedNodeRewards(rp, trustedNodeAccount.GetTransactor(), nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -0,0 +1,322 @@
+package rocketpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// The ABIs of a contract before and after an upgrade
+const (
+	bundleTestAbi         = `[{"type":"function","name":"getBalance","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+	bundleTestUpgradedAbi = `[{"type":"function","name":"getBalance","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},{"type":"function","name":"getTotal","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"}]`
+)
+
+// A RocketStorage contract served by a stub client, which counts the calls made to it
+type fakeStorage struct {
+	storageAbi abi.ABI
+	addresses  map[common.Hash]common.Address
+	strings    map[common.Hash]string
+	calls      int
+	lock       sync.Mutex
+}
+
+// Create a fake RocketStorage
+func newFakeStorage(t *testing.T) *fakeStorage {
+	storageAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeStorage{
+		storageAbi: storageAbi,
+		addresses:  map[common.Hash]common.Address{},
+		strings:    map[common.Hash]string{},
+	}
+}
+
+// Set an address in the storage
+func (s *fakeStorage) setAddress(contractName string, address common.Address) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.addresses[crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName))] = address
+}
+
+// Register a contract with the storage
+func (s *fakeStorage) setContract(t *testing.T, contractName string, address common.Address, abiString string) {
+	encodedAbi, err := rocketpool.EncodeAbiStr(abiString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.addresses[crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName))] = address
+	s.strings[crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName))] = encodedAbi
+}
+
+// Get the number of calls made to the storage
+func (s *fakeStorage) getCalls() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.calls
+}
+
+// Get a client that serves the storage
+func (s *fakeStorage) client() *stub.Client {
+	return &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			s.calls++
+			method, err := s.storageAbi.MethodById(call.Data[:4])
+			if err != nil {
+				return nil, err
+			}
+			var key common.Hash
+			copy(key[:], call.Data[4:36])
+			switch method.Name {
+			case "getAddress":
+				return method.Outputs.Pack(s.addresses[key])
+			case "getString":
+				return method.Outputs.Pack(s.strings[key])
+			case "getUint":
+				return method.Outputs.Pack(big.NewInt(0))
+			}
+			return nil, stub.ErrNotImplemented
+		},
+	}
+}
+
+func TestContractBundle(t *testing.T) {
+
+	// Deploy a contract to the fake storage and bundle it
+	storage := newFakeStorage(t)
+	storageAddress := common.HexToAddress(tests.RocketStorageAddress)
+	contractAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	storage.setContract(t, "poolseaDepositPool", contractAddress, bundleTestAbi)
+	chainRp, err := rocketpool.NewRocketPool(storage.client(), storageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := rocketpool.CreateContractBundle(context.Background(), chainRp, "local", 1337, "1.2.0", "poolseaDepositPool")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Save and reload it
+	bundlePath := filepath.Join(t.TempDir(), "bundle.json")
+	if err := bundle.Save(bundlePath); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err = rocketpool.LoadContractBundle(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Version != "1.2.0" || bundle.ChainID != 1337 || bundle.StorageAddress != storageAddress {
+		t.Errorf("Incorrect bundle metadata %s / %d / %s", bundle.Version, bundle.ChainID, bundle.StorageAddress.Hex())
+	}
+	if address, contractAbi, err := bundle.GetContract("poolseaDepositPool"); err != nil {
+		t.Fatal(err)
+	} else if *address != contractAddress || contractAbi.Methods["getBalance"].Name == "" {
+		t.Errorf("Incorrect bundled contract at %s", address.Hex())
+	}
+
+	// Resolve contracts from the bundle without touching the chain
+	bundleRp, err := rocketpool.NewRocketPool(storage.client(), storageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundleRp.UseContractBundle(bundle, rocketpool.ContractBundleSettings{Validation: rocketpool.BundleValidationNone}); err != nil {
+		t.Fatal(err)
+	}
+	calls := storage.getCalls()
+	contract, err := bundleRp.GetContract("poolseaDepositPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *contract.Address != contractAddress || storage.getCalls() != calls {
+		t.Errorf("Contract at %s was not resolved from the bundle (%d calls)", contract.Address.Hex(), storage.getCalls()-calls)
+	}
+
+	// Bundled ABIs are only decoded once
+	firstAbi, err := bundleRp.GetABI("poolseaDepositPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secondAbi, err := bundleRp.GetABI("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if secondAbi != firstAbi {
+		t.Error("Bundled ABI was decoded again")
+	}
+
+	// Bundles for another RocketStorage are rejected
+	otherRp, err := rocketpool.NewRocketPool(storage.client(), common.HexToAddress("0x3333333333333333333333333333333333333333"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := otherRp.UseContractBundle(bundle, rocketpool.ContractBundleSettings{}); err == nil {
+		t.Error("Expected error for bundle with a different storage address")
+	}
+
+	// Upgrade the contract on chain and validate explicitly
+	upgradedAddress := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	storage.setContract(t, "poolseaDepositPool", upgradedAddress, bundleTestUpgradedAbi)
+	if err := bundleRp.ValidateContractBundle(context.Background()); !errors.Is(err, rocketpool.ErrBundleMismatch) {
+		t.Errorf("Expected bundle mismatch error, got %v", err)
+	}
+	if contract, err := bundleRp.GetContract("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != upgradedAddress {
+		t.Errorf("Mismatched contract was resolved at %s", contract.Address.Hex())
+	}
+
+}
+
+func TestContractBundleLazyValidation(t *testing.T) {
+
+	// Bundle a contract that has since been upgraded in place
+	storage := newFakeStorage(t)
+	storageAddress := common.HexToAddress(tests.RocketStorageAddress)
+	contractAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	storage.setContract(t, "poolseaDepositPool", contractAddress, bundleTestAbi)
+	storage.setContract(t, "poolseaNodeManager", contractAddress, bundleTestAbi)
+	rp, err := rocketpool.NewRocketPool(storage.client(), storageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := rocketpool.CreateContractBundle(context.Background(), rp, "local", 1337, "1.2.0", "poolseaDepositPool", "poolseaNodeManager")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.setContract(t, "poolseaDepositPool", contractAddress, bundleTestUpgradedAbi)
+
+	// Use it with lazy validation
+	var mismatches []string
+	var lock sync.Mutex
+	if err := rp.UseContractBundle(bundle, rocketpool.ContractBundleSettings{
+		OnMismatch: func(contractName string, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			mismatches = append(mismatches, contractName)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Using the contracts validates them in the background
+	if _, err := rp.GetContracts(nil, "poolseaDepositPool", "poolseaNodeManager"); err != nil {
+		t.Fatal(err)
+	}
+	rp.WaitForContractBundleValidation()
+	if mismatched := rp.GetMismatchedBundleContracts(); len(mismatched) != 1 || mismatched[0] != "poolseaDepositPool" || len(mismatches) != 1 {
+		t.Errorf("Incorrect mismatched contracts %v, reported %v", mismatched, mismatches)
+	}
+	contract, err := rp.GetContract("poolseaDepositPool", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := contract.ABI.Methods["getTotal"]; !exists {
+		t.Error("Upgraded contract ABI was not loaded from the chain")
+	}
+
+}
+
+func TestContractBundleRegistry(t *testing.T) {
+
+	// Bundle a contract for two networks
+	storage := newFakeStorage(t)
+	storageAddress := common.HexToAddress(tests.RocketStorageAddress)
+	otherStorageAddress := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	contractAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	storage.setContract(t, "poolseaDepositPool", contractAddress, bundleTestAbi)
+	rp, err := rocketpool.NewRocketPool(storage.client(), storageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := rocketpool.CreateContractBundle(context.Background(), rp, "local", 1337, "1.2.0", "poolseaDepositPool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherBundle := *bundle
+	otherBundle.Network = "other"
+	otherBundle.ChainID = 1338
+	otherBundle.StorageAddress = otherStorageAddress
+	registryFs := fstest.MapFS{}
+	for _, b := range []*rocketpool.ContractBundle{bundle, &otherBundle} {
+		bundleBytes, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registryFs[b.Network+".json"] = &fstest.MapFile{Data: bundleBytes}
+	}
+	registry, err := rocketpool.NewContractBundleRegistry(registryFs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bundles are found by chain ID or storage address
+	if found, exists := registry.GetBundleByChainID(1338); !exists || found.Network != "other" {
+		t.Errorf("Incorrect bundle for chain ID 1338: %v", found)
+	}
+	if found, exists := registry.GetBundleByStorageAddress(storageAddress); !exists || found.Network != "local" {
+		t.Errorf("Incorrect bundle for storage %s: %v", storageAddress.Hex(), found)
+	}
+	if _, exists := registry.GetBundleByChainID(1); exists {
+		t.Error("Found a bundle for an unregistered chain ID")
+	}
+
+	// Two bundles for one network are rejected
+	registryFs["duplicate.json"] = registryFs["local.json"]
+	if _, err := rocketpool.NewContractBundleRegistry(registryFs); err == nil {
+		t.Error("Expected error for duplicate network bundles")
+	}
+
+	// The registered bundle for the network's storage is used, and checked against RocketStorage when its contracts are used
+	if _, err := rp.UseRegisteredContractBundle(registry, 1338, rocketpool.ContractBundleSettings{}); err == nil {
+		t.Error("Expected error for a bundle with a different chain ID")
+	}
+	if used, err := rp.UseRegisteredContractBundle(registry, 1337, rocketpool.ContractBundleSettings{}); err != nil {
+		t.Fatal(err)
+	} else if used == nil || used.Network != "local" {
+		t.Fatalf("Incorrect registered bundle %v", used)
+	}
+	storage.setContract(t, "poolseaDepositPool", contractAddress, bundleTestUpgradedAbi)
+	if _, err := rp.GetContract("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	}
+	rp.WaitForContractBundleValidation()
+	if mismatched := rp.GetMismatchedBundleContracts(); len(mismatched) != 1 || mismatched[0] != "poolseaDepositPool" {
+		t.Errorf("Incorrect mismatched contracts %v", mismatched)
+	}
+
+	// Networks without a registered bundle resolve contracts from the chain
+	unregisteredRp, err := rocketpool.NewRocketPool(storage.client(), common.HexToAddress("0x5555555555555555555555555555555555555555"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used, err := unregisteredRp.UseRegisteredContractBundle(registry, 0, rocketpool.ContractBundleSettings{}); err != nil || used != nil {
+		t.Errorf("Expected no registered bundle, got %v (%v)", used, err)
+	}
+
+	// The embedded registry loads
+	if _, err := rocketpool.GetEmbeddedContractBundleRegistry(); err != nil {
+		t.Error(err)
+	}
+
+}
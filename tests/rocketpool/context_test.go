@@ -0,0 +1,81 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// The time to wait before cancelling a request to the slow client
+const slowClientTimeout = 50 * time.Millisecond
+
+func TestGetContractContextTimeout(t *testing.T) {
+
+	// Initialize contract manager against an unresponsive client
+	slowRp, err := rocketpool.NewRocketPool(stub.NewSlowClient(), common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Get contract with a deadline
+	ctx, cancel := context.WithTimeout(context.Background(), slowClientTimeout)
+	defer cancel()
+	if _, err := slowRp.GetContractContext(ctx, "poolseaDepositPool", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded error, got %v", err)
+	}
+
+	// Get contract with a deadline provided by the call options
+	ctx, cancel = context.WithTimeout(context.Background(), slowClientTimeout)
+	defer cancel()
+	if _, err := slowRp.GetContract("poolseaDepositPool", &bind.CallOpts{Context: ctx}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded error, got %v", err)
+	}
+
+}
+
+func TestGetLogsContextCancel(t *testing.T) {
+
+	// Initialize contract manager against an unresponsive client
+	slowRp, err := rocketpool.NewRocketPool(stub.NewSlowClient(), common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancel the request once it has started
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(slowClientTimeout, cancel)
+	if _, err := eth.GetLogsContext(ctx, slowRp, nil, nil, nil, nil, nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context cancelled error, got %v", err)
+	}
+
+}
+
+func TestWaitForTransactionContextTimeout(t *testing.T) {
+
+	// Initialize a client that never finds the transaction
+	notFoundClient := stub.NewSlowClient()
+	notFoundClient.TransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+		return nil, false, ethereum.NotFound
+	}
+
+	// Wait for the transaction with a deadline
+	ctx, cancel := context.WithTimeout(context.Background(), slowClientTimeout)
+	defer cancel()
+	if _, err := utils.WaitForTransactionContext(ctx, notFoundClient, common.Hash{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded error, got %v", err)
+	}
+
+}
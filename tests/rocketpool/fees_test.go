@@ -0,0 +1,209 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/clients"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// A stub client that also serves fee history
+type feeHistoryClient struct {
+	*stub.Client
+	rewards []*big.Int
+}
+
+func (c *feeHistoryClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	history := &ethereum.FeeHistory{}
+	for _, reward := range c.rewards {
+		history.Reward = append(history.Reward, []*big.Int{reward})
+		history.GasUsedRatio = append(history.GasUsedRatio, 0.5)
+	}
+	return history, nil
+}
+
+// Create a stub client with a 10 gwei base fee and a 2 gwei suggested priority fee
+func newFeeClient() *stub.Client {
+	return &stub.Client{
+		HeaderByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: big.NewInt(100), BaseFee: eth.GweiToWei(10)}, nil
+		},
+		SuggestGasTipCapFunc: func(ctx context.Context) (*big.Int, error) {
+			return eth.GweiToWei(2), nil
+		},
+		EstimateGasFunc: func(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+			return 100000, nil
+		},
+	}
+}
+
+// Estimate fees with an oracle and check them against the expected values in gwei
+func checkFees(t *testing.T, client rocketpool.ExecutionClient, settings rocketpool.FeeOracleSettings, expectedTip float64, expectedFeeCap float64) {
+	t.Helper()
+	oracle, err := rocketpool.NewFeeOracle(client, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fees, err := oracle.EstimateFees(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasTipCap.Cmp(eth.GweiToWei(expectedTip)) != 0 {
+		t.Errorf("Incorrect %s priority fee %s", settings.Strategy, fees.GasTipCap.String())
+	}
+	if fees.GasFeeCap.Cmp(eth.GweiToWei(expectedFeeCap)) != 0 {
+		t.Errorf("Incorrect %s max fee %s", settings.Strategy, fees.GasFeeCap.String())
+	}
+	if fees.BaseFee.Cmp(eth.GweiToWei(10)) != 0 {
+		t.Errorf("Incorrect base fee %s", fees.BaseFee.String())
+	}
+}
+
+func TestFeeStrategies(t *testing.T) {
+
+	// Fixed strategies
+	client := newFeeClient()
+	checkFees(t, client, rocketpool.FeeOracleSettings{}, 2, 22)
+	checkFees(t, client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategySlow}, 1, 12.25)
+	checkFees(t, client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyFast}, 4, 34)
+
+	// Max fee ceiling, which also caps the priority fee
+	checkFees(t, client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyFast, MaxFeeCap: eth.GweiToWei(15)}, 4, 15)
+	checkFees(t, client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyFast, MaxFeeCap: eth.GweiToWei(3)}, 3, 3)
+
+	// Percentile from recent blocks, including through client wrappers
+	historyClient := &feeHistoryClient{Client: client, rewards: []*big.Int{eth.GweiToWei(5), eth.GweiToWei(1), eth.GweiToWei(3)}}
+	checkFees(t, historyClient, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyPercentile, Percentile: 90}, 3, 23)
+	checkFees(t, clients.NewRetryClient(clients.NewFailoverClient(historyClient), clients.RetrySettings{}), rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyPercentile}, 3, 23)
+
+	// Falling back to the suggestion when recent blocks are empty
+	checkFees(t, &feeHistoryClient{Client: client}, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyPercentile}, 2, 22)
+
+	// The percentile strategy fails if the client has no fee history
+	if oracle, err := rocketpool.NewFeeOracle(client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyPercentile}); err != nil {
+		t.Fatal(err)
+	} else if _, err := oracle.EstimateFees(context.Background()); !errors.Is(err, rocketpool.ErrFeeHistoryNotSupported) {
+		t.Errorf("Expected fee history error, got %v", err)
+	}
+	if oracle, err := rocketpool.NewFeeOracle(clients.NewRetryClient(client, clients.RetrySettings{}), rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyPercentile}); err != nil {
+		t.Fatal(err)
+	} else if _, err := oracle.EstimateFees(context.Background()); !errors.Is(err, rocketpool.ErrFeeHistoryNotSupported) {
+		t.Errorf("Expected fee history error through a wrapper, got %v", err)
+	}
+
+	// Invalid settings
+	if _, err := rocketpool.NewFeeOracle(client, rocketpool.FeeOracleSettings{Strategy: "instant"}); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+	if _, err := rocketpool.NewFeeOracle(client, rocketpool.FeeOracleSettings{Percentile: 101}); err == nil {
+		t.Error("Expected error for invalid percentile")
+	}
+
+}
+
+func TestGasSettings(t *testing.T) {
+
+	// Create a contract that uses a manager's gas settings
+	var sentTx *types.Transaction
+	client := newFeeClient()
+	client.PendingNonceAtFunc = func(ctx context.Context, account common.Address) (uint64, error) {
+		return 0, nil
+	}
+	client.SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error {
+		sentTx = tx
+		return nil
+	}
+	feeRp, err := rocketpool.NewRocketPool(client, common.HexToAddress("0x1111111111111111111111111111111111111111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contractAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"stakeRPL","inputs":[{"name":"amount","type":"uint256"}],"outputs":[],"stateMutability":"nonpayable"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	contract := &rocketpool.Contract{
+		Contract:    bind.NewBoundContract(address, contractAbi, client, client, client),
+		Address:     &address,
+		ABI:         &contractAbi,
+		Client:      client,
+		Name:        "poolseaNodeStaking",
+		GasSettings: feeRp.GetGasSettings(),
+	}
+	account, err := accounts.GetAccount(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(account.PrivateKey, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Default gas limits without fees
+	gasInfo, err := contract.GetTransactionGasInfo(opts, "stakeRPL", big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasInfo.EstGasLimit != 100000 || gasInfo.SafeGasLimit != 150000 || gasInfo.EstGasCost != nil {
+		t.Errorf("Incorrect default gas info %+v", gasInfo)
+	}
+
+	// Custom gas limits with fees
+	oracle, err := rocketpool.NewFeeOracle(client, rocketpool.FeeOracleSettings{Strategy: rocketpool.FeeStrategyFast})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeRp.GetGasSettings().GasLimitMultiplier = 2
+	feeRp.GetGasSettings().MaxGasLimit = 180000
+	feeRp.GetGasSettings().FeeOracle = oracle
+	gasInfo, err = contract.GetTransactionGasInfo(opts, "stakeRPL", big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gasInfo.SafeGasLimit != 180000 {
+		t.Errorf("Incorrect safe gas limit %d", gasInfo.SafeGasLimit)
+	}
+	if gasInfo.GasTipCap.Cmp(eth.GweiToWei(4)) != 0 || gasInfo.GasFeeCap.Cmp(eth.GweiToWei(34)) != 0 {
+		t.Errorf("Incorrect fees %s / %s", gasInfo.GasTipCap.String(), gasInfo.GasFeeCap.String())
+	}
+	if gasInfo.EstGasCost.Cmp(eth.GweiToWei(14*100000)) != 0 || gasInfo.MaxGasCost.Cmp(eth.GweiToWei(34*180000)) != 0 {
+		t.Errorf("Incorrect gas costs %s / %s", gasInfo.EstGasCost.String(), gasInfo.MaxGasCost.String())
+	}
+
+	// Transactions get the oracle's fees unless they set their own
+	if _, err := contract.Transact(opts, "stakeRPL", big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if sentTx.GasTipCap().Cmp(eth.GweiToWei(4)) != 0 || sentTx.GasFeeCap().Cmp(eth.GweiToWei(34)) != 0 || sentTx.Gas() != 180000 {
+		t.Errorf("Incorrect transaction fees %s / %s with gas %d", sentTx.GasTipCap().String(), sentTx.GasFeeCap().String(), sentTx.Gas())
+	}
+	opts.GasTipCap = eth.GweiToWei(1)
+	opts.GasFeeCap = eth.GweiToWei(50)
+	if _, err := contract.Transact(opts, "stakeRPL", big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if sentTx.GasTipCap().Cmp(eth.GweiToWei(1)) != 0 || sentTx.GasFeeCap().Cmp(eth.GweiToWei(50)) != 0 {
+		t.Errorf("Transaction fees were overridden: %s / %s", sentTx.GasTipCap().String(), sentTx.GasFeeCap().String())
+	}
+
+	// Max gas limit is enforced
+	feeRp.GetGasSettings().MaxGasLimit = 90000
+	if _, err := contract.GetTransactionGasInfo(opts, "stakeRPL", big.NewInt(1)); err == nil {
+		t.Error("Expected error for gas above the max gas limit")
+	}
+
+}
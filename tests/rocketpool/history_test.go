@@ -0,0 +1,178 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+)
+
+// Get the logs that match a filter query
+func filterLogs(logs []types.Log, query ethereum.FilterQuery) []types.Log {
+	matches := []types.Log{}
+	for _, log := range logs {
+		if query.FromBlock != nil && log.BlockNumber < query.FromBlock.Uint64() {
+			continue
+		}
+		if query.ToBlock != nil && log.BlockNumber > query.ToBlock.Uint64() {
+			continue
+		}
+		addressMatch := len(query.Addresses) == 0
+		for _, address := range query.Addresses {
+			addressMatch = addressMatch || address == log.Address
+		}
+		topicsMatch := true
+		for i, topics := range query.Topics {
+			if len(topics) == 0 {
+				continue
+			}
+			topicMatch := false
+			for _, topic := range topics {
+				topicMatch = topicMatch || (i < len(log.Topics) && log.Topics[i] == topic)
+			}
+			topicsMatch = topicsMatch && topicMatch
+		}
+		if addressMatch && topicsMatch {
+			matches = append(matches, log)
+		}
+	}
+	return matches
+}
+
+func TestGetContractAt(t *testing.T) {
+
+	// Deploy the upgrade contract, a contract that was upgraded at block 20 and a contract that was added at block 30
+	storage := newFakeStorage(t)
+	upgradeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	legacyAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	currentAddress := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	addedAddress := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	storage.setContract(t, rocketpool.UpgradeContractName, upgradeAddress, upgradeTestAbi)
+	storage.setContract(t, "poolseaNodeStaking", currentAddress, bundleTestUpgradedAbi)
+	storage.setAddress("poolseaNodeStaking.v2", legacyAddress)
+	storage.setContract(t, "poolseaNodeDistributorFactory", addedAddress, bundleTestAbi)
+
+	// Serve the upgrade events
+	client := storage.client()
+	historyRp, err := rocketpool.NewRocketPool(client, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgradeAbi, err := historyRp.GetABI(rocketpool.UpgradeContractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := []types.Log{
+		{
+			Address:     upgradeAddress,
+			BlockNumber: 20,
+			Topics: []common.Hash{
+				upgradeAbi.Events["ContractUpgraded"].ID,
+				crypto.Keccak256Hash([]byte("poolseaNodeStaking")),
+				common.BytesToHash(legacyAddress.Bytes()),
+				common.BytesToHash(currentAddress.Bytes()),
+			},
+		},
+		{
+			Address:     upgradeAddress,
+			BlockNumber: 30,
+			Topics: []common.Hash{
+				upgradeAbi.Events["ContractAdded"].ID,
+				crypto.Keccak256Hash([]byte("poolseaNodeDistributorFactory")),
+				common.BytesToHash(addedAddress.Bytes()),
+			},
+		},
+		{
+			// Events from other contracts are ignored
+			Address:     common.HexToAddress("0x5555555555555555555555555555555555555555"),
+			BlockNumber: 40,
+			Topics: []common.Hash{
+				upgradeAbi.Events["ContractUpgraded"].ID,
+				crypto.Keccak256Hash([]byte("poolseaNodeStaking")),
+				common.BytesToHash(currentAddress.Bytes()),
+				common.BytesToHash(addedAddress.Bytes()),
+			},
+		},
+	}
+	client.BlockNumberFunc = func(ctx context.Context) (uint64, error) {
+		return 50, nil
+	}
+	var queries []ethereum.FilterQuery
+	var queriesLock sync.Mutex
+	client.FilterLogsFunc = func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+		queriesLock.Lock()
+		queries = append(queries, query)
+		queriesLock.Unlock()
+		return filterLogs(logs, query), nil
+	}
+	intervalSize := big.NewInt(20)
+	historyRp.SetEventLogInterval(intervalSize)
+
+	// Before the upgrade, the legacy address and ABI were live
+	legacyAbi, err := rocketpool.DecodeAbi(historyRp.VersionManager.V1_1_0.GetEncodedABI("poolseaNodeStaking"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := historyRp.GetContractAt("poolseaNodeStaking", 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *contract.Address != legacyAddress || len(contract.ABI.Methods) != len(legacyAbi.Methods) {
+		t.Errorf("Incorrect legacy contract at %s with %d methods", contract.Address.Hex(), len(contract.ABI.Methods))
+	}
+
+	// From the upgrade block on, the current contract is live
+	for _, block := range []uint64{20, 45} {
+		contract, err := historyRp.GetContractAt("poolseaNodeStaking", block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, exists := contract.ABI.Methods["getTotal"]; *contract.Address != currentAddress || !exists {
+			t.Errorf("Incorrect contract at %s for block %d", contract.Address.Hex(), block)
+		}
+	}
+
+	// Contracts that were added later didn't exist before
+	if _, err := historyRp.GetContractAt("poolseaNodeDistributorFactory", 29); !errors.Is(err, rocketpool.ErrContractNotDeployed) {
+		t.Errorf("Expected not deployed error, got %v", err)
+	}
+	if contract, err := historyRp.GetContractAt("poolseaNodeDistributorFactory", 30); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != addedAddress {
+		t.Errorf("Incorrect added contract at %s", contract.Address.Hex())
+	}
+
+	// Calls at a block use the contract as it was at that block, and calls without one use the current contract
+	if contract, err := historyRp.GetContractForCall("poolseaNodeStaking", &bind.CallOpts{BlockNumber: big.NewInt(19)}); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != legacyAddress {
+		t.Errorf("Incorrect contract at %s for a call at block 19", contract.Address.Hex())
+	}
+	if contract, err := historyRp.GetContractForCall("poolseaNodeStaking", nil); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != currentAddress {
+		t.Errorf("Incorrect contract at %s for a call at the head", contract.Address.Hex())
+	}
+
+	// The events were scanned in batches of the interval size, once per contract
+	if len(queries) != 6 {
+		t.Fatalf("Expected 6 log requests, got %d", len(queries))
+	}
+	for _, query := range queries {
+		if blocks := query.ToBlock.Uint64() - query.FromBlock.Uint64() + 1; blocks > intervalSize.Uint64() {
+			t.Errorf("Log request for blocks %s to %s is larger than the interval size", query.FromBlock.String(), query.ToBlock.String())
+		}
+	}
+
+}
@@ -0,0 +1,129 @@
+package rocketpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// A contract with one method and one custom error
+const revertTestAbi = `[
+	{"type":"function","name":"deposit","inputs":[{"name":"amount","type":"uint256"}],"outputs":[],"stateMutability":"payable"},
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+]`
+
+// A JSON-RPC error carrying revert data, as returned by geth
+type revertDataError struct {
+	data string
+}
+
+func (e revertDataError) Error() string          { return "execution reverted" }
+func (e revertDataError) ErrorCode() int         { return 3 }
+func (e revertDataError) ErrorData() interface{} { return e.data }
+
+// Create a contract whose gas estimates fail with an error
+func newRevertingContract(t *testing.T, estimateErr error) *rocketpool.Contract {
+	contractAbi, err := abi.JSON(strings.NewReader(revertTestAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &stub.Client{
+		EstimateGasFunc: func(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+			return 0, estimateErr
+		},
+	}
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	return &rocketpool.Contract{
+		Contract: bind.NewBoundContract(address, contractAbi, client, client, client),
+		Address:  &address,
+		ABI:      &contractAbi,
+		Client:   client,
+		Name:     "poolseaDepositPool",
+	}
+}
+
+// Encode revert data for an error signature and arguments
+func encodeRevertData(t *testing.T, signature string, types []string, values ...interface{}) string {
+	args := abi.Arguments{}
+	for _, typeName := range types {
+		argType, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args = append(args, abi.Argument{Type: argType})
+	}
+	packed, err := args.Pack(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hexutil.Encode(append(crypto.Keccak256([]byte(signature))[:4], packed...))
+}
+
+func TestRevertErrors(t *testing.T) {
+
+	// Revert strings
+	contract := newRevertingContract(t, revertDataError{data: encodeRevertData(t, "Error(string)", []string{"string"}, "The deposit pool is full")})
+	_, err := contract.GetTransactionGasInfo(&bind.TransactOpts{}, "deposit", big.NewInt(1))
+	var revertErr *rocketpool.RevertError
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("Expected revert error, got %v", err)
+	}
+	if revertErr.ContractName != "poolseaDepositPool" || revertErr.Method != "deposit" || revertErr.ErrorName != rocketpool.ErrorStringName || revertErr.Reason != "The deposit pool is full" {
+		t.Errorf("Incorrect revert error %+v", revertErr)
+	}
+	if !strings.Contains(err.Error(), "poolseaDepositPool.deposit: execution reverted: The deposit pool is full") {
+		t.Errorf("Incorrect revert error message %s", err.Error())
+	}
+
+	// Panics
+	contract = newRevertingContract(t, revertDataError{data: encodeRevertData(t, "Panic(uint256)", []string{"uint256"}, big.NewInt(0x11))})
+	_, err = contract.Transact(&bind.TransactOpts{}, "deposit", big.NewInt(1))
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("Expected revert error, got %v", err)
+	}
+	if revertErr.ErrorName != rocketpool.PanicName || revertErr.PanicCode.Uint64() != 0x11 || !strings.Contains(revertErr.Reason, "overflow") {
+		t.Errorf("Incorrect revert error %+v", revertErr)
+	}
+
+	// Custom errors
+	contract = newRevertingContract(t, revertDataError{data: encodeRevertData(t, "InsufficientBalance(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(100), big.NewInt(200))})
+	_, err = contract.GetTransferGasInfo(&bind.TransactOpts{})
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("Expected revert error, got %v", err)
+	}
+	if revertErr.ErrorName != "InsufficientBalance" || len(revertErr.Args) != 2 || revertErr.Reason != "InsufficientBalance(100, 200)" || revertErr.Method != "" {
+		t.Errorf("Incorrect revert error %+v", revertErr)
+	}
+
+	// Nethermind reverts
+	contract = newRevertingContract(t, errors.New("Reverted 0x"+strings.TrimPrefix(hexutil.Encode([]byte("Minipool is not staking")), "0x")))
+	_, err = contract.GetTransactionGasInfo(&bind.TransactOpts{}, "deposit", big.NewInt(1))
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("Expected revert error, got %v", err)
+	}
+	if revertErr.Reason != "Minipool is not staking" {
+		t.Errorf("Incorrect revert reason %s", revertErr.Reason)
+	}
+
+	// Other errors are left alone
+	connectionErr := errors.New("connection refused")
+	contract = newRevertingContract(t, connectionErr)
+	_, err = contract.GetTransactionGasInfo(&bind.TransactOpts{}, "deposit", big.NewInt(1))
+	if errors.As(err, &revertErr) || !errors.Is(err, connectionErr) {
+		t.Errorf("Expected connection error, got %v", err)
+	}
+
+}
@@ -6,12 +6,36 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
 )
 
+// Create a contract manager for a simulated chain with the fixture bundle deployed
+func newFixtureRocketPool(t *testing.T) *rocketpool.RocketPool {
+	t.Helper()
+	bundle, err := simulated.LoadFixtureBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := simulated.NewChainWithContracts(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		chain.Close()
+	})
+	return chain.RocketPool
+}
+
 func TestGetAddress(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get contract address
-	address1, err := rp.GetAddress("poolseaDepositPool")
+	address1, err := rp.GetAddress("poolseaDepositPool", nil)
 	if err != nil {
 		t.Fatalf("Could not get contract address: %s", err)
 	} else if bytes.Equal(address1.Bytes(), common.Address{}.Bytes()) {
@@ -19,7 +43,7 @@ func TestGetAddress(t *testing.T) {
 	}
 
 	// Get cached contract address
-	address2, err := rp.GetAddress("poolseaDepositPool")
+	address2, err := rp.GetAddress("poolseaDepositPool", nil)
 	if err != nil {
 		t.Fatalf("Could not get cached contract address: %s", err)
 	} else if !bytes.Equal(address2.Bytes(), address1.Bytes()) {
@@ -30,8 +54,11 @@ func TestGetAddress(t *testing.T) {
 
 func TestGetAddresses(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get contract addresses
-	addresses1, err := rp.GetAddresses("poolseaNodeManager", "poolseaNodeDeposit")
+	addresses1, err := rp.GetAddresses(nil, "poolseaNodeManager", "poolseaNodeDeposit")
 	if err != nil {
 		t.Fatalf("Could not get contract addresses: %s", err)
 	} else {
@@ -43,7 +70,7 @@ func TestGetAddresses(t *testing.T) {
 	}
 
 	// Get cached contract addresses
-	addresses2, err := rp.GetAddresses("poolseaNodeManager", "poolseaNodeDeposit")
+	addresses2, err := rp.GetAddresses(nil, "poolseaNodeManager", "poolseaNodeDeposit")
 	if err != nil {
 		t.Fatalf("Could not get cached contract addresses: %s", err)
 	} else {
@@ -58,14 +85,17 @@ func TestGetAddresses(t *testing.T) {
 
 func TestGetABI(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get ABI
-	abi1, err := rp.GetABI("poolseaDepositPool")
+	abi1, err := rp.GetABI("poolseaDepositPool", nil)
 	if err != nil {
 		t.Fatalf("Could not get contract ABI: %s", err)
 	}
 
 	// Get cached ABI
-	abi2, err := rp.GetABI("poolseaDepositPool")
+	abi2, err := rp.GetABI("poolseaDepositPool", nil)
 	if err != nil {
 		t.Fatalf("Could not get cached contract ABI: %s", err)
 	} else {
@@ -86,14 +116,17 @@ func TestGetABI(t *testing.T) {
 
 func TestGetABIs(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get ABIs
-	abis1, err := rp.GetABIs("poolseaNodeManager", "poolseaNodeDeposit")
+	abis1, err := rp.GetABIs(nil, "poolseaNodeManager", "poolseaNodeDeposit")
 	if err != nil {
 		t.Fatalf("Could not get contract ABIs: %s", err)
 	}
 
 	// Get cached ABIs
-	abis2, err := rp.GetABIs("poolseaNodeManager", "poolseaNodeDeposit")
+	abis2, err := rp.GetABIs(nil, "poolseaNodeManager", "poolseaNodeDeposit")
 	if err != nil {
 		t.Fatalf("Could not get cached contract ABIs: %s", err)
 	} else {
@@ -116,13 +149,23 @@ func TestGetABIs(t *testing.T) {
 
 func TestGetContract(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get contract
-	if _, err := rp.GetContract("poolseaDepositPool"); err != nil {
+	contract, err := rp.GetContract("poolseaDepositPool", nil)
+	if err != nil {
 		t.Fatalf("Could not get contract: %s", err)
 	}
+	version := new(uint8)
+	if err := contract.Call(nil, version, "version"); err != nil {
+		t.Fatalf("Could not call contract: %s", err)
+	} else if *version != 1 {
+		t.Errorf("Incorrect contract version %d", *version)
+	}
 
 	// Get cached contract
-	if _, err := rp.GetContract("poolseaDepositPool"); err != nil {
+	if _, err := rp.GetContract("poolseaDepositPool", nil); err != nil {
 		t.Fatalf("Could not get cached contract: %s", err)
 	}
 
@@ -130,13 +173,16 @@ func TestGetContract(t *testing.T) {
 
 func TestGetContracts(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Get contracts
-	if _, err := rp.GetContracts("poolseaNodeManager", "poolseaNodeDeposit"); err != nil {
+	if _, err := rp.GetContracts(nil, "poolseaNodeManager", "poolseaNodeDeposit"); err != nil {
 		t.Fatalf("Could not get contracts: %s", err)
 	}
 
 	// Get cached contracts
-	if _, err := rp.GetContracts("poolseaNodeManager", "poolseaNodeDeposit"); err != nil {
+	if _, err := rp.GetContracts(nil, "poolseaNodeManager", "poolseaNodeDeposit"); err != nil {
 		t.Fatalf("Could not get cached contracts: %s", err)
 	}
 
@@ -144,13 +190,16 @@ func TestGetContracts(t *testing.T) {
 
 func TestMakeContract(t *testing.T) {
 
+	// Deploy the fixture contracts
+	rp := newFixtureRocketPool(t)
+
 	// Make contract
-	if _, err := rp.MakeContract("poolseaMinipool", common.HexToAddress("0x1111111111111111111111111111111111111111")); err != nil {
+	if _, err := rp.MakeContract("poolseaMinipool", common.HexToAddress("0x1111111111111111111111111111111111111111"), nil); err != nil {
 		t.Fatalf("Could not make contract: %s", err)
 	}
 
 	// Make contract with cached ABI
-	if _, err := rp.MakeContract("poolseaMinipool", common.HexToAddress("0x2222222222222222222222222222222222222222")); err != nil {
+	if _, err := rp.MakeContract("poolseaMinipool", common.HexToAddress("0x2222222222222222222222222222222222222222"), nil); err != nil {
 		t.Fatalf("Could not make contract with cached ABI: %s", err)
 	}
 
@@ -0,0 +1,132 @@
+package rocketpool
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
+)
+
+func TestSimulatedChain(t *testing.T) {
+
+	// Initialize simulated chain
+	chain, err := simulated.NewChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+	ctx := context.Background()
+
+	// Get accounts
+	sender, err := accounts.GetAccount(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := accounts.GetAccount(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance, err := chain.Client.BalanceAt(ctx, recipient.Address, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Cmp(simulated.AccountBalance) != 0 {
+		t.Errorf("Incorrect initial account balance %s", balance.String())
+	}
+
+	// Mine blocks
+	chain.MineBlocks(3)
+	if blockNumber, err := chain.Client.BlockNumber(ctx); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 3 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+
+	// Increase time
+	header, err := chain.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.IncreaseTime(3600); err != nil {
+		t.Fatal(err)
+	}
+	if newHeader, err := chain.Client.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	} else if newHeader.Time < header.Time+3600 {
+		t.Errorf("Time was not increased; block time went from %d to %d", header.Time, newHeader.Time)
+	}
+
+	// Take a snapshot, then send ETH
+	chain.TakeSnapshot()
+	opts, err := chain.GetTransactor(sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Value = big.NewInt(params.Ether)
+	opts.GasFeeCap = big.NewInt(100 * params.GWei)
+	opts.GasTipCap = big.NewInt(params.GWei)
+	if _, err := eth.SendTransaction(chain.Client, recipient.Address, big.NewInt(simulated.ChainID), opts); err != nil {
+		t.Fatal(err)
+	}
+	expectedBalance := new(big.Int).Add(simulated.AccountBalance, opts.Value)
+	if balance, err := chain.Client.BalanceAt(ctx, recipient.Address, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Cmp(expectedBalance) != 0 {
+		t.Errorf("Incorrect account balance %s after transfer", balance.String())
+	}
+
+	// Revert the snapshot
+	if err := chain.RevertSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if balance, err := chain.Client.BalanceAt(ctx, recipient.Address, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Cmp(simulated.AccountBalance) != 0 {
+		t.Errorf("Incorrect account balance %s after reverting", balance.String())
+	}
+	if blockNumber, err := chain.Client.BlockNumber(ctx); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 4 {
+		t.Errorf("Incorrect block number %d after reverting", blockNumber)
+	}
+
+}
+
+func TestSimulatedChainContracts(t *testing.T) {
+
+	// Load the contract bundle, falling back to the fixture bundle
+	bundle, err := simulated.LoadFixtureBundle()
+	if bundlePath := os.Getenv(tests.ContractBundlePathEnvVar); bundlePath != "" {
+		bundle, err = simulated.LoadContractBundle(bundlePath)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deploy contracts
+	chain, err := simulated.NewChainWithContracts(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	// Check that every deployed contract can be loaded
+	for _, contractName := range bundle.ContractNames {
+		if artifact := bundle.Artifacts[contractName]; artifact.Bytecode == "" || artifact.Bytecode == "0x" {
+			continue
+		}
+		if address, err := chain.RocketPool.GetAddress(contractName, nil); err != nil {
+			t.Error(err)
+		} else if *address == (common.Address{}) {
+			t.Errorf("Contract %s was not registered", contractName)
+		}
+	}
+
+}
@@ -0,0 +1,242 @@
+package rocketpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+)
+
+// The upgrade contract's events
+const upgradeTestAbi = `[
+	{"type":"event","name":"ContractUpgraded","anonymous":false,"inputs":[{"indexed":true,"name":"name","type":"bytes32"},{"indexed":true,"name":"oldAddress","type":"address"},{"indexed":true,"name":"newAddress","type":"address"},{"indexed":false,"name":"time","type":"uint256"}]},
+	{"type":"event","name":"ContractAdded","anonymous":false,"inputs":[{"indexed":true,"name":"name","type":"bytes32"},{"indexed":true,"name":"newAddress","type":"address"},{"indexed":false,"name":"time","type":"uint256"}]}
+]`
+
+// A chain that serves upgrade events by block
+type upgradeChain struct {
+	latestBlock uint64
+	logs        []types.Log
+	lock        sync.Mutex
+}
+
+// Mine a block with an upgrade event
+func (c *upgradeChain) addLog(log types.Log) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.latestBlock++
+	log.BlockNumber = c.latestBlock
+	c.logs = append(c.logs, log)
+}
+
+func TestUpgradeWatcher(t *testing.T) {
+
+	// Deploy the upgrade contract and a contract to upgrade
+	storage := newFakeStorage(t)
+	upgradeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	oldAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	newAddress := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	storage.setContract(t, rocketpool.UpgradeContractName, upgradeAddress, upgradeTestAbi)
+	storage.setContract(t, "poolseaDepositPool", oldAddress, bundleTestAbi)
+
+	// Serve upgrade events from a client without subscription support
+	chain := &upgradeChain{latestBlock: 10}
+	client := storage.client()
+	client.BlockNumberFunc = func(ctx context.Context) (uint64, error) {
+		chain.lock.Lock()
+		defer chain.lock.Unlock()
+		return chain.latestBlock, nil
+	}
+	client.FilterLogsFunc = func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+		chain.lock.Lock()
+		defer chain.lock.Unlock()
+		logs := []types.Log{}
+		for _, log := range chain.logs {
+			if log.BlockNumber >= query.FromBlock.Uint64() && log.BlockNumber <= query.ToBlock.Uint64() && log.Address == query.Addresses[0] {
+				logs = append(logs, log)
+			}
+		}
+		return logs, nil
+	}
+	watchedRp, err := rocketpool.NewRocketPool(client, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract, err := watchedRp.GetContract("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != oldAddress {
+		t.Fatalf("Incorrect initial address %s", contract.Address.Hex())
+	}
+
+	// Start watching
+	watcher := rocketpool.NewUpgradeWatcher(watchedRp, rocketpool.UpgradeWatcherSettings{PollInterval: time.Millisecond})
+	changes := make(chan rocketpool.ContractChange, 10)
+	watcher.AddHook(func(change rocketpool.ContractChange) {
+		changes <- change
+	}, "poolseaDepositPool")
+	removeHook := watcher.AddHook(func(change rocketpool.ContractChange) {
+		t.Error("Removed hook was called")
+	})
+	removeHook()
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	// Upgrade the contract
+	upgradeAbi, err := watchedRp.GetABI(rocketpool.UpgradeContractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.setContract(t, "poolseaDepositPool", newAddress, bundleTestAbi)
+	chain.addLog(types.Log{
+		Address: upgradeAddress,
+		Topics: []common.Hash{
+			upgradeAbi.Events["ContractUpgraded"].ID,
+			crypto.Keccak256Hash([]byte("poolseaDepositPool")),
+			common.BytesToHash(oldAddress.Bytes()),
+			common.BytesToHash(newAddress.Bytes()),
+		},
+	})
+
+	// Wait for the hook
+	select {
+	case change := <-changes:
+		if change.Type != rocketpool.ContractChangeUpgraded || change.Name != "poolseaDepositPool" || change.OldAddress != oldAddress || change.NewAddress != newAddress || change.BlockNumber != 11 {
+			t.Errorf("Incorrect change %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for contract change")
+	}
+
+	// The cached contract was invalidated and the TTL was extended
+	if contract, err := watchedRp.GetContract("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != newAddress {
+		t.Errorf("Contract was not invalidated; still at %s", contract.Address.Hex())
+	}
+	if ttl := watchedRp.GetCacheTTL(); ttl != rocketpool.DefaultWatchedCacheTTL {
+		t.Errorf("Incorrect cache TTL %s while watching", ttl)
+	}
+
+	// Stopping restores the default TTL
+	watcher.Stop()
+	if ttl := watchedRp.GetCacheTTL(); ttl != rocketpool.CacheTTL*time.Second {
+		t.Errorf("Incorrect cache TTL %s after stopping", ttl)
+	}
+	if lastBlock := watcher.GetLastBlock(); lastBlock < 11 {
+		t.Errorf("Incorrect last block %d", lastBlock)
+	}
+
+}
+
+func TestUpgradeWatcherSubscription(t *testing.T) {
+
+	// Deploy the upgrade contract and a contract to upgrade
+	storage := newFakeStorage(t)
+	upgradeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	oldAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	newAddress := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	storage.setContract(t, rocketpool.UpgradeContractName, upgradeAddress, upgradeTestAbi)
+	storage.setContract(t, "poolseaDepositPool", oldAddress, bundleTestAbi)
+	client := storage.client()
+	watchedRp, err := rocketpool.NewRocketPool(client, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgradeAbi, err := watchedRp.GetABI(rocketpool.UpgradeContractName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgradeLog := types.Log{
+		Address: upgradeAddress,
+		Topics: []common.Hash{
+			upgradeAbi.Events["ContractUpgraded"].ID,
+			crypto.Keccak256Hash([]byte("poolseaDepositPool")),
+			common.BytesToHash(oldAddress.Bytes()),
+			common.BytesToHash(newAddress.Bytes()),
+		},
+	}
+
+	// Serve upgrade events from a client that delivers the upgrade mined while subscribing through both the subscription and the logs
+	chain := &upgradeChain{latestBlock: 10}
+	client.BlockNumberFunc = func(ctx context.Context) (uint64, error) {
+		chain.lock.Lock()
+		defer chain.lock.Unlock()
+		return chain.latestBlock, nil
+	}
+	client.FilterLogsFunc = func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+		chain.lock.Lock()
+		defer chain.lock.Unlock()
+		logs := []types.Log{}
+		for _, log := range chain.logs {
+			if log.BlockNumber >= query.FromBlock.Uint64() && log.BlockNumber <= query.ToBlock.Uint64() {
+				logs = append(logs, log)
+			}
+		}
+		return logs, nil
+	}
+	var subscribeOnce sync.Once
+	client.SubscribeFilterLogsFunc = func(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+		subscribeOnce.Do(func() {
+			storage.setContract(t, "poolseaDepositPool", newAddress, bundleTestAbi)
+			chain.addLog(upgradeLog)
+		})
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			chain.lock.Lock()
+			logs := append([]types.Log{}, chain.logs...)
+			chain.lock.Unlock()
+			for _, log := range logs {
+				select {
+				case ch <- log:
+				case <-quit:
+					return nil
+				}
+			}
+			<-quit
+			return nil
+		}), nil
+	}
+
+	// Start watching
+	watcher := rocketpool.NewUpgradeWatcher(watchedRp, rocketpool.UpgradeWatcherSettings{PollInterval: time.Millisecond})
+	changes := make(chan rocketpool.ContractChange, 10)
+	watcher.AddHook(func(change rocketpool.ContractChange) {
+		changes <- change
+	}, "poolseaDepositPool")
+	if err := watcher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	// The upgrade is handled once
+	select {
+	case change := <-changes:
+		if change.NewAddress != newAddress || change.BlockNumber != 11 {
+			t.Errorf("Incorrect change %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for contract change")
+	}
+	select {
+	case change := <-changes:
+		t.Errorf("Upgrade was handled twice: %+v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if contract, err := watchedRp.GetContract("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *contract.Address != newAddress {
+		t.Errorf("Contract was not invalidated; still at %s", contract.Address.Hex())
+	}
+
+}
@@ -1,50 +1,42 @@
 package evm
 
 import (
-	"github.com/ethereum/go-ethereum/rpc"
+	"errors"
 
-	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
 )
 
+// The chain the EVM helpers act on
+var chain *simulated.Chain
+
+// Set the simulated chain the EVM helpers act on
+func UseChain(c *simulated.Chain) {
+	chain = c
+}
+
+// Get the chain the EVM helpers act on
+func getChain() (*simulated.Chain, error) {
+	if chain == nil {
+		return nil, errors.New("no simulated chain is set; call UseChain first")
+	}
+	return chain, nil
+}
+
 // Mine a number of blocks
 func MineBlocks(numBlocks int) error {
-
-	// Initialize RPC client
-	client, err := rpc.Dial(tests.Eth1ProviderAddress)
+	c, err := getChain()
 	if err != nil {
 		return err
 	}
-
-	// Make RPC calls
-	for bi := 0; bi < numBlocks; bi++ {
-		if err := client.Call(nil, "evm_mine"); err != nil {
-			return err
-		}
-	}
-
-	// Return
+	c.MineBlocks(numBlocks)
 	return nil
-
 }
 
 // Fast forward to some number of seconds
 func IncreaseTime(time int) error {
-
-	// Initialize RPC client
-	client, err := rpc.Dial(tests.Eth1ProviderAddress)
+	c, err := getChain()
 	if err != nil {
 		return err
 	}
-
-	// Make RPC calls
-	if err := client.Call(nil, "evm_increaseTime", time); err != nil {
-		return err
-	}
-	if err := MineBlocks(1); err != nil {
-		return err
-	}
-
-	// Return
-	return nil
-
+	return c.IncreaseTime(time)
 }
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/RedDuck-Software/poolsea-go/minipool"
@@ -27,7 +28,7 @@ type minipoolCreated struct {
 }
 
 // Create a minipool
-func CreateMinipool(t *testing.T, rp *rocketpool.RocketPool, ownerAccount, nodeAccount *accounts.Account, depositAmount *big.Int, pubkey int) (*minipool.Minipool, error) {
+func CreateMinipool(t *testing.T, rp *rocketpool.RocketPool, ownerAccount, nodeAccount *accounts.Account, depositAmount *big.Int, pubkey int) (minipool.Minipool, error) {
 
 	// Mint & stake RPL required for mininpool
 	rplRequired, err := GetMinipoolRPLRequired(rp)
@@ -45,7 +46,7 @@ func CreateMinipool(t *testing.T, rp *rocketpool.RocketPool, ownerAccount, nodeA
 	}
 
 	// Get minipool manager contract
-	rocketMinipoolManager, err := rp.GetContract("poolseaMinipoolManager")
+	rocketMinipoolManager, err := rp.GetContract("poolseaMinipoolManager", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -63,19 +64,19 @@ func CreateMinipool(t *testing.T, rp *rocketpool.RocketPool, ownerAccount, nodeA
 	}
 
 	// Return minipool instance
-	return minipool.NewMinipool(rp, minipoolAddress)
+	return minipool.NewMinipool(rp, minipoolAddress, nil)
 
 }
 
 // Stake a minipool
-func StakeMinipool(rp *rocketpool.RocketPool, mp *minipool.Minipool, nodeAccount *accounts.Account) error {
+func StakeMinipool(rp *rocketpool.RocketPool, mp minipool.Minipool, nodeAccount *accounts.Account) error {
 
 	// Get validator & deposit data
 	validatorPubkey, err := validator.GetValidatorPubkey(1)
 	if err != nil {
 		return err
 	}
-	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, mp.Address, nil)
+	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, mp.GetAddress(), nil)
 	if err != nil {
 		return err
 	}
@@ -94,6 +95,28 @@ func StakeMinipool(rp *rocketpool.RocketPool, mp *minipool.Minipool, nodeAccount
 
 }
 
+// Distribute a minipool's balance
+func DistributeBalance(mp minipool.Minipool, opts *bind.TransactOpts) (common.Hash, error) {
+	if mpv3, ok := minipool.GetMinipoolAsV3(mp); ok {
+		return mpv3.DistributeBalance(false, opts)
+	}
+	if mpv2, ok := minipool.GetMinipoolAsV2(mp); ok {
+		return mpv2.DistributeBalance(opts)
+	}
+	return common.Hash{}, fmt.Errorf("minipool version %d can't distribute its balance", mp.GetVersion())
+}
+
+// Distribute a minipool's balance and finalise it; Atlas minipools finalise during a full distribution
+func DistributeBalanceAndFinalise(mp minipool.Minipool, opts *bind.TransactOpts) (common.Hash, error) {
+	if mpv3, ok := minipool.GetMinipoolAsV3(mp); ok {
+		return mpv3.DistributeBalance(false, opts)
+	}
+	if mpv2, ok := minipool.GetMinipoolAsV2(mp); ok {
+		return mpv2.DistributeBalanceAndFinalise(opts)
+	}
+	return common.Hash{}, fmt.Errorf("minipool version %d can't distribute its balance", mp.GetVersion())
+}
+
 // Get the RPL required per minipool
 func GetMinipoolRPLRequired(rp *rocketpool.RocketPool) (*big.Int, error) {
 
@@ -32,15 +32,11 @@ func Deposit(t *testing.T, rp *rocketpool.RocketPool, nodeAccount *accounts.Acco
 	salt := GetSalt()
 
 	// Get validator & deposit data
-	depositType, err := node.GetDepositType(rp, depositAmount, nil)
-	if err != nil {
-		return common.Address{}, nil, fmt.Errorf("Error getting deposit type: %w", err)
-	}
 	validatorPubkey, err := validator.GetValidatorPubkey(pubkey)
 	if err != nil {
 		return common.Address{}, nil, fmt.Errorf("Error getting validator pubkey: %w", err)
 	}
-	expectedMinipoolAddress, err := utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil)
+	expectedMinipoolAddress, err := minipool.GetExpectedAddress(rp, nodeAccount.Address, salt, nil)
 	if err != nil {
 		return common.Address{}, nil, fmt.Errorf("Error generating minipool address: %w", err)
 	}
@@ -64,7 +60,7 @@ func Deposit(t *testing.T, rp *rocketpool.RocketPool, nodeAccount *accounts.Acco
 	minNodeFee := 0.0
 	//t.Logf("Deposit:\n\tMin Node Fee: %f\n\tValidator Pubkey: %s\n\tValidator Signature: %s\n\tDeposit Data Root: %s\n\tNode Address: %s\n\tSalt: %s\n\tExpected Minipool: %s\n",
 	//    minNodeFee, validatorPubkey.Hex(), validatorSignature.Hex(), depositDataRoot.Hex(), nodeAccount.Address.Hex(), GetDefaultSalt().String(), expectedMinipoolAddress.Hex())
-	tx, err := node.Deposit(rp, minNodeFee, validatorPubkey, validatorSignature, depositDataRoot, salt, expectedMinipoolAddress, opts)
+	tx, err := node.Deposit(rp, depositAmount, minNodeFee, validatorPubkey, validatorSignature, depositDataRoot, salt, expectedMinipoolAddress, opts)
 	if err != nil {
 		return common.Address{}, nil, fmt.Errorf("Error executing deposit: %w", err)
 	}
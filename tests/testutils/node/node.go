@@ -55,7 +55,7 @@ func MintTrustedNodeBond(rp *rocketpool.RocketPool, ownerAccount *accounts.Accou
 	}
 
 	// Get RocketDAONodeTrustedActions contract address
-	rocketDAONodeTrustedActionsAddress, err := rp.GetAddress("poolseaDAONodeTrustedActions")
+	rocketDAONodeTrustedActionsAddress, err := rp.GetAddress("poolseaDAONodeTrustedActions", nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,146 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+)
+
+// Settings
+const (
+	ChainID       int64  = tests.ChainID
+	BlockGasLimit uint64 = 30000000
+)
+
+// The balance given to each test account at genesis
+var AccountBalance = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+
+// Make sure the simulated client satisfies the execution client interface
+var _ rocketpool.ExecutionClient = (*Client)(nil)
+
+// An execution client backed by go-ethereum's simulated backend.
+// Transactions are mined into their own block as soon as they are sent, like ganache's automine mode.
+type Client struct {
+	*backends.SimulatedBackend
+}
+
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.Commit()
+	return nil
+}
+
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+func (c *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// An in-process chain for tests, with every test account funded at genesis
+type Chain struct {
+	Client     *Client
+	RocketPool *rocketpool.RocketPool
+
+	// The block number of the current snapshot of the chain state
+	snapshotBlock uint64
+}
+
+// Create a new simulated chain with no contracts deployed
+func NewChain() (*Chain, error) {
+
+	// Fund the test accounts
+	alloc := core.GenesisAlloc{}
+	for ai := range tests.AccountPrivateKeys {
+		account, err := accounts.GetAccount(uint8(ai))
+		if err != nil {
+			return nil, fmt.Errorf("Could not get test account %d: %w", ai, err)
+		}
+		alloc[account.Address] = core.GenesisAccount{Balance: AccountBalance}
+	}
+
+	// Return
+	return &Chain{
+		Client: &Client{backends.NewSimulatedBackend(alloc, BlockGasLimit)},
+	}, nil
+
+}
+
+// Create a new simulated chain and deploy the Poolsea contracts in a bundle to it
+func NewChainWithContracts(bundle *ContractBundle) (*Chain, error) {
+	chain, err := NewChain()
+	if err != nil {
+		return nil, err
+	}
+	if err := chain.DeployContracts(bundle); err != nil {
+		chain.Close()
+		return nil, err
+	}
+	return chain, nil
+}
+
+// Stop the simulated chain
+func (c *Chain) Close() error {
+	return c.Client.Close()
+}
+
+// Get a transactor for an account, signing for the simulated chain ID
+func (c *Chain) GetTransactor(account *accounts.Account) (*bind.TransactOpts, error) {
+	opts, err := bind.NewKeyedTransactorWithChainID(account.PrivateKey, big.NewInt(ChainID))
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = context.Background()
+	return opts, nil
+}
+
+// Mine a number of empty blocks
+func (c *Chain) MineBlocks(numBlocks int) {
+	for bi := 0; bi < numBlocks; bi++ {
+		c.Client.Commit()
+	}
+}
+
+// Fast forward by some number of seconds and mine a block with the new time
+func (c *Chain) IncreaseTime(seconds int) error {
+	if err := c.Client.AdjustTime(time.Duration(seconds) * time.Second); err != nil {
+		return err
+	}
+	c.Client.Commit()
+	return nil
+}
+
+// Take a snapshot of the chain state
+func (c *Chain) TakeSnapshot() {
+	c.snapshotBlock = c.Client.Blockchain().CurrentBlock().NumberU64()
+}
+
+// Restore the last snapshot of the chain state, discarding every block mined since it was taken
+func (c *Chain) RevertSnapshot() error {
+	blockchain := c.Client.Blockchain()
+	if blockchain.CurrentBlock().NumberU64() < c.snapshotBlock {
+		return errors.New("the chain is behind the snapshot block")
+	}
+	if err := blockchain.SetHead(c.snapshotBlock); err != nil {
+		return fmt.Errorf("Could not revert to snapshot block %d: %w", c.snapshotBlock, err)
+	}
+	c.Client.Rollback()
+	return nil
+}
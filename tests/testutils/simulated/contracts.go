@@ -0,0 +1,291 @@
+package simulated
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+)
+
+// The name of the RocketStorage artifact in a contract bundle
+const StorageContractName = "poolseaStorage"
+
+// The core Poolsea contracts, in deployment order
+var CoreContractNames = []string{
+	"poolseaTokenRPLFixedSupply",
+	"poolseaTokenRETH",
+	"poolseaTokenRPL",
+	"poolseaAuctionManager",
+	"poolseaDepositPool",
+	"poolseaMinipool",
+	"poolseaMinipoolManager",
+	"poolseaMinipoolQueue",
+	"poolseaMinipoolStatus",
+	"poolseaMinipoolFactory",
+	"poolseaMinipoolBondReducer",
+	"poolseaNetworkBalances",
+	"poolseaNetworkFees",
+	"poolseaNetworkPrices",
+	"poolseaNetworkPenalties",
+	"poolseaRewardsPool",
+	"poolseaClaimDAO",
+	"poolseaClaimNode",
+	"poolseaClaimTrustedNode",
+	"poolseaNodeManager",
+	"poolseaNodeDeposit",
+	"poolseaNodeStaking",
+	"poolseaNodeDistributorFactory",
+	"poolseaNodeDistributorDelegate",
+	"poolseaSmoothingPool",
+	"poolseaMerkleDistributorMainnet",
+	"poolseaDAONodeTrusted",
+	"poolseaDAONodeTrustedProposals",
+	"poolseaDAONodeTrustedActions",
+	"poolseaDAONodeTrustedUpgrade",
+	"poolseaDAONodeTrustedSettingsMembers",
+	"poolseaDAONodeTrustedSettingsProposals",
+	"poolseaDAONodeTrustedSettingsMinipool",
+	"poolseaDAONodeTrustedSettingsRewards",
+	"poolseaDAOProtocol",
+	"poolseaDAOProtocolSettingsNetwork",
+	"poolseaDAOProtocolSettingsRewards",
+	"poolseaDAOProtocolSettingsInflation",
+	"poolseaDAOProtocolSettingsAuction",
+	"poolseaDAOProtocolSettingsNode",
+	"poolseaDAOProtocolSettingsDeposit",
+	"poolseaDAOProtocolSettingsMinipool",
+	"poolseaDAOProposal",
+}
+
+// Builds the constructor arguments for a contract from the addresses deployed before it
+type ConstructorArgsFunc func(storageAddress common.Address, deployed map[string]common.Address) []interface{}
+
+// Constructor arguments for the core contracts that don't just take the RocketStorage address
+var CoreConstructorArgs = map[string]ConstructorArgsFunc{
+	"poolseaTokenRPLFixedSupply": func(storageAddress common.Address, deployed map[string]common.Address) []interface{} {
+		return []interface{}{}
+	},
+	"poolseaTokenRPL": func(storageAddress common.Address, deployed map[string]common.Address) []interface{} {
+		return []interface{}{storageAddress, deployed["poolseaTokenRPLFixedSupply"]}
+	},
+}
+
+// A compiled contract, in the Truffle / Hardhat artifact format.
+// Artifacts without bytecode only have their ABI registered, like the minipool ABI.
+type ContractArtifact struct {
+	ABI      json.RawMessage `json:"abi"`
+	Bytecode string          `json:"bytecode"`
+}
+
+// A set of compiled contracts and how to deploy them
+type ContractBundle struct {
+	Artifacts       map[string]ContractArtifact
+	ContractNames   []string
+	ConstructorArgs map[string]ConstructorArgsFunc
+}
+
+//go:generate go run gen-fixture.go
+
+// The fixture bundle, with a minimal RocketStorage and placeholders for the other contracts
+//
+//go:embed fixture/*.json
+var fixtureBundle embed.FS
+
+// Returned by NewChainFromEnv when no contract bundle is configured
+var ErrNoContractBundle = fmt.Errorf("the %s environment variable isn't set to a directory of compiled Poolsea contracts", tests.ContractBundlePathEnvVar)
+
+// Create a new simulated chain and deploy the compiled Poolsea contracts in the directory named by the contract bundle environment variable
+func NewChainFromEnv() (*Chain, error) {
+	bundlePath := os.Getenv(tests.ContractBundlePathEnvVar)
+	if bundlePath == "" {
+		return nil, ErrNoContractBundle
+	}
+	bundle, err := LoadContractBundle(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewChainWithContracts(bundle)
+}
+
+// Load a contract bundle from a directory of artifacts named <contract name>.json
+func LoadContractBundle(path string) (*ContractBundle, error) {
+	return loadContractBundle(os.DirFS(path), ".")
+}
+
+// Load the fixture bundle. Its RocketStorage only implements the getters and setters, without access control, and the other
+// contracts only have a version() function, so it's suited to testing contract resolution rather than protocol logic.
+func LoadFixtureBundle() (*ContractBundle, error) {
+	return loadContractBundle(fixtureBundle, "fixture")
+}
+
+// Load a contract bundle from a directory in a filesystem
+func loadContractBundle(fsys fs.FS, dir string) (*ContractBundle, error) {
+	filenames, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	artifacts := map[string]ContractArtifact{}
+	for _, filename := range filenames {
+		bytes, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read contract artifact %s: %w", filename, err)
+		}
+		var artifact ContractArtifact
+		if err := json.Unmarshal(bytes, &artifact); err != nil {
+			return nil, fmt.Errorf("Could not decode contract artifact %s: %w", filename, err)
+		}
+		artifacts[strings.TrimSuffix(path.Base(filename), ".json")] = artifact
+	}
+	return &ContractBundle{
+		Artifacts:       artifacts,
+		ContractNames:   CoreContractNames,
+		ConstructorArgs: CoreConstructorArgs,
+	}, nil
+}
+
+// Deploy RocketStorage and the contracts in a bundle, register them in storage and initialize the chain's RocketPool
+func (c *Chain) DeployContracts(bundle *ContractBundle) error {
+
+	// Get the guardian account
+	guardian, err := accounts.GetAccount(0)
+	if err != nil {
+		return err
+	}
+	opts, err := c.GetTransactor(guardian)
+	if err != nil {
+		return err
+	}
+
+	// Deploy RocketStorage
+	storageAddress, err := c.deployContract(bundle, StorageContractName, opts)
+	if err != nil {
+		return err
+	}
+	rocketStorage, err := contracts.NewRocketStorage(storageAddress, c.Client)
+	if err != nil {
+		return err
+	}
+	deployBlock := c.Client.Blockchain().CurrentBlock().Number()
+
+	// Deploy and register each contract
+	deployed := map[string]common.Address{}
+	for _, contractName := range bundle.ContractNames {
+		artifact, exists := bundle.Artifacts[contractName]
+		if !exists {
+			return fmt.Errorf("Contract bundle is missing an artifact for %s", contractName)
+		}
+
+		// Register the ABI
+		abiEncoded, err := rocketpool.EncodeAbiStr(string(artifact.ABI))
+		if err != nil {
+			return fmt.Errorf("Could not encode %s ABI: %w", contractName, err)
+		}
+		if err := c.waitForTransaction(rocketStorage.SetString(opts, crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName)), abiEncoded)); err != nil {
+			return fmt.Errorf("Could not register %s ABI: %w", contractName, err)
+		}
+		if !hasBytecode(artifact) {
+			continue
+		}
+
+		// Deploy the contract
+		args := []interface{}{storageAddress}
+		if argsFunc, exists := bundle.ConstructorArgs[contractName]; exists {
+			args = argsFunc(storageAddress, deployed)
+		}
+		address, err := c.deployContract(bundle, contractName, opts, args...)
+		if err != nil {
+			return err
+		}
+		deployed[contractName] = address
+
+		// Register the contract
+		if err := c.waitForTransaction(rocketStorage.SetAddress(opts, crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName)), address)); err != nil {
+			return fmt.Errorf("Could not register %s address: %w", contractName, err)
+		}
+		if err := c.waitForTransaction(rocketStorage.SetString(opts, crypto.Keccak256Hash([]byte("contract.name"), address.Bytes()), contractName)); err != nil {
+			return fmt.Errorf("Could not register %s name: %w", contractName, err)
+		}
+		if err := c.waitForTransaction(rocketStorage.SetBool(opts, crypto.Keccak256Hash([]byte("contract.exists"), address.Bytes()), true)); err != nil {
+			return fmt.Errorf("Could not register %s: %w", contractName, err)
+		}
+
+	}
+
+	// Record the deployment block, which the library uses as the lower bound for event scans
+	if err := c.waitForTransaction(rocketStorage.SetUint(opts, crypto.Keccak256Hash([]byte("deploy.block")), deployBlock)); err != nil {
+		return fmt.Errorf("Could not set deployment block: %w", err)
+	}
+
+	// Lock storage to the registered contracts
+	storageRaw := &contracts.RocketStorageRaw{Contract: rocketStorage}
+	if err := c.waitForTransaction(storageRaw.Transact(opts, "setDeployedStatus")); err != nil {
+		return fmt.Errorf("Could not set storage deployed status: %w", err)
+	}
+
+	// Initialize contract manager
+	rp, err := rocketpool.NewRocketPool(c.Client, storageAddress)
+	if err != nil {
+		return err
+	}
+	c.RocketPool = rp
+	return nil
+
+}
+
+// Deploy a contract from a bundle
+func (c *Chain) deployContract(bundle *ContractBundle, contractName string, opts *bind.TransactOpts, args ...interface{}) (common.Address, error) {
+	artifact, exists := bundle.Artifacts[contractName]
+	if !exists {
+		return common.Address{}, fmt.Errorf("Contract bundle is missing an artifact for %s", contractName)
+	}
+	contractAbi, err := abi.JSON(strings.NewReader(string(artifact.ABI)))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("Could not parse %s ABI: %w", contractName, err)
+	}
+	bytecode, err := hexutil.Decode(artifact.Bytecode)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("Could not decode %s bytecode: %w", contractName, err)
+	}
+	address, tx, _, err := bind.DeployContract(opts, contractAbi, bytecode, c.Client, args...)
+	if err := c.waitForTransaction(tx, err); err != nil {
+		return common.Address{}, fmt.Errorf("Could not deploy %s: %w", contractName, err)
+	}
+	return address, nil
+}
+
+// Wait for a transaction to be mined and check that it succeeded
+func (c *Chain) waitForTransaction(tx *types.Transaction, err error) error {
+	if err != nil {
+		return err
+	}
+	receipt, err := bind.WaitMined(context.Background(), c.Client, tx)
+	if err != nil {
+		return err
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return fmt.Errorf("transaction %s reverted", tx.Hash().Hex())
+	}
+	return nil
+}
+
+// Check whether an artifact has deployable bytecode
+func hasBytecode(artifact ContractArtifact) bool {
+	return artifact.Bytecode != "" && artifact.Bytecode != "0x"
+}
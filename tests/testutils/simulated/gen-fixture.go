@@ -0,0 +1,292 @@
+//go:build ignore
+
+// Generates the fixture contract bundle in fixture/.
+// Run with `go generate ./tests/testutils/simulated/` from the repository root.
+//
+// The bundle doesn't hold the real Poolsea contracts. RocketStorage is a hand-assembled key-value store that implements the
+// RocketStorage getters and setters the library and the harness use, without any access control, and every other contract is
+// a placeholder whose only function is version(). That's enough to deploy and resolve the contracts without a compiler.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
+)
+
+// The directory the artifacts are written to
+const outputDir = "fixture"
+
+// The ABI of the placeholder contracts, with a constructor taking the given inputs
+const placeholderABI = `[{"inputs":[%s],"stateMutability":"nonpayable","type":"constructor"},{"inputs":[],"name":"version","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+
+// The placeholder constructor inputs, matching simulated.CoreConstructorArgs
+const addressInput = `{"internalType":"address","name":"_rocketStorageAddress","type":"address"}`
+
+var constructorInputs = map[string]string{
+	"poolseaTokenRPLFixedSupply": "",
+	"poolseaTokenRPL":            addressInput + `,{"internalType":"address","name":"_rocketTokenRPLFixedSupplyAddress","type":"address"}`,
+}
+
+// Contracts that only have their ABI registered, like the real minipool
+var abiOnlyContracts = map[string]bool{
+	"poolseaMinipool": true,
+}
+
+// The value types RocketStorage can hold; each one gets its own storage namespace
+const (
+	familyAddress byte = iota + 1
+	familyUint
+	familyString
+	familyBytes
+	familyBool
+	familyInt
+	familyBytes32
+)
+
+func main() {
+
+	// Write the storage contract
+	if err := writeArtifact(simulated.StorageContractName, contracts.RocketStorageABI, assembleStorage()); err != nil {
+		log.Fatal(err)
+	}
+
+	// Write the placeholders
+	placeholder := deployCode(assemble(
+		push(1), push(0), op(0x52), // mstore(0, 1)
+		push(32), push(0), op(0xf3), // return(0, 32)
+	))
+	for _, contractName := range simulated.CoreContractNames {
+		bytecode := placeholder
+		if abiOnlyContracts[contractName] {
+			bytecode = nil
+		}
+		inputs, exists := constructorInputs[contractName]
+		if !exists {
+			inputs = addressInput
+		}
+		if err := writeArtifact(contractName, fmt.Sprintf(placeholderABI, inputs), bytecode); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+}
+
+// Write an artifact to the output directory
+func writeArtifact(contractName string, abi string, bytecode []byte) error {
+	artifact := simulated.ContractArtifact{
+		ABI:      json.RawMessage(abi),
+		Bytecode: "0x",
+	}
+	if len(bytecode) > 0 {
+		artifact.Bytecode = hexutil.Encode(bytecode)
+	}
+	bytes, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing %s: %w", contractName, err)
+	}
+	return os.WriteFile(filepath.Join(outputDir, contractName+".json"), append(bytes, '\n'), 0644)
+}
+
+// Assemble the storage contract
+func assembleStorage() []byte {
+	type function struct {
+		signature string
+		body      []item
+	}
+	functions := []function{
+		{"getAddress(bytes32)", getStatic(familyAddress)},
+		{"getUint(bytes32)", getStatic(familyUint)},
+		{"getString(bytes32)", getDynamic(familyString)},
+		{"getBytes(bytes32)", getDynamic(familyBytes)},
+		{"getBool(bytes32)", getStatic(familyBool)},
+		{"getInt(bytes32)", getStatic(familyInt)},
+		{"getBytes32(bytes32)", getStatic(familyBytes32)},
+		{"setAddress(bytes32,address)", setStatic(familyAddress)},
+		{"setUint(bytes32,uint256)", setStatic(familyUint)},
+		{"setString(bytes32,string)", setDynamic(familyString)},
+		{"setBytes(bytes32,bytes)", setDynamic(familyBytes)},
+		{"setBool(bytes32,bool)", setStatic(familyBool)},
+		{"setInt(bytes32,int256)", setStatic(familyInt)},
+		{"setBytes32(bytes32,bytes32)", setStatic(familyBytes32)},
+		{"getDeployedStatus()", []item{
+			push(0), op(0x54), push(0), op(0x52), // mstore(0, sload(0))
+			push(32), push(0), op(0xf3), // return(0, 32)
+		}},
+		{"setDeployedStatus()", []item{
+			push(1), push(0), op(0x55), // sstore(0, 1)
+			op(0x00),
+		}},
+	}
+
+	// Dispatch on the selector, reverting on unknown functions
+	items := []item{push(0), op(0x35), push(224), op(0x1c)} // shr(224, calldataload(0))
+	for fi, fn := range functions {
+		items = append(items, op(0x80), pushBytes(crypto.Keccak256([]byte(fn.signature))[:4]), op(0x14), ref(fmt.Sprintf("fn%d", fi)), op(0x57))
+	}
+	items = append(items, push(0), op(0x80), op(0xfd))
+	for fi, fn := range functions {
+		items = append(items, label(fmt.Sprintf("fn%d", fi)))
+		items = append(items, prefixLabels(fmt.Sprintf("fn%d.", fi), fn.body)...)
+	}
+	return deployCode(assemble(items...))
+}
+
+// Push the storage slot for a value family and the key in the first argument
+func slot(family byte) []item {
+	return []item{
+		push(uint64(family)), push(0), op(0x52), // mstore(0, family)
+		push(4), op(0x35), push(32), op(0x52), // mstore(32, key)
+		push(64), push(0), op(0x20), // keccak256(0, 64)
+	}
+}
+
+// Store a single word value
+func setStatic(family byte) []item {
+	return append(slot(family),
+		push(36), op(0x35), op(0x90), op(0x55), // sstore(slot, calldataload(36))
+		op(0x00),
+	)
+}
+
+// Return a single word value
+func getStatic(family byte) []item {
+	return append(slot(family),
+		op(0x54), push(0), op(0x52), // mstore(0, sload(slot))
+		push(32), push(0), op(0xf3), // return(0, 32)
+	)
+}
+
+// Store a string or bytes value as its length followed by its padded words
+func setDynamic(family byte) []item {
+	return append(slot(family),
+		push(36), op(0x35), push(4), op(0x01), // lenPos = 4 + calldataload(36)
+		op(0x80), op(0x35), // len = calldataload(lenPos)
+		op(0x80), op(0x83), op(0x55), // sstore(slot, len)
+		push(31), op(0x01), push(5), op(0x1c), // words = (len + 31) >> 5
+		push(0), // i
+		label("loop"),
+		op(0x81), op(0x81), op(0x10), op(0x15), ref("end"), op(0x57), // if !(i < words) goto end
+		op(0x80), push(5), op(0x1b), op(0x83), op(0x01), push(32), op(0x01), op(0x35), // value = calldataload(lenPos + 32 + i*32)
+		op(0x81), op(0x85), op(0x01), push(1), op(0x01), op(0x55), // sstore(slot + 1 + i, value)
+		push(1), op(0x01), ref("loop"), op(0x56), // i++
+		label("end"),
+		op(0x00),
+	)
+}
+
+// Return a string or bytes value
+func getDynamic(family byte) []item {
+	return append(slot(family),
+		op(0x80), op(0x54), // len = sload(slot)
+		push(32), push(0), op(0x52), // mstore(0, 32)
+		op(0x80), push(32), op(0x52), // mstore(32, len)
+		push(31), op(0x01), push(5), op(0x1c), // words = (len + 31) >> 5
+		push(0), // i
+		label("loop"),
+		op(0x81), op(0x81), op(0x10), op(0x15), ref("end"), op(0x57), // if !(i < words) goto end
+		op(0x80), op(0x83), op(0x01), push(1), op(0x01), op(0x54), // value = sload(slot + 1 + i)
+		op(0x81), push(5), op(0x1b), push(64), op(0x01), op(0x52), // mstore(64 + i*32, value)
+		push(1), op(0x01), ref("loop"), op(0x56), // i++
+		label("end"),
+		op(0x50), push(5), op(0x1b), push(64), op(0x01), push(0), op(0xf3), // return(0, 64 + words*32)
+	)
+}
+
+// Wrap runtime code in init code that returns it
+func deployCode(runtime []byte) []byte {
+	const initLength = 13
+	init := []byte{
+		0x61, byte(len(runtime) >> 8), byte(len(runtime)), // push2 len
+		0x80,                   // dup1
+		0x61, 0x00, initLength, // push2 offset
+		0x60, 0x00, // push1 0
+		0x39,       // codecopy
+		0x60, 0x00, // push1 0
+		0xf3, // return
+	}
+	return append(init, runtime...)
+}
+
+// An item in an assembly listing
+type item struct {
+	code  []byte
+	label string // Set for jump destinations
+	ref   string // Set for pushes of jump destinations
+}
+
+func op(opcode byte) item {
+	return item{code: []byte{opcode}}
+}
+
+func push(value uint64) item {
+	bytes := []byte{}
+	for ; value > 0; value >>= 8 {
+		bytes = append([]byte{byte(value)}, bytes...)
+	}
+	if len(bytes) == 0 {
+		bytes = []byte{0}
+	}
+	return pushBytes(bytes)
+}
+
+func pushBytes(bytes []byte) item {
+	return item{code: append([]byte{0x5f + byte(len(bytes))}, bytes...)}
+}
+
+func label(name string) item {
+	return item{code: []byte{0x5b}, label: name}
+}
+
+func ref(name string) item {
+	return item{code: []byte{0x61, 0, 0}, ref: name}
+}
+
+// Scope the labels in a function body
+func prefixLabels(prefix string, items []item) []item {
+	prefixed := make([]item, len(items))
+	for i, it := range items {
+		if it.label != "" {
+			it.label = prefix + it.label
+		}
+		if it.ref != "" {
+			it.ref = prefix + it.ref
+		}
+		prefixed[i] = it
+	}
+	return prefixed
+}
+
+// Assemble a listing, resolving jump destinations
+func assemble(items ...item) []byte {
+	labels := map[string]int{}
+	offset := 0
+	for _, it := range items {
+		if it.label != "" {
+			labels[it.label] = offset
+		}
+		offset += len(it.code)
+	}
+	code := []byte{}
+	for _, it := range items {
+		if it.ref != "" {
+			target, exists := labels[it.ref]
+			if !exists {
+				log.Fatalf("unknown label %s", it.ref)
+			}
+			code = append(code, 0x61, byte(target>>8), byte(target))
+			continue
+		}
+		code = append(code, it.code...)
+	}
+	return code
+}
@@ -0,0 +1,228 @@
+package stub
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Returned by any stub client method that hasn't been given an implementation
+var ErrNotImplemented = errors.New("method not implemented by stub client")
+
+// An in-memory execution client for tests; each method delegates to its function field if one is set
+type Client struct {
+	CodeAtFunc              func(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContractFunc        func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	HeaderByHashFunc        func(ctx context.Context, hash common.Hash) (*types.Header, error)
+	HeaderByNumberFunc      func(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingCodeAtFunc       func(ctx context.Context, account common.Address) ([]byte, error)
+	PendingNonceAtFunc      func(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPriceFunc     func(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCapFunc    func(ctx context.Context) (*big.Int, error)
+	EstimateGasFunc         func(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransactionFunc     func(ctx context.Context, tx *types.Transaction) error
+	FilterLogsFunc          func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+	SubscribeFilterLogsFunc func(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	TransactionReceiptFunc  func(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumberFunc         func(ctx context.Context) (uint64, error)
+	BalanceAtFunc           func(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionByHashFunc   func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	NonceAtFunc             func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SyncProgressFunc        func(ctx context.Context) (*ethereum.SyncProgress, error)
+}
+
+// Create a stub client that blocks every request until its context is done, simulating an unresponsive node
+func NewSlowClient() *Client {
+	return &Client{
+		CodeAtFunc: func(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+			return nil, block(ctx)
+		},
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			return nil, block(ctx)
+		},
+		HeaderByHashFunc: func(ctx context.Context, hash common.Hash) (*types.Header, error) {
+			return nil, block(ctx)
+		},
+		HeaderByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			return nil, block(ctx)
+		},
+		PendingCodeAtFunc: func(ctx context.Context, account common.Address) ([]byte, error) {
+			return nil, block(ctx)
+		},
+		PendingNonceAtFunc: func(ctx context.Context, account common.Address) (uint64, error) {
+			return 0, block(ctx)
+		},
+		SuggestGasPriceFunc: func(ctx context.Context) (*big.Int, error) {
+			return nil, block(ctx)
+		},
+		SuggestGasTipCapFunc: func(ctx context.Context) (*big.Int, error) {
+			return nil, block(ctx)
+		},
+		EstimateGasFunc: func(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+			return 0, block(ctx)
+		},
+		SendTransactionFunc: func(ctx context.Context, tx *types.Transaction) error {
+			return block(ctx)
+		},
+		FilterLogsFunc: func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+			return nil, block(ctx)
+		},
+		SubscribeFilterLogsFunc: func(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+			return nil, block(ctx)
+		},
+		TransactionReceiptFunc: func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			return nil, block(ctx)
+		},
+		BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+			return 0, block(ctx)
+		},
+		BalanceAtFunc: func(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+			return nil, block(ctx)
+		},
+		TransactionByHashFunc: func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+			return nil, false, block(ctx)
+		},
+		NonceAtFunc: func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+			return 0, block(ctx)
+		},
+		SyncProgressFunc: func(ctx context.Context) (*ethereum.SyncProgress, error) {
+			return nil, block(ctx)
+		},
+	}
+}
+
+// Wait for a context to be done and return its error
+func block(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (c *Client) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if c.CodeAtFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.CodeAtFunc(ctx, contract, blockNumber)
+}
+
+func (c *Client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if c.CallContractFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.CallContractFunc(ctx, call, blockNumber)
+}
+
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if c.HeaderByHashFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.HeaderByHashFunc(ctx, hash)
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if c.HeaderByNumberFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.HeaderByNumberFunc(ctx, number)
+}
+
+func (c *Client) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	if c.PendingCodeAtFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.PendingCodeAtFunc(ctx, account)
+}
+
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if c.PendingNonceAtFunc == nil {
+		return 0, ErrNotImplemented
+	}
+	return c.PendingNonceAtFunc(ctx, account)
+}
+
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	if c.SuggestGasPriceFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.SuggestGasPriceFunc(ctx)
+}
+
+func (c *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	if c.SuggestGasTipCapFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.SuggestGasTipCapFunc(ctx)
+}
+
+func (c *Client) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	if c.EstimateGasFunc == nil {
+		return 0, ErrNotImplemented
+	}
+	return c.EstimateGasFunc(ctx, call)
+}
+
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.SendTransactionFunc == nil {
+		return ErrNotImplemented
+	}
+	return c.SendTransactionFunc(ctx, tx)
+}
+
+func (c *Client) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if c.FilterLogsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.FilterLogsFunc(ctx, query)
+}
+
+func (c *Client) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if c.SubscribeFilterLogsFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.SubscribeFilterLogsFunc(ctx, query, ch)
+}
+
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if c.TransactionReceiptFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.TransactionReceiptFunc(ctx, txHash)
+}
+
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	if c.BlockNumberFunc == nil {
+		return 0, ErrNotImplemented
+	}
+	return c.BlockNumberFunc(ctx)
+}
+
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	if c.BalanceAtFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.BalanceAtFunc(ctx, account, blockNumber)
+}
+
+func (c *Client) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	if c.TransactionByHashFunc == nil {
+		return nil, false, ErrNotImplemented
+	}
+	return c.TransactionByHashFunc(ctx, hash)
+}
+
+func (c *Client) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	if c.NonceAtFunc == nil {
+		return 0, ErrNotImplemented
+	}
+	return c.NonceAtFunc(ctx, account, blockNumber)
+}
+
+func (c *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	if c.SyncProgressFunc == nil {
+		return nil, ErrNotImplemented
+	}
+	return c.SyncProgressFunc(ctx)
+}
@@ -14,7 +14,7 @@ import (
 func MintRPL(rp *rocketpool.RocketPool, ownerAccount *accounts.Account, toAccount *accounts.Account, amount *big.Int) error {
 
 	// Get RPL token contract address
-	rocketTokenRPLAddress, err := rp.GetAddress("poolseaTokenRPL")
+	rocketTokenRPLAddress, err := rp.GetAddress("poolseaTokenRPL", nil)
 	if err != nil {
 		return err
 	}
@@ -37,7 +37,7 @@ func MintRPL(rp *rocketpool.RocketPool, ownerAccount *accounts.Account, toAccoun
 
 // Mint an amount of fixed-supply RPL to an account
 func MintFixedSupplyRPL(rp *rocketpool.RocketPool, ownerAccount *accounts.Account, toAccount *accounts.Account, amount *big.Int) error {
-	rocketTokenFixedSupplyRPL, err := rp.GetContract("poolseaTokenRPLFixedSupply")
+	rocketTokenFixedSupplyRPL, err := rp.GetContract("poolseaTokenRPLFixedSupply", nil)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,102 @@
+package beacon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/beacon"
+)
+
+// Create a pubkey for testing
+func testPubkey(b byte) types.ValidatorPubkey {
+	var pubkey types.ValidatorPubkey
+	pubkey[0] = b
+	return pubkey
+}
+
+// Serve a Beacon API with one validator and the finality checkpoints of the head state
+func newBeaconServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		switch r.URL.Path {
+		case "/eth/v1/beacon/states/head/validators":
+			data := []string{}
+			for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+				if id == "0x"+testPubkey(1).Hex() {
+					data = append(data, fmt.Sprintf(`{"index":"7","balance":"32010000000","status":"active_ongoing","validator":{"pubkey":"%s","effective_balance":"32000000000","slashed":false,"activation_eligibility_epoch":"1","activation_epoch":"2","exit_epoch":"18446744073709551615","withdrawable_epoch":"18446744073709551615"}}`, id))
+				}
+			}
+			fmt.Fprintf(w, `{"execution_optimistic":false,"data":[%s]}`, strings.Join(data, ","))
+		case "/eth/v1/beacon/states/head/finality_checkpoints":
+			fmt.Fprint(w, `{"data":{"previous_justified":{"epoch":"9","root":"0x01"},"current_justified":{"epoch":"10","root":"0x02"},"finalized":{"epoch":"8","root":"0x03"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":404,"message":"State not found"}`)
+		}
+	}))
+}
+
+func TestHttpClient(t *testing.T) {
+
+	// Get validators in batches of one
+	requests := 0
+	server := newBeaconServer(t, &requests)
+	defer server.Close()
+	client := beacon.NewHttpClient(server.URL + "/")
+	client.ValidatorBatchSize = 1
+	validators, err := client.GetValidators(context.Background(), types.HeadBeaconState, []types.ValidatorPubkey{testPubkey(1), testPubkey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(validators) != 1 {
+		t.Fatalf("Incorrect validators %+v after %d requests", validators, requests)
+	}
+	validator := validators[testPubkey(1)]
+	if validator.Index != 7 || validator.Balance != 32010000000 || validator.EffectiveBalance != 32000000000 ||
+		validator.Status != types.ValidatorActiveOngoing || validator.ActivationEpoch != 2 || validator.ExitEpoch != types.FarFutureEpoch {
+		t.Errorf("Incorrect validator %+v", validator)
+	}
+
+	// Get the finality checkpoints
+	checkpoints, err := client.GetFinalityCheckpoints(context.Background(), types.HeadBeaconState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints.Finalized.Epoch != 8 || checkpoints.CurrentJustified.Root != common.HexToHash("0x02") {
+		t.Errorf("Incorrect checkpoints %+v", checkpoints)
+	}
+
+	// API errors are reported with their status and message
+	_, err = client.GetFinalityCheckpoints(context.Background(), types.SlotBeaconState(123))
+	var apiErr *beacon.ApiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "State not found" {
+		t.Errorf("Incorrect error %v", err)
+	}
+
+}
+
+func TestFakeClient(t *testing.T) {
+
+	// Validators are only served from the state they were set in
+	client := beacon.NewFakeClient()
+	client.SetValidator(types.SlotBeaconState(100), types.ValidatorDetails{Pubkey: testPubkey(1), Balance: 32e9})
+	validators, err := client.GetValidators(context.Background(), types.SlotBeaconState(100), []types.ValidatorPubkey{testPubkey(1), testPubkey(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validators) != 1 || validators[testPubkey(1)].Balance != 32e9 {
+		t.Errorf("Incorrect validators %+v", validators)
+	}
+	if _, err := client.GetValidators(context.Background(), types.SlotBeaconState(101), nil); err == nil {
+		t.Error("Expected error for missing state")
+	}
+
+}
@@ -0,0 +1,261 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/clients"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// Fast retry settings for tests
+var testRetrySettings = clients.RetrySettings{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+// Create a stub client whose BlockNumber calls fail with the given errors in order, then succeed
+func newFlakyClient(blockNumber uint64, errs ...error) (*stub.Client, *int) {
+	calls := new(int)
+	client := &stub.Client{
+		BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+			*calls++
+			if *calls <= len(errs) {
+				return 0, errs[*calls-1]
+			}
+			return blockNumber, nil
+		},
+	}
+	return client, calls
+}
+
+func TestIsTransientError(t *testing.T) {
+
+	// Transient errors
+	for _, err := range []error{
+		errors.New("dial tcp 127.0.0.1:8545: connect: connection refused"),
+		rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"},
+		rpc.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"},
+	} {
+		if !clients.IsTransientError(err) {
+			t.Errorf("Expected error [%s] to be transient", err.Error())
+		}
+	}
+
+	// Permanent errors
+	for _, err := range []error{
+		nil,
+		errors.New("execution reverted: Minipool is not staking"),
+		rpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"},
+		ethereum.NotFound,
+		context.DeadlineExceeded,
+	} {
+		if clients.IsTransientError(err) {
+			t.Errorf("Expected error [%v] not to be transient", err)
+		}
+	}
+
+}
+
+func TestRetryClient(t *testing.T) {
+
+	// Succeed after transient errors
+	inner, calls := newFlakyClient(100, errors.New("connection reset by peer"), errors.New("connection reset by peer"))
+	client := clients.NewRetryClient(inner, testRetrySettings)
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 100 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+	if *calls != 3 {
+		t.Errorf("Incorrect call count %d", *calls)
+	}
+
+	// Don't retry permanent errors
+	revertErr := errors.New("execution reverted")
+	inner, calls = newFlakyClient(100, revertErr)
+	client = clients.NewRetryClient(inner, testRetrySettings)
+	if _, err := client.BlockNumber(context.Background()); !errors.Is(err, revertErr) {
+		t.Errorf("Expected revert error, got %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("Incorrect call count %d", *calls)
+	}
+
+	// Give up after the maximum number of attempts
+	timeoutErr := errors.New("i/o timeout")
+	inner, calls = newFlakyClient(100, timeoutErr, timeoutErr, timeoutErr, timeoutErr)
+	client = clients.NewRetryClient(inner, testRetrySettings)
+	if _, err := client.BlockNumber(context.Background()); !errors.Is(err, timeoutErr) {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if *calls != testRetrySettings.MaxAttempts {
+		t.Errorf("Incorrect call count %d", *calls)
+	}
+
+}
+
+func TestFailoverClient(t *testing.T) {
+
+	// Fall back when the primary is unavailable
+	primary, primaryCalls := newFlakyClient(1, errors.New("connection refused"))
+	fallback, fallbackCalls := newFlakyClient(2)
+	client := clients.NewFailoverClient(primary, fallback)
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 2 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+
+	// Go back to the primary once it recovers
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 1 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+	if *primaryCalls != 2 || *fallbackCalls != 1 {
+		t.Errorf("Incorrect call counts %d and %d", *primaryCalls, *fallbackCalls)
+	}
+
+	// Don't fall back on permanent errors
+	revertErr := errors.New("execution reverted")
+	primary, _ = newFlakyClient(1, revertErr)
+	fallback, fallbackCalls = newFlakyClient(2)
+	client = clients.NewFailoverClient(primary, fallback)
+	if _, err := client.BlockNumber(context.Background()); !errors.Is(err, revertErr) {
+		t.Errorf("Expected revert error, got %v", err)
+	}
+	if *fallbackCalls != 0 {
+		t.Errorf("Incorrect fallback call count %d", *fallbackCalls)
+	}
+
+	// Report every failure when all clients are down
+	refusedErr := errors.New("connection refused")
+	primary, _ = newFlakyClient(1, refusedErr)
+	fallback, _ = newFlakyClient(2, refusedErr)
+	client = clients.NewFailoverClient(primary, fallback)
+	if _, err := client.BlockNumber(context.Background()); !errors.Is(err, refusedErr) {
+		t.Errorf("Expected connection refused error, got %v", err)
+	}
+
+	// Fall back on transient errors when ShouldFailover isn't set
+	primary, _ = newFlakyClient(1, errors.New("connection refused"))
+	fallback, _ = newFlakyClient(2)
+	client = clients.NewFailoverClient(primary, fallback)
+	client.ShouldFailover = nil
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 2 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+
+}
+
+func TestRateLimitedClient(t *testing.T) {
+
+	// Allow a burst of 1 request then 20 per second
+	inner, calls := newFlakyClient(1)
+	client := clients.NewRateLimitedClient(inner, 20, 1)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.BlockNumber(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Requests were not rate limited; 3 requests took %s", elapsed)
+	}
+	if *calls != 3 {
+		t.Errorf("Incorrect call count %d", *calls)
+	}
+
+	// Stop waiting for a token when the context is done
+	client = clients.NewRateLimitedClient(inner, 0.1, 1)
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.BlockNumber(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+
+}
+
+func TestComposedClients(t *testing.T) {
+
+	// Retry a failover group whose members are both briefly unavailable
+	refusedErr := errors.New("connection refused")
+	primary, _ := newFlakyClient(1, refusedErr, refusedErr)
+	fallback, _ := newFlakyClient(2, refusedErr, refusedErr)
+	client := clients.NewRetryClient(clients.NewFailoverClient(clients.NewRateLimitedClient(primary, 1000, 10), fallback), testRetrySettings)
+	if blockNumber, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if blockNumber != 1 {
+		t.Errorf("Incorrect block number %d", blockNumber)
+	}
+
+}
+
+func TestSendTransactionIsNotRepeated(t *testing.T) {
+
+	// Create clients whose sends time out
+	timeoutErr := errors.New("i/o timeout")
+	newSender := func() (*stub.Client, *int) {
+		calls := new(int)
+		client := &stub.Client{
+			BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+				return 1, nil
+			},
+			SendTransactionFunc: func(ctx context.Context, tx *types.Transaction) error {
+				*calls++
+				return timeoutErr
+			},
+		}
+		return client, calls
+	}
+	primary, primaryCalls := newSender()
+	fallback, fallbackCalls := newSender()
+	client := clients.NewRetryClient(clients.NewFailoverClient(primary, fallback), testRetrySettings)
+
+	// The send is attempted once against the primary, and the error is classified as transient
+	err := client.SendTransaction(context.Background(), types.NewTx(&types.LegacyTx{}))
+	var sendErr *clients.SendError
+	if !errors.As(err, &sendErr) || !sendErr.Transient || !errors.Is(err, timeoutErr) {
+		t.Errorf("Expected transient send error, got %v", err)
+	}
+	if *primaryCalls != 1 || *fallbackCalls != 0 {
+		t.Errorf("Incorrect send counts %d and %d", *primaryCalls, *fallbackCalls)
+	}
+
+	// Sends go to whichever client handled the last successful request
+	primary.BlockNumberFunc = func(ctx context.Context) (uint64, error) {
+		return 0, errors.New("connection refused")
+	}
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client.SendTransaction(context.Background(), types.NewTx(&types.LegacyTx{}))
+	if *primaryCalls != 1 || *fallbackCalls != 1 {
+		t.Errorf("Incorrect send counts after failover %d and %d", *primaryCalls, *fallbackCalls)
+	}
+
+	// Permanent errors aren't transient
+	nonceErr := errors.New("nonce too low")
+	fallback.SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error {
+		return nonceErr
+	}
+	err = client.SendTransaction(context.Background(), types.NewTx(&types.LegacyTx{}))
+	if !errors.As(err, &sendErr) || sendErr.Transient || err.Error() != nonceErr.Error() {
+		t.Errorf("Expected permanent send error, got %v", err)
+	}
+
+}
@@ -0,0 +1,178 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/clients"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// A JSON-RPC error with a code and data, as returned by a node for a reverted call
+type revertError struct{}
+
+func (e revertError) Error() string          { return "execution reverted" }
+func (e revertError) ErrorCode() int         { return 3 }
+func (e revertError) ErrorData() interface{} { return "0x08c379a0" }
+
+// Create a stub node that serves a fixed contract address from RocketStorage, a block header, logs and balances
+func newRecordableClient(t *testing.T, contractAddress common.Address) (*stub.Client, *int) {
+	storageAbi, err := abi.JSON(strings.NewReader(contracts.RocketStorageABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addressResponse, err := storageAbi.Methods["getAddress"].Outputs.Pack(contractAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := new(int)
+	client := &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			*calls++
+			if blockNumber != nil && blockNumber.Uint64() == 1 {
+				return nil, revertError{}
+			}
+			return addressResponse, nil
+		},
+		HeaderByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			*calls++
+			return &types.Header{Number: big.NewInt(100), Time: 1234, Difficulty: big.NewInt(0), BaseFee: big.NewInt(7)}, nil
+		},
+		FilterLogsFunc: func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+			*calls++
+			return []types.Log{{Address: contractAddress, Topics: []common.Hash{common.HexToHash("0x01")}, Data: []byte{}, BlockNumber: 90}}, nil
+		},
+		BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+			*calls++
+			return uint64(100 + *calls), nil
+		},
+		BalanceAtFunc: func(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+			*calls++
+			return big.NewInt(42), nil
+		},
+	}
+	return client, calls
+}
+
+func TestRecordAndReplay(t *testing.T) {
+
+	// Record requests against a stub node
+	contractAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	inner, innerCalls := newRecordableClient(t, contractAddress)
+	recorder := clients.NewRecordingClient(inner)
+	recordedRp, err := rocketpool.NewRocketPool(recorder, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address, err := recordedRp.GetAddress("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *address != contractAddress {
+		t.Fatalf("Incorrect recorded contract address %s", address.Hex())
+	}
+	ctx := context.Background()
+	query := ethereum.FilterQuery{Addresses: []common.Address{contractAddress}, FromBlock: big.NewInt(0), ToBlock: big.NewInt(100)}
+	if _, err := recorder.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.FilterLogs(ctx, query); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.BalanceAt(ctx, contractAddress, nil); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := recorder.BlockNumber(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := recorder.CallContract(ctx, ethereum.CallMsg{To: &contractAddress}, big.NewInt(1)); err == nil {
+		t.Fatal("Expected recorded call to fail")
+	}
+
+	// Save the fixture and replay it from the file
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	recordedCalls := *innerCalls
+	replayer, err := clients.NewReplayClientFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay the contract manager requests
+	replayedRp, err := rocketpool.NewRocketPool(replayer, common.HexToAddress(tests.RocketStorageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address, err := replayedRp.GetAddress("poolseaDepositPool", nil); err != nil {
+		t.Fatal(err)
+	} else if *address != contractAddress {
+		t.Errorf("Incorrect replayed contract address %s", address.Hex())
+	}
+
+	// Replay the direct requests
+	if header, err := replayer.HeaderByNumber(ctx, nil); err != nil {
+		t.Fatal(err)
+	} else if header.Number.Uint64() != 100 || header.Time != 1234 || header.BaseFee.Uint64() != 7 {
+		t.Errorf("Incorrect replayed header %+v", header)
+	}
+	if logs, err := replayer.FilterLogs(ctx, query); err != nil {
+		t.Fatal(err)
+	} else if len(logs) != 1 || logs[0].Address != contractAddress || logs[0].BlockNumber != 90 {
+		t.Errorf("Incorrect replayed logs %+v", logs)
+	}
+	if balance, err := replayer.BalanceAt(ctx, contractAddress, nil); err != nil {
+		t.Fatal(err)
+	} else if balance.Uint64() != 42 {
+		t.Errorf("Incorrect replayed balance %s", balance.String())
+	}
+
+	// Repeated requests are replayed in order, sticking on the last response
+	for _, expected := range []uint64{105, 106, 106} {
+		if blockNumber, err := replayer.BlockNumber(ctx); err != nil {
+			t.Fatal(err)
+		} else if blockNumber != expected {
+			t.Errorf("Incorrect replayed block number %d, expected %d", blockNumber, expected)
+		}
+	}
+
+	// Errors keep their JSON-RPC code and data
+	_, err = replayer.CallContract(ctx, ethereum.CallMsg{To: &contractAddress}, big.NewInt(1))
+	var rpcErr rpc.Error
+	var dataErr rpc.DataError
+	if !errors.As(err, &rpcErr) || !errors.As(err, &dataErr) {
+		t.Fatalf("Expected replayed JSON-RPC error, got %v", err)
+	} else if err.Error() != "execution reverted" || rpcErr.ErrorCode() != 3 || dataErr.ErrorData() != "0x08c379a0" {
+		t.Errorf("Incorrect replayed error %s (%d, %v)", err.Error(), rpcErr.ErrorCode(), dataErr.ErrorData())
+	}
+
+	// Requests that weren't recorded fail
+	if _, err := replayer.BalanceAt(ctx, common.Address{}, nil); !errors.Is(err, clients.ErrNotRecorded) {
+		t.Errorf("Expected not recorded error, got %v", err)
+	}
+	if _, err := replayer.SuggestGasTipCap(ctx); !errors.Is(err, clients.ErrNotReplayable) {
+		t.Errorf("Expected not replayable error, got %v", err)
+	}
+
+	// Replaying never touches the node
+	if *innerCalls != recordedCalls {
+		t.Errorf("Replay client made %d requests to the node", *innerCalls-recordedCalls)
+	}
+
+}
@@ -6,33 +6,27 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/RedDuck-Software/poolsea-go/utils/eth"
 
-	"github.com/RedDuck-Software/poolsea-go/tests"
 	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
-	"github.com/RedDuck-Software/poolsea-go/tests/testutils/evm"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
 	"github.com/RedDuck-Software/poolsea-go/utils"
 )
 
 func TestSendTransaction(t *testing.T) {
 
-	// State snapshotting
-	if err := evm.TakeSnapshot(); err != nil {
+	// Start a simulated chain
+	chain, err := simulated.NewChain()
+	if err != nil {
 		t.Fatal(err)
 	}
 	t.Cleanup(func() {
-		if err := evm.RevertSnapshot(); err != nil {
-			t.Fatal(err)
+		if err := chain.Close(); err != nil {
+			t.Error(err)
 		}
 	})
-
-	// Initialize eth client
-	client, err := ethclient.Dial(tests.Eth1ProviderAddress)
-	if err != nil {
-		t.Fatal(err)
-	}
+	client := chain.Client
 
 	// Initialize accounts
 	userAccount, err := accounts.GetAccount(9)
@@ -47,7 +41,9 @@ func TestSendTransaction(t *testing.T) {
 	// Send transaction
 	opts := userAccount.GetTransactor()
 	opts.Value = sendAmount
-	hash, err := eth.SendTransaction(client, toAddress, big.NewInt(1337), opts) // Ganache's default chain ID is 1337
+	opts.GasFeeCap = eth.GweiToWei(10)
+	opts.GasTipCap = eth.GweiToWei(1)
+	hash, err := eth.SendTransaction(client, toAddress, big.NewInt(simulated.ChainID), opts)
 	if err != nil {
 		t.Fatal(err)
 	}
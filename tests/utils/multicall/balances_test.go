@@ -0,0 +1,93 @@
+package multicall
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// Get a client serving a balances contract where each balance encodes the address and token it belongs to
+func newBalancesClient(t *testing.T, calls *int, lock *sync.Mutex) *stub.Client {
+	balancesAbi, err := abi.JSON(strings.NewReader(multicall.BalancesABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := balancesAbi.Methods["balances"]
+	return &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			lock.Lock()
+			*calls++
+			lock.Unlock()
+			args, err := method.Inputs.Unpack(call.Data[4:])
+			if err != nil {
+				return nil, err
+			}
+			users := args[0].([]common.Address)
+			tokens := args[1].([]common.Address)
+			balances := []*big.Int{}
+			for _, user := range users {
+				for _, token := range tokens {
+					balance := new(big.Int).Mul(new(big.Int).SetBytes(user.Bytes()), big.NewInt(1000))
+					balances = append(balances, balance.Add(balance, new(big.Int).SetBytes(token.Bytes())))
+				}
+			}
+			return method.Outputs.Pack(balances)
+		},
+	}
+}
+
+func TestTokenBalances(t *testing.T) {
+
+	// Get the balances of more addresses than fit in one call
+	var calls int
+	var lock sync.Mutex
+	batcher, err := multicall.NewBalanceBatcher(newBalancesClient(t, &calls, &lock), common.HexToAddress("0x3333333333333333333333333333333333333333"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses := make([]common.Address, 600)
+	for i := range addresses {
+		addresses[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	tokens := []common.Address{{}, common.BigToAddress(big.NewInt(1)), common.BigToAddress(big.NewInt(2))}
+	balances, err := batcher.GetTokenBalances(addresses, tokens, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls < 2 {
+		t.Errorf("Expected the balances to be split across calls, got %d", calls)
+	}
+
+	// The balances are indexed by address and then token
+	if len(balances) != len(addresses) {
+		t.Fatalf("Incorrect balance count %d", len(balances))
+	}
+	for i, addressBalances := range balances {
+		for j, balance := range addressBalances {
+			expected := int64((i+1)*1000 + j)
+			if balance.Int64() != expected {
+				t.Fatalf("Incorrect balance %s for address %d token %d", balance, i, j)
+			}
+		}
+	}
+
+	// ETH balances use the empty token
+	ethBalances, err := batcher.GetEthBalances(addresses[:2], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ethBalances[0].Int64() != 1000 || ethBalances[1].Int64() != 2000 {
+		t.Errorf("Incorrect ETH balances %v", ethBalances)
+	}
+
+}
@@ -0,0 +1,314 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// A contract with a getter and a method that always reverts with a custom error
+const targetAbi = `[
+	{"type":"function","name":"getValue","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+	{"type":"function","name":"getBroken","inputs":[],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+	{"type":"error","name":"NotReady","inputs":[{"name":"until","type":"uint256"}]}
+]`
+
+// Test addresses and values
+var (
+	multicallAddress = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	targetAddress    = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	blockNumber      = big.NewInt(100)
+	ethBalance       = big.NewInt(5e18)
+	targetValue      = big.NewInt(42)
+)
+
+// A JSON-RPC error carrying revert data, as returned by geth
+type revertDataError struct {
+	data string
+}
+
+func (e revertDataError) Error() string          { return "execution reverted" }
+func (e revertDataError) ErrorCode() int         { return 3 }
+func (e revertDataError) ErrorData() interface{} { return e.data }
+
+// A multicall contract that executes calls against the target contract, counting the batches it runs
+type fakeMulticall struct {
+	multicallAbi abi.ABI
+	targetAbi    abi.ABI
+	maxCalls     int // The most calls in a batch before it runs out of gas, if set
+	batches      int
+	lock         sync.Mutex
+}
+
+// Create a fake multicall contract
+func newFakeMulticall(t *testing.T) *fakeMulticall {
+	multicallAbi, err := abi.JSON(strings.NewReader(multicall.Multicall3ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetAbi, err := abi.JSON(strings.NewReader(targetAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeMulticall{
+		multicallAbi: multicallAbi,
+		targetAbi:    targetAbi,
+	}
+}
+
+// Get the target contract
+func (m *fakeMulticall) target() *rocketpool.Contract {
+	address := targetAddress
+	return &rocketpool.Contract{
+		Address: &address,
+		ABI:     &m.targetAbi,
+		Name:    "poolseaNetworkPrices",
+	}
+}
+
+// Execute a single call, returning whether it succeeded and its return or revert data
+func (m *fakeMulticall) execute(target common.Address, data []byte) (bool, []byte) {
+	abis := map[common.Address]abi.ABI{multicallAddress: m.multicallAbi, targetAddress: m.targetAbi}
+	contractAbi, exists := abis[target]
+	if !exists {
+		return true, nil
+	}
+	method, err := contractAbi.MethodById(data[:4])
+	if err != nil {
+		return false, nil
+	}
+	var output []byte
+	switch method.Name {
+	case "getBlockNumber":
+		output, err = method.Outputs.Pack(blockNumber)
+	case "getEthBalance":
+		output, err = method.Outputs.Pack(ethBalance)
+	case "getValue":
+		output, err = method.Outputs.Pack(targetValue)
+	case "getBroken":
+		notReady := m.targetAbi.Errors["NotReady"]
+		output, _ = notReady.Inputs.Pack(big.NewInt(1000))
+		return false, append(notReady.ID[:4], output...)
+	}
+	if err != nil {
+		return false, nil
+	}
+	return true, output
+}
+
+// Get a client that serves the contract
+func (m *fakeMulticall) client() *stub.Client {
+	return &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			m.batches++
+			method, err := m.multicallAbi.MethodById(call.Data[:4])
+			if err != nil {
+				return nil, err
+			}
+			args, err := method.Inputs.Unpack(call.Data[4:])
+			if err != nil {
+				return nil, err
+			}
+			type result struct {
+				Success    bool
+				ReturnData []byte
+			}
+			results := []result{}
+			switch method.Name {
+			case "tryAggregate":
+				for _, call := range args[1].([]struct {
+					Target   common.Address `json:"target"`
+					CallData []byte         `json:"callData"`
+				}) {
+					success, data := m.execute(call.Target, call.CallData)
+					results = append(results, result{Success: success, ReturnData: data})
+				}
+			case "aggregate3":
+				for _, call := range args[0].([]struct {
+					Target       common.Address `json:"target"`
+					AllowFailure bool           `json:"allowFailure"`
+					CallData     []byte         `json:"callData"`
+				}) {
+					success, data := m.execute(call.Target, call.CallData)
+					if !success && !call.AllowFailure {
+						reason, _ := abi.NewType("string", "", nil)
+						packed, _ := abi.Arguments{{Type: reason}}.Pack("Multicall3: call failed")
+						return nil, revertDataError{data: hexutil.Encode(append([]byte{0x08, 0xc3, 0x79, 0xa0}, packed...))}
+					}
+					results = append(results, result{Success: success, ReturnData: data})
+				}
+			default:
+				return nil, stub.ErrNotImplemented
+			}
+			if m.maxCalls > 0 && len(results) > m.maxCalls {
+				return nil, errors.New("out of gas")
+			}
+			return method.Outputs.Pack(results)
+		},
+	}
+}
+
+func TestMulticall3(t *testing.T) {
+
+	// Batch a required getter, an optional call that fails and the multicall helpers
+	fake := newFakeMulticall(t)
+	mc, err := multicall.NewMultiCaller3(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value, broken, block, balance *big.Int
+	mc.AddCall(fake.target(), &value, "getValue")
+	mc.AddOptionalCall(fake.target(), &broken, "getBroken")
+	mc.AddBlockNumberCall(&block)
+	mc.AddEthBalanceCall(targetAddress, &balance)
+	results, err := mc.FlexibleCall(true, &bind.CallOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.batches != 1 {
+		t.Errorf("Incorrect batch count %d", fake.batches)
+	}
+	if value.Cmp(targetValue) != 0 || block.Cmp(blockNumber) != 0 || balance.Cmp(ethBalance) != 0 {
+		t.Errorf("Incorrect outputs %s, %s, %s", value, block, balance)
+	}
+
+	// The optional call's failure is reported with its decoded reason
+	if results[1].Success || broken != nil {
+		t.Fatalf("Optional call did not fail")
+	}
+	var revertErr *rocketpool.RevertError
+	if !errors.As(results[1].Error, &revertErr) || revertErr.ErrorName != "NotReady" || revertErr.Reason != "NotReady(1000)" {
+		t.Errorf("Incorrect optional call error %v", results[1].Error)
+	}
+
+}
+
+func TestMulticall3RequiredFailure(t *testing.T) {
+
+	// A required call fails
+	fake := newFakeMulticall(t)
+	mc, err := multicall.NewMultiCaller3(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value, broken *big.Int
+	mc.AddCall(fake.target(), &value, "getValue")
+	mc.AddCall(fake.target(), &broken, "getBroken")
+	results, err := mc.FlexibleCall(true, nil)
+
+	// The batch is rerun to find the failed call, which is reported with the site it was added from
+	var batchErr *multicall.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 {
+		t.Fatalf("Expected batch error, got %v", err)
+	}
+	callErr := batchErr.Errors[0]
+	if callErr.Index != 1 || callErr.Method != "getBroken" || callErr.ContractName != "poolseaNetworkPrices" || !strings.HasPrefix(callErr.Site, "multicaller_test.go:") {
+		t.Errorf("Incorrect call error %+v", callErr)
+	}
+	var revertErr *rocketpool.RevertError
+	if !errors.As(err, &revertErr) || revertErr.Reason != "NotReady(1000)" {
+		t.Errorf("Incorrect revert error %v", err)
+	}
+	if fake.batches != 2 || len(results) != 2 || !results[0].Success || value.Cmp(targetValue) != 0 {
+		t.Errorf("Incorrect results after %d batches: %+v", fake.batches, results)
+	}
+
+}
+
+func TestMulticall2(t *testing.T) {
+
+	// Batches that don't require success report failures without failing
+	fake := newFakeMulticall(t)
+	mc, err := multicall.NewMultiCaller(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value, broken *big.Int
+	mc.AddCall(fake.target(), &value, "getValue")
+	mc.AddCall(fake.target(), &broken, "getBroken")
+	results, err := mc.FlexibleCall(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Success || value.Cmp(targetValue) != 0 || results[1].Success || results[1].Error == nil {
+		t.Errorf("Incorrect results %+v", results)
+	}
+
+	// Required calls fail the batch
+	mc.AddCall(fake.target(), &broken, "getBroken")
+	if _, err := mc.FlexibleCall(true, nil); err == nil {
+		t.Error("Expected error for failed required call")
+	}
+
+}
+
+func TestMulticallBatches(t *testing.T) {
+
+	// Add more calls than fit in a batch, to a node that can't execute batches of more than 30 calls
+	fake := newFakeMulticall(t)
+	fake.maxCalls = 30
+	mc, err := multicall.NewMultiCaller3(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mc.BatchSettings = multicall.BatchSettings{MaxCalls: 40, ThreadLimit: 2}
+	values := make([]*big.Int, 200)
+	for i := range values {
+		mc.AddCall(fake.target(), &values[i], "getValue")
+	}
+
+	// Oversized batches are split and the results are kept in order
+	results, err := mc.FlexibleCall(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(values) {
+		t.Fatalf("Incorrect result count %d", len(results))
+	}
+	for i, value := range values {
+		if value == nil || value.Cmp(targetValue) != 0 {
+			t.Fatalf("Incorrect value %s for call %d", value, i)
+		}
+	}
+
+	// Later batches use the smaller size
+	batches := fake.batches
+	other := mc.NewCaller()
+	for i := range values {
+		other.AddCall(fake.target(), &values[i], "getValue")
+	}
+	if _, err := other.FlexibleCall(true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fake.batches-batches != 10 {
+		t.Errorf("Incorrect batch count %d after learning the limit", fake.batches-batches)
+	}
+
+	// Failures are reported with their index in the whole set of calls
+	var broken *big.Int
+	for i := range values {
+		mc.AddCall(fake.target(), &values[i], "getValue")
+	}
+	mc.AddCall(fake.target(), &broken, "getBroken")
+	var batchErr *multicall.BatchError
+	if _, err := mc.FlexibleCall(true, nil); !errors.As(err, &batchErr) || batchErr.Errors[0].Index != len(values) {
+		t.Errorf("Incorrect batch error %v", err)
+	}
+
+}
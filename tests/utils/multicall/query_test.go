@@ -0,0 +1,95 @@
+package multicall
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
+)
+
+func TestTypedQueries(t *testing.T) {
+
+	// Add typed calls
+	fake := newFakeMulticall(t)
+	mc, err := multicall.NewMultiCaller3(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := multicall.Add[*big.Int](mc, fake.target(), "getValue")
+	broken := multicall.AddOptional[*big.Int](mc, fake.target(), "getBroken")
+	if _, err := value.Get(); !errors.Is(err, multicall.ErrQueryNotExecuted) {
+		t.Errorf("Expected not executed error, got %v", err)
+	}
+
+	// Their values are available after execution
+	if _, err := mc.FlexibleCall(true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := value.Get(); err != nil || result.Cmp(targetValue) != 0 {
+		t.Errorf("Incorrect value %s (%v)", result, err)
+	}
+	var revertErr *rocketpool.RevertError
+	if broken.Succeeded() || broken.Value() != nil || !errors.As(broken.Err(), &revertErr) {
+		t.Errorf("Incorrect optional query error %v", broken.Err())
+	}
+	if values := multicall.Values([]*multicall.Query[*big.Int]{value, broken}); values[0].Cmp(targetValue) != 0 || values[1] != nil {
+		t.Errorf("Incorrect values %v", values)
+	}
+
+}
+
+func TestTypedQueryErrors(t *testing.T) {
+
+	// Outputs of the wrong type and bad arguments are reported when the calls are added
+	fake := newFakeMulticall(t)
+	mc, err := multicall.NewMultiCaller3(fake.client(), multicallAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongType := multicall.Add[bool](mc, fake.target(), "getValue")
+	if err := wrongType.Err(); err == nil || !strings.Contains(err.Error(), "query_test.go:") {
+		t.Errorf("Expected output type error with the call site, got %v", err)
+	}
+	if err := mc.Err(); err == nil {
+		t.Error("Expected multicaller error")
+	}
+	if err := mc.AddCall(fake.target(), new(*big.Int), "getValue", big.NewInt(1)); err == nil {
+		t.Error("Expected argument error")
+	}
+
+	// Executing fails without making any calls
+	value := multicall.Add[*big.Int](mc, fake.target(), "getValue")
+	if _, err := mc.FlexibleCall(true, nil); err == nil || fake.batches != 0 {
+		t.Errorf("Expected error before executing, got %v after %d batches", err, fake.batches)
+	}
+	if value.Succeeded() {
+		t.Error("Query succeeded after a failed execution")
+	}
+
+	// The error is cleared for the next set of calls
+	value = multicall.Add[*big.Int](mc, fake.target(), "getValue")
+	if _, err := mc.FlexibleCall(true, nil); err != nil || !value.Succeeded() {
+		t.Errorf("Incorrect query after reset: %v", err)
+	}
+
+	// Executing the raw calls clears the error too
+	if err := mc.AddCall(fake.target(), new(*big.Int), "getValue", big.NewInt(1)); err == nil {
+		t.Error("Expected argument error")
+	}
+	if _, err := mc.Execute(true, nil); err == nil {
+		t.Error("Expected error before executing")
+	}
+	if err := mc.Err(); err != nil {
+		t.Errorf("Error wasn't cleared after executing: %v", err)
+	}
+	if err := mc.AddCall(fake.target(), new(*big.Int), "getValue"); err != nil {
+		t.Fatal(err)
+	}
+	if responses, err := mc.Execute(true, nil); err != nil || len(responses) != 1 {
+		t.Errorf("Incorrect responses after reset: %d, %v", len(responses), err)
+	}
+
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+func TestBondReductionPlans(t *testing.T) {
+
+	// A node with room to borrow 8 more ETH and two minipools reducing their bond from 16 to 8 ETH
+	beginTime := time.Unix(1_700_000_000, 0)
+	node := newNode(testNodeA, 100, big.NewInt(0))
+	node.EthMatched = eth.EthToWei(48)
+	node.EthMatchedLimit = eth.EthToWei(56)
+	node.DepositCreditBalance = eth.EthToWei(1)
+	minipools := []state.NativeMinipoolDetails{
+		newReducingMinipool(testMp1, testNodeA, 1, beginTime),
+		newReducingMinipool(testMp2, testNodeA, 2, beginTime.Add(time.Hour)),
+		newMinipool(testMp3, testNodeA, 3, 0.15),
+	}
+	networkFee, _ := big.NewInt(0).SetString("140000000000000001", 10)
+	networkDetails := &state.NetworkDetails{
+		NodeFee:                   eth.WeiToEth(networkFee),
+		NodeFeeRaw:                networkFee,
+		BondReductionEnabled:      true,
+		BondReductionWindowStart:  12 * time.Hour,
+		BondReductionWindowLength: 2 * 24 * time.Hour,
+	}
+	networkState := newTestNetworkState(t, 100, networkDetails, []state.NativeNodeDetails{node}, minipools)
+
+	// Before the windows open, the first reduction is waiting and the second doesn't have enough collateral
+	plans := state.GetBondReductionPlans(networkState, beginTime.Add(time.Hour))
+	if len(plans) != 2 || plans[0].MinipoolAddress != testMp1 || plans[1].MinipoolAddress != testMp2 {
+		t.Fatalf("Incorrect plans %+v", plans)
+	}
+	first := plans[0]
+	if first.Status != state.BondReductionStatusWaiting || !first.WindowStart.Equal(beginTime.Add(12*time.Hour)) || !first.WindowEnd.Equal(beginTime.Add(60*time.Hour)) {
+		t.Errorf("Incorrect first plan timing %s, %s, %s", first.Status, first.WindowStart, first.WindowEnd)
+	}
+	if first.CreditIncrease.Cmp(eth.EthToWei(8)) != 0 || first.NewDepositCredit.Cmp(eth.EthToWei(9)) != 0 || first.NewEthMatched.Cmp(eth.EthToWei(56)) != 0 {
+		t.Errorf("Incorrect first plan projections %s, %s, %s", first.CreditIncrease, first.NewDepositCredit, first.NewEthMatched)
+	}
+	if first.NewNodeFee.Cmp(networkFee) != 0 || first.CurrentNodeFee.Cmp(eth.EthToWei(0.15)) != 0 {
+		t.Errorf("Incorrect first plan fees %s, %s", first.CurrentNodeFee, first.NewNodeFee)
+	}
+	if second := plans[1]; second.Status != state.BondReductionStatusWaiting || len(second.UnmetPrerequisites) != 1 || second.NewEthMatched.Cmp(eth.EthToWei(64)) != 0 {
+		t.Errorf("Incorrect second plan %s, %v, %s", second.Status, second.UnmetPrerequisites, second.NewEthMatched)
+	}
+
+	// Once the windows open, only the first reduction is ready
+	plans = state.GetBondReductionPlans(networkState, beginTime.Add(24*time.Hour))
+	ready := state.GetReadyBondReductions(plans)
+	if len(ready) != 1 || ready[0].MinipoolAddress != testMp1 || plans[1].Status != state.BondReductionStatusBlocked {
+		t.Errorf("Incorrect ready plans %+v", ready)
+	}
+
+	// After the windows close, both have expired
+	plans = state.GetBondReductionPlans(networkState, beginTime.Add(72*time.Hour))
+	if plans[0].Status != state.BondReductionStatusExpired || plans[1].Status != state.BondReductionStatusExpired {
+		t.Errorf("Incorrect statuses after the window %s, %s", plans[0].Status, plans[1].Status)
+	}
+
+	// Disabling bond reductions blocks every reduction
+	networkDetails.BondReductionEnabled = false
+	plans = state.GetBondReductionPlans(networkState, beginTime.Add(24*time.Hour))
+	if len(state.GetReadyBondReductions(plans)) != 0 || plans[0].Status != state.BondReductionStatusBlocked {
+		t.Errorf("Bond reduction wasn't blocked while disabled: %+v", plans[0])
+	}
+
+}
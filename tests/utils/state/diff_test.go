@@ -0,0 +1,71 @@
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+func TestDiffNetworkStates(t *testing.T) {
+
+	// Take a snapshot, then change the network details, a node's stake and a minipool's status and add a node and minipool
+	oldState := newTestNetworkState(t, 100,
+		&state.NetworkDetails{QueueLength: big.NewInt(5), RETHExchangeRate: 1.05},
+		[]state.NativeNodeDetails{newNode(testNodeA, 100, big.NewInt(0))},
+		[]state.NativeMinipoolDetails{newMinipool(testMp1, testNodeA, 1, 0.1)})
+	minipool1 := newMinipool(testMp1, testNodeA, 1, 0.1)
+	minipool1.Status = types.Withdrawable
+	newState := newTestNetworkState(t, 110,
+		&state.NetworkDetails{QueueLength: big.NewInt(4), RETHExchangeRate: 1.06},
+		[]state.NativeNodeDetails{newNode(testNodeA, 120, big.NewInt(0)), newNode(testNodeB, 0, big.NewInt(0))},
+		[]state.NativeMinipoolDetails{minipool1, newMinipool(testMp2, testNodeB, 2, 0.14)})
+	diff := state.DiffNetworkStates(oldState, newState)
+
+	// Network changes
+	if diff.FromBlock != 100 || diff.ToBlock != 110 || len(diff.NetworkChanges) != 2 {
+		t.Fatalf("Incorrect network changes %+v", diff.NetworkChanges)
+	}
+	if change := diff.NetworkChanges[0]; change.Field != "QueueLength" || change.Old.(*big.Int).Int64() != 5 || change.New.(*big.Int).Int64() != 4 {
+		t.Errorf("Incorrect queue length change %+v", change)
+	}
+	if change := diff.NetworkChanges[1]; change.Field != "RETHExchangeRate" || change.Old != 1.05 || change.New != 1.06 {
+		t.Errorf("Incorrect exchange rate change %+v", change)
+	}
+
+	// Added and changed entities
+	if len(diff.AddedNodes) != 1 || diff.AddedNodes[0] != testNodeB || len(diff.RemovedNodes) != 0 {
+		t.Errorf("Incorrect added or removed nodes %v, %v", diff.AddedNodes, diff.RemovedNodes)
+	}
+	// The node's average fee drops to 0 since it no longer has any staking minipools
+	if len(diff.ChangedNodes) != 1 || len(diff.ChangedNodes[0].Changes) != 2 || diff.ChangedNodes[0].Changes[0].Field != "EffectiveRPLStake" || diff.ChangedNodes[0].Changes[1].Field != "AverageNodeFee" ||
+		diff.ChangedNodes[0].Changes[0].New.(*big.Int).Cmp(eth.EthToWei(120)) != 0 {
+		t.Errorf("Incorrect node changes %+v", diff.ChangedNodes)
+	}
+	if len(diff.AddedMinipools) != 1 || diff.AddedMinipools[0] != testMp2 {
+		t.Errorf("Incorrect added minipools %v", diff.AddedMinipools)
+	}
+	if len(diff.ChangedMinipools) != 1 || len(diff.ChangedMinipools[0].Changes) != 1 ||
+		diff.ChangedMinipools[0].Changes[0].Old != types.Staking || diff.ChangedMinipools[0].Changes[0].New != types.Withdrawable {
+		t.Errorf("Incorrect minipool changes %+v", diff.ChangedMinipools)
+	}
+
+	// JSON rendering
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `{"field":"Status","old":"Staking","new":"Withdrawable"}`) {
+		t.Errorf("Incorrect JSON rendering %s", encoded)
+	}
+
+	// Nothing changes between a snapshot and itself
+	if diff := state.DiffNetworkStates(newState, newState); !diff.IsEmpty() {
+		t.Errorf("Unexpected changes %+v", diff)
+	}
+
+}
@@ -0,0 +1,147 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+// Create a network state with every kind of field set
+func newTestState(t *testing.T) *state.NetworkState {
+	node := newNode(testNodeA, 100, eth.EthToWei(1))
+	node.TimezoneLocation = "Europe/Kyiv"
+	node.RplStake = big.NewInt(-1) // Negative values aren't expected on chain but must survive anyway
+	minipool1 := newMinipool(testMp1, testNodeA, 1, 0.1)
+	minipool1.WithdrawalCredentials = common.HexToHash("0x01")
+	minipool1.DepositType = types.Variable
+	networkDetails := &state.NetworkDetails{
+		RplPrice:         eth.EthToWei(0.01),
+		IntervalDuration: 28 * 24 * time.Hour,
+		IntervalStart:    time.Unix(1680000000, 0),
+		QueueCapacity:    minipool.QueueCapacity{Total: big.NewInt(0), Effective: eth.EthToWei(32)},
+		RETHExchangeRate: 1.05,
+		PricesBlock:      1000,
+	}
+	return newTestNetworkState(t, 1234, networkDetails, []state.NativeNodeDetails{node}, []state.NativeMinipoolDetails{minipool1})
+}
+
+// Check that a decoded state matches the original
+func checkDecodedState(t *testing.T, decoded *state.NetworkState) {
+	details := decoded.NetworkDetails
+	if decoded.ElBlockNumber != 1234 || !decoded.Features.Has(state.FeatureAtlas) || decoded.TotalEffectiveRPLStake.Cmp(eth.EthToWei(100)) != 0 {
+		t.Errorf("Incorrect snapshot fields %d, %t, %s", decoded.ElBlockNumber, decoded.Features.Has(state.FeatureAtlas), decoded.TotalEffectiveRPLStake)
+	}
+	if details.RplPrice.Cmp(eth.EthToWei(0.01)) != 0 || details.IntervalDuration != 28*24*time.Hour || !details.IntervalStart.Equal(time.Unix(1680000000, 0)) ||
+		details.QueueCapacity.Total.Sign() != 0 || details.RETHExchangeRate != 1.05 || details.PricesBlock != 1000 {
+		t.Errorf("Incorrect network details %+v", details)
+	}
+
+	// Nil and zero big.Ints are kept apart
+	if details.StakingETHBalance != nil || details.QueueCapacity.Total == nil {
+		t.Error("Incorrect nil and zero values")
+	}
+
+	// Lookups work on the decoded details, and the derived fields are kept
+	node, exists := decoded.GetNode(testNodeA)
+	if !exists || node.TimezoneLocation != "Europe/Kyiv" || node.RplStake.Cmp(big.NewInt(-1)) != 0 || node.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.55)) != 0 {
+		t.Errorf("Incorrect node details %+v", node)
+	}
+	var pubkey types.ValidatorPubkey
+	pubkey[0] = 1
+	mp, exists := decoded.GetMinipoolByPubkey(pubkey)
+	if !exists || mp.DepositType != types.Variable || mp.Status != types.Staking || mp.WithdrawalCredentials != common.HexToHash("0x01") {
+		t.Errorf("Incorrect minipool details %+v", mp)
+	}
+}
+
+func TestNetworkStateJson(t *testing.T) {
+
+	// Round trip the state through JSON
+	networkState := newTestState(t)
+	encoded, err := networkState.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := state.LoadNetworkState(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDecodedState(t, decoded)
+
+	// Encoding is deterministic
+	reencoded, err := decoded.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, reencoded) {
+		t.Errorf("Re-encoded state differs:\n%s\n%s", encoded, reencoded)
+	}
+
+	// Other versions are rejected
+	if _, err := state.LoadNetworkState([]byte(fmt.Sprintf(`{"version":%d}`, state.NetworkStateEncodingVersion+1))); err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+
+}
+
+func TestNetworkStateBinary(t *testing.T) {
+
+	// Round trip the state through the binary encoding
+	networkState := newTestState(t)
+	encoded, err := networkState.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := state.LoadNetworkState(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDecodedState(t, decoded)
+
+	// Encoding is deterministic and more compact than JSON
+	reencoded, err := decoded.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, reencoded) {
+		t.Error("Re-encoded state differs")
+	}
+	jsonEncoded, err := networkState.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded)*2 > len(jsonEncoded) {
+		t.Errorf("Binary encoding is %d bytes, JSON is %d", len(encoded), len(jsonEncoded))
+	}
+
+	// Truncated data and data for other types are rejected
+	if _, err := state.LoadNetworkState(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+	nodeEncoded, err := networkState.NodeDetails[0].MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.LoadNetworkState(nodeEncoded); err == nil {
+		t.Error("Expected error for node details")
+	}
+
+	// The details can also be encoded on their own
+	var node state.NativeNodeDetails
+	if err := node.UnmarshalBinary(nodeEncoded); err != nil {
+		t.Fatal(err)
+	}
+	if node.NodeAddress != networkState.NodeDetails[0].NodeAddress || node.EffectiveRPLStake.Cmp(eth.EthToWei(100)) != 0 {
+		t.Errorf("Incorrect node details %+v", node)
+	}
+
+}
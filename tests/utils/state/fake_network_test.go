@@ -0,0 +1,583 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/contracts"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	rptypes "github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// The addresses of the fake network's utility contracts
+var (
+	fakeStorageAddress        = common.HexToAddress("0x1000000000000000000000000000000000000000")
+	fakeMulticallAddress      = common.HexToAddress("0x2000000000000000000000000000000000000000")
+	fakeBalanceBatcherAddress = common.HexToAddress("0x3000000000000000000000000000000000000000")
+)
+
+// The methods of the fake network contracts that the state loaders call, as name(inputs)(outputs) signatures
+var fakeContractMethods = map[string][]string{
+	"poolseaDAONodeTrustedSettingsMinipool": {"getScrubPeriod()(uint256)", "getPromotionScrubPeriod()(uint256)", "getBondReductionWindowStart()(uint256)", "getBondReductionWindowLength()(uint256)"},
+	"poolseaDAOProtocolSettingsMinipool":    {"getLaunchTimeout()(uint256)", "getBondReductionEnabled()(bool)"},
+	"poolseaDAOProtocolSettingsNetwork":     {"getSubmitBalancesEnabled()(bool)", "getSubmitPricesEnabled()(bool)"},
+	"poolseaDAOProtocolSettingsNode":        {"getMinimumPerMinipoolStake()(uint256)", "getMaximumPerMinipoolStake()(uint256)"},
+	"poolseaDepositPool":                    {"getBalance()(uint256)", "getExcessBalance()(uint256)", "getUserBalance()(int256)"},
+	"poolseaMinipoolManager": {"getMinipoolCount()(uint256)", "getMinipoolAt(uint256)(address)", "getNodeMinipoolCount(address)(uint256)", "getNodeMinipoolAt(address,uint256)(address)",
+		"getMinipoolExists(address)(bool)", "getMinipoolPubkey(address)(bytes)", "getMinipoolWithdrawalCredentials(address)(bytes)", "getMinipoolRPLSlashed(address)(bool)", "getMinipoolDepositType(address)(uint8)"},
+	"poolseaMinipoolQueue":          {"getTotalCapacity()(uint256)", "getEffectiveCapacity()(uint256)", "getTotalLength()(uint256)"},
+	"poolseaNetworkBalances":        {"getETHUtilizationRate()(uint256)", "getStakingETHBalance()(uint256)", "getTotalETHBalance()(uint256)", "getBalancesBlock()(uint256)", "getLatestReportableBlock()(uint256)"},
+	"poolseaNetworkFees":            {"getNodeFee()(uint256)"},
+	"poolseaNetworkPrices":          {"getRPLPrice()(uint256)", "getPricesBlock()(uint256)", "getLatestReportableBlock()(uint256)"},
+	"poolseaNodeDeposit":            {"getNodeDepositCredit(address)(uint256)"},
+	"poolseaNodeDistributorFactory": {"getProxyAddress(address)(address)"},
+	"poolseaNodeManager": {"version()(uint8)", "getNodeCount()(uint256)", "getNodeAt(uint256)(address)", "getNodeExists(address)(bool)", "getNodeRegistrationTime(address)(uint256)",
+		"getNodeTimezoneLocation(address)(string)", "getFeeDistributorInitialised(address)(bool)", "getRewardNetwork(address)(uint256)",
+		"getSmoothingPoolRegistrationState(address)(bool)", "getSmoothingPoolRegistrationChanged(address)(uint256)"},
+	"poolseaNodeStaking": {"version()(uint8)", "getTotalRPLStake()(uint256)", "getNodeRPLStake(address)(uint256)", "getNodeEffectiveRPLStake(address)(uint256)", "getNodeMinimumRPLStake(address)(uint256)",
+		"getNodeMaximumRPLStake(address)(uint256)", "getNodeETHMatched(address)(uint256)", "getNodeETHMatchedLimit(address)(uint256)", "getNodeETHCollateralisationRatio(address)(uint256)"},
+	"poolseaRewardsPool":           {"getRewardIndex()(uint256)", "getClaimIntervalTimeStart()(uint256)", "getClaimIntervalTime()(uint256)", "getClaimingContractPerc(string)(uint256)", "getPendingRPLRewards()(uint256)"},
+	"poolseaSmoothingPool":         {},
+	"poolseaTokenRETH":             {"getExchangeRate()(uint256)", "totalSupply()(uint256)"},
+	"poolseaTokenRPL":              {"getInflationIntervalRate()(uint256)", "totalSupply()(uint256)"},
+	"poolseaTokenRPLFixedSupply":   {},
+	"poolseaMinipoolBondReducer":   {"getReduceBondTime(address)(uint256)", "getReduceBondCancelled(address)(bool)", "getLastBondReductionTime(address)(uint256)", "getLastBondReductionPrevValue(address)(uint256)", "getLastBondReductionPrevNodeFee(address)(uint256)", "getReduceBondValue(address)(uint256)"},
+	rocketpool.UpgradeContractName: {},
+}
+
+// The events of the fake network contracts, as ABI entries
+var fakeContractEvents = map[string]string{
+	"poolseaMinipoolManager": `{"anonymous":false,"inputs":[{"indexed":true,"name":"minipool","type":"address"},{"indexed":true,"name":"node","type":"address"},{"indexed":false,"name":"time","type":"uint256"}],"name":"MinipoolDestroyed","type":"event"}`,
+	rocketpool.UpgradeContractName: `{"anonymous":false,"inputs":[{"indexed":true,"name":"name","type":"bytes32"},{"indexed":true,"name":"oldAddress","type":"address"},{"indexed":true,"name":"newAddress","type":"address"},{"indexed":false,"name":"time","type":"uint256"}],"name":"ContractUpgraded","type":"event"},` +
+		`{"anonymous":false,"inputs":[{"indexed":true,"name":"name","type":"bytes32"},{"indexed":true,"name":"newAddress","type":"address"},{"indexed":false,"name":"time","type":"uint256"}],"name":"ContractAdded","type":"event"}`,
+}
+
+// The methods of the fake minipools
+var fakeMinipoolMethods = []string{
+	"version()(uint8)", "getStatus()(uint8)", "getStatusBlock()(uint256)", "getStatusTime()(uint256)", "getFinalised()(bool)", "getNodeFee()(uint256)",
+	"getNodeDepositBalance()(uint256)", "getNodeDepositAssigned()(bool)", "getUserDepositBalance()(uint256)", "getUserDepositAssigned()(bool)", "getUserDepositAssignedTime()(uint256)",
+	"getUseLatestDelegate()(bool)", "getDelegate()(address)", "getPreviousDelegate()(address)", "getEffectiveDelegate()(address)", "getNodeAddress()(address)", "getNodeRefundBalance()(uint256)",
+	"getUserDistributed()(bool)", "getVacant()(bool)", "getPreMigrationBalance()(uint256)", "calculateNodeShare(uint256)(uint256)", "calculateUserShare(uint256)(uint256)", "getDepositType()(uint8)",
+}
+
+// The topic of the event minipools emit when their status changes
+var minipoolStatusUpdatedTopic = crypto.Keccak256Hash([]byte("StatusUpdated(uint8,uint256)"))
+
+// A minipool on the fake network
+type fakeMinipool struct {
+	address            common.Address
+	node               common.Address
+	status             rptypes.MinipoolStatus
+	version            uint8
+	depositType        rptypes.MinipoolDeposit
+	nodeFee            *big.Int
+	nodeDepositBalance *big.Int
+	userDepositBalance *big.Int
+}
+
+// The state of the fake network from a block on
+type fakeSnapshot struct {
+	nodes     []common.Address
+	minipools []fakeMinipool
+	rplPrice  *big.Int
+	balances  map[common.Address]*big.Int // ETH balances
+}
+
+// Copy the snapshot so it can be changed for a later block
+func (s *fakeSnapshot) copy() *fakeSnapshot {
+	balances := make(map[common.Address]*big.Int, len(s.balances))
+	for address, balance := range s.balances {
+		balances[address] = balance
+	}
+	return &fakeSnapshot{
+		nodes:     append([]common.Address{}, s.nodes...),
+		minipools: append([]fakeMinipool{}, s.minipools...),
+		rplPrice:  s.rplPrice,
+		balances:  balances,
+	}
+}
+
+// Get a minipool
+func (s *fakeSnapshot) getMinipool(address common.Address) (*fakeMinipool, bool) {
+	for i := range s.minipools {
+		if s.minipools[i].address == address {
+			return &s.minipools[i], true
+		}
+	}
+	return nil, false
+}
+
+// Get a node's minipools
+func (s *fakeSnapshot) getNodeMinipools(node common.Address) []common.Address {
+	addresses := []common.Address{}
+	for _, mp := range s.minipools {
+		if mp.node == node {
+			addresses = append(addresses, mp.address)
+		}
+	}
+	return addresses
+}
+
+// Add a node to the network
+func (s *fakeSnapshot) addNode(node common.Address) {
+	s.nodes = append(s.nodes, node)
+}
+
+// Add an Atlas minipool with a 16 ETH bond to the network
+func (s *fakeSnapshot) addMinipool(address common.Address, node common.Address, status rptypes.MinipoolStatus) *fakeMinipool {
+	s.minipools = append(s.minipools, fakeMinipool{
+		address:            address,
+		node:               node,
+		status:             status,
+		version:            3,
+		depositType:        rptypes.Variable,
+		nodeFee:            eth.EthToWei(0.14),
+		nodeDepositBalance: eth.EthToWei(16),
+		userDepositBalance: eth.EthToWei(16),
+	})
+	return &s.minipools[len(s.minipools)-1]
+}
+
+// A Rocket Pool network served by a stub client, with its state kept per block so snapshots at different blocks can be loaded.
+// Nodes and minipools are derived from their addresses and the snapshot, and every other getter returns a zero value.
+type fakeNetwork struct {
+	storageAbi   abi.ABI
+	multicallAbi abi.ABI
+	balancesAbi  abi.ABI
+	minipoolAbi  abi.ABI
+	addresses    map[common.Hash]common.Address
+	strings      map[common.Hash]string
+	contracts    map[common.Address]abi.ABI
+	names        map[common.Address]string
+	snapshots    map[uint64]*fakeSnapshot // Keyed by the block each one starts at
+	forks        map[uint64]byte          // Changes the hash of a block when it's reorged out
+	logs         []types.Log
+	lock         sync.Mutex
+}
+
+// Create a fake network with the given state from the given block on
+func newFakeNetwork(t *testing.T, block uint64, snapshot *fakeSnapshot) *fakeNetwork {
+	t.Helper()
+	n := &fakeNetwork{
+		storageAbi:   parseAbi(t, contracts.RocketStorageABI),
+		multicallAbi: parseAbi(t, multicall.MulticallABI),
+		balancesAbi:  parseAbi(t, multicall.BalancesABI),
+		minipoolAbi:  parseAbi(t, fakeAbiString(fakeMinipoolMethods, "")),
+		addresses:    map[common.Hash]common.Address{},
+		strings:      map[common.Hash]string{},
+		contracts:    map[common.Address]abi.ABI{},
+		names:        map[common.Address]string{},
+		snapshots:    map[uint64]*fakeSnapshot{block: snapshot},
+		forks:        map[uint64]byte{},
+	}
+	for contractName, methods := range fakeContractMethods {
+		abiString := fakeAbiString(methods, fakeContractEvents[contractName])
+		encodedAbi, err := rocketpool.EncodeAbiStr(abiString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		address := fakeContractAddress(contractName)
+		n.addresses[crypto.Keccak256Hash([]byte("contract.address"), []byte(contractName))] = address
+		n.strings[crypto.Keccak256Hash([]byte("contract.abi"), []byte(contractName))] = encodedAbi
+		n.contracts[address] = parseAbi(t, abiString)
+		n.names[address] = contractName
+	}
+	return n
+}
+
+// Parse an ABI
+func parseAbi(t *testing.T, abiString string) abi.ABI {
+	t.Helper()
+	parsed, err := abi.JSON(strings.NewReader(abiString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// Build an ABI from method signatures and event entries
+func fakeAbiString(methods []string, events string) string {
+	type argument struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	type function struct {
+		Type            string     `json:"type"`
+		Name            string     `json:"name"`
+		Inputs          []argument `json:"inputs"`
+		Outputs         []argument `json:"outputs"`
+		StateMutability string     `json:"stateMutability"`
+	}
+	arguments := func(types string) []argument {
+		args := []argument{}
+		for _, argType := range strings.Split(types, ",") {
+			if argType != "" {
+				args = append(args, argument{Type: argType})
+			}
+		}
+		return args
+	}
+	entries := []string{}
+	for _, signature := range methods {
+		name, rest, _ := strings.Cut(signature, "(")
+		inputs, outputs, _ := strings.Cut(rest, ")(")
+		bytes, _ := json.Marshal(function{
+			Type:            "function",
+			Name:            name,
+			Inputs:          arguments(inputs),
+			Outputs:         arguments(strings.TrimSuffix(outputs, ")")),
+			StateMutability: "view",
+		})
+		entries = append(entries, string(bytes))
+	}
+	if events != "" {
+		entries = append(entries, events)
+	}
+	return "[" + strings.Join(entries, ",") + "]"
+}
+
+// Get the address of a network contract
+func fakeContractAddress(contractName string) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte(contractName))[:common.AddressLength])
+}
+
+// Get the fee distributor address of a node
+func fakeDistributorAddress(node common.Address) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte("distributor"), node.Bytes())[:common.AddressLength])
+}
+
+// Get the minimum RPL stake of a node at an RPL price, which is 2.4 ETH worth of RPL
+func fakeMinimumRPLStake(rplPrice *big.Int) *big.Int {
+	stake := big.NewInt(0).Mul(eth.EthToWei(2.4), eth.EthToWei(1))
+	return stake.Div(stake, rplPrice)
+}
+
+// Change the network from a block on, starting from the state at the block before it
+func (n *fakeNetwork) advance(block uint64, change func(s *fakeSnapshot)) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	snapshot := n.snapshotAt(block).copy()
+	change(snapshot)
+	n.snapshots[block] = snapshot
+}
+
+// Add event logs
+func (n *fakeNetwork) emit(logs ...types.Log) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.logs = append(n.logs, logs...)
+}
+
+// Reorg a block out, changing its hash
+func (n *fakeNetwork) reorg(block uint64) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.forks[block]++
+}
+
+// Get the state of the network at a block
+func (n *fakeNetwork) snapshotAt(block uint64) *fakeSnapshot {
+	starts := []uint64{}
+	for start := range n.snapshots {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] > starts[j] })
+	for _, start := range starts {
+		if start <= block {
+			return n.snapshots[start]
+		}
+	}
+	return n.snapshots[starts[len(starts)-1]]
+}
+
+// Get the latest block the network has a state for
+func (n *fakeNetwork) latestBlock() uint64 {
+	latest := uint64(0)
+	for start := range n.snapshots {
+		if start > latest {
+			latest = start
+		}
+	}
+	return latest
+}
+
+// Create a contract manager for the network
+func (n *fakeNetwork) rocketPool(t *testing.T) *rocketpool.RocketPool {
+	t.Helper()
+	rp, err := rocketpool.NewRocketPool(n.client(), fakeStorageAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// Get a client that serves the network
+func (n *fakeNetwork) client() *stub.Client {
+	return &stub.Client{
+		CallContractFunc: func(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			n.lock.Lock()
+			defer n.lock.Unlock()
+			block := n.latestBlock()
+			if blockNumber != nil {
+				block = blockNumber.Uint64()
+			}
+			if call.To == nil {
+				return nil, stub.ErrNotImplemented
+			}
+			success, output, err := n.execute(n.snapshotAt(block), *call.To, call.Data)
+			if err != nil {
+				return nil, err
+			}
+			if !success {
+				return nil, fmt.Errorf("execution reverted")
+			}
+			return output, nil
+		},
+		HeaderByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			n.lock.Lock()
+			defer n.lock.Unlock()
+			block := n.latestBlock()
+			if number != nil {
+				block = number.Uint64()
+			}
+			return &types.Header{
+				Number:     big.NewInt(0).SetUint64(block),
+				Difficulty: big.NewInt(0),
+				Extra:      []byte{n.forks[block]},
+			}, nil
+		},
+		BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+			n.lock.Lock()
+			defer n.lock.Unlock()
+			return n.latestBlock(), nil
+		},
+		FilterLogsFunc: func(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+			n.lock.Lock()
+			defer n.lock.Unlock()
+			logs := []types.Log{}
+			for _, log := range n.logs {
+				if (query.FromBlock != nil && log.BlockNumber < query.FromBlock.Uint64()) || (query.ToBlock != nil && log.BlockNumber > query.ToBlock.Uint64()) {
+					continue
+				}
+				for _, address := range query.Addresses {
+					if address == log.Address {
+						logs = append(logs, log)
+						break
+					}
+				}
+			}
+			return logs, nil
+		},
+	}
+}
+
+// Execute a call against the network, returning whether it succeeded and its output
+func (n *fakeNetwork) execute(s *fakeSnapshot, to common.Address, data []byte) (bool, []byte, error) {
+	if len(data) < 4 {
+		return false, nil, nil
+	}
+
+	// Get the contract's ABI
+	var contractAbi abi.ABI
+	mp, isMinipool := s.getMinipool(to)
+	switch {
+	case to == fakeStorageAddress:
+		contractAbi = n.storageAbi
+	case to == fakeMulticallAddress:
+		contractAbi = n.multicallAbi
+	case to == fakeBalanceBatcherAddress:
+		contractAbi = n.balancesAbi
+	case isMinipool:
+		contractAbi = n.minipoolAbi
+	default:
+		var exists bool
+		contractAbi, exists = n.contracts[to]
+		if !exists {
+			return false, nil, nil
+		}
+	}
+	method, err := contractAbi.MethodById(data[:4])
+	if err != nil {
+		return false, nil, nil
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Get the outputs
+	var outputs []interface{}
+	switch {
+	case to == fakeMulticallAddress:
+		if method.Name != "tryAggregate" {
+			return false, nil, stub.ErrNotImplemented
+		}
+		type result struct {
+			Success    bool
+			ReturnData []byte
+		}
+		results := []result{}
+		for _, call := range args[1].([]struct {
+			Target   common.Address `json:"target"`
+			CallData []byte         `json:"callData"`
+		}) {
+			success, output, err := n.execute(s, call.Target, call.CallData)
+			if err != nil {
+				return false, nil, err
+			}
+			results = append(results, result{Success: success, ReturnData: output})
+		}
+		outputs = []interface{}{results}
+	case to == fakeBalanceBatcherAddress:
+		balances := []*big.Int{}
+		for _, user := range args[0].([]common.Address) {
+			for _, token := range args[1].([]common.Address) {
+				balance := big.NewInt(0)
+				if token == (common.Address{}) && s.balances[user] != nil {
+					balance = s.balances[user]
+				}
+				balances = append(balances, balance)
+			}
+		}
+		outputs = []interface{}{balances}
+	case isMinipool:
+		outputs = n.callMinipool(s, mp, method.Name, args)
+	default:
+		outputs = n.callContract(s, n.names[to], method.Name, args)
+	}
+	if outputs == nil {
+		outputs = zeroOutputs(method)
+	}
+	output, err := method.Outputs.Pack(outputs...)
+	if err != nil {
+		return false, nil, fmt.Errorf("error packing %s outputs: %w", method.Name, err)
+	}
+	return true, output, nil
+}
+
+// Call a network contract, returning nil for the zero value
+func (n *fakeNetwork) callContract(s *fakeSnapshot, contractName string, methodName string, args []interface{}) []interface{} {
+	switch contractName + "." + methodName {
+	case ".getAddress":
+		return []interface{}{n.addresses[args[0].([32]byte)]}
+	case ".getString":
+		return []interface{}{n.strings[args[0].([32]byte)]}
+	case ".getNodeWithdrawalAddress":
+		return []interface{}{args[0]}
+	case "poolseaNodeStaking.version":
+		return []interface{}{uint8(4)}
+	case "poolseaNodeManager.getNodeCount":
+		return []interface{}{big.NewInt(int64(len(s.nodes)))}
+	case "poolseaNodeManager.getNodeAt":
+		return []interface{}{s.nodes[args[0].(*big.Int).Uint64()]}
+	case "poolseaNodeManager.getNodeExists", "poolseaMinipoolManager.getMinipoolExists":
+		return []interface{}{true}
+	case "poolseaNodeManager.getNodeTimezoneLocation":
+		return []interface{}{"Etc/UTC"}
+	case "poolseaNodeDistributorFactory.getProxyAddress":
+		return []interface{}{fakeDistributorAddress(args[0].(common.Address))}
+	case "poolseaNodeStaking.getNodeMinimumRPLStake":
+		return []interface{}{fakeMinimumRPLStake(s.rplPrice)}
+	case "poolseaNodeStaking.getNodeRPLStake", "poolseaNodeStaking.getNodeEffectiveRPLStake":
+		return []interface{}{eth.EthToWei(1000)}
+	case "poolseaNodeStaking.getNodeETHCollateralisationRatio":
+		return []interface{}{eth.EthToWei(2)}
+	case "poolseaNodeStaking.getNodeETHMatched":
+		return []interface{}{big.NewInt(0).Mul(big.NewInt(int64(len(s.getNodeMinipools(args[0].(common.Address))))), eth.EthToWei(16))}
+	case "poolseaNetworkPrices.getRPLPrice":
+		return []interface{}{s.rplPrice}
+	case "poolseaMinipoolManager.getMinipoolCount":
+		return []interface{}{big.NewInt(int64(len(s.minipools)))}
+	case "poolseaMinipoolManager.getMinipoolAt":
+		return []interface{}{s.minipools[args[0].(*big.Int).Uint64()].address}
+	case "poolseaMinipoolManager.getNodeMinipoolCount":
+		return []interface{}{big.NewInt(int64(len(s.getNodeMinipools(args[0].(common.Address)))))}
+	case "poolseaMinipoolManager.getNodeMinipoolAt":
+		return []interface{}{s.getNodeMinipools(args[0].(common.Address))[args[1].(*big.Int).Uint64()]}
+	case "poolseaMinipoolManager.getMinipoolPubkey":
+		hash := crypto.Keccak256(args[0].(common.Address).Bytes())
+		return []interface{}{append(hash, hash[:16]...)}
+	case "poolseaMinipoolManager.getMinipoolWithdrawalCredentials":
+		return []interface{}{common.BytesToHash(args[0].(common.Address).Bytes()).Bytes()}
+	case "poolseaMinipoolManager.getMinipoolDepositType":
+		if mp, exists := s.getMinipool(args[0].(common.Address)); exists {
+			return []interface{}{uint8(mp.depositType)}
+		}
+	case "poolseaNetworkFees.getNodeFee":
+		return []interface{}{eth.EthToWei(0.14)}
+	}
+	return nil
+}
+
+// Call a minipool, returning nil for the zero value
+func (n *fakeNetwork) callMinipool(s *fakeSnapshot, mp *fakeMinipool, methodName string, args []interface{}) []interface{} {
+	switch methodName {
+	case "version":
+		return []interface{}{mp.version}
+	case "getStatus":
+		return []interface{}{uint8(mp.status)}
+	case "getNodeAddress":
+		return []interface{}{mp.node}
+	case "getNodeFee":
+		return []interface{}{mp.nodeFee}
+	case "getNodeDepositBalance":
+		return []interface{}{mp.nodeDepositBalance}
+	case "getUserDepositBalance":
+		return []interface{}{mp.userDepositBalance}
+	case "getDepositType":
+		return []interface{}{uint8(mp.depositType)}
+	}
+	return nil
+}
+
+// Get the zero values of a method's outputs
+func zeroOutputs(method *abi.Method) []interface{} {
+	outputs := []interface{}{}
+	for _, output := range method.Outputs {
+		switch output.Type.T {
+		case abi.UintTy, abi.IntTy:
+			if output.Type.Size > 64 {
+				outputs = append(outputs, big.NewInt(0))
+				continue
+			}
+		case abi.BytesTy:
+			outputs = append(outputs, []byte{})
+			continue
+		}
+		outputs = append(outputs, reflect.Zero(output.Type.GetType()).Interface())
+	}
+	return outputs
+}
+
+// Create a contracts container and load the state of the network at a block
+func (n *fakeNetwork) loadState(t *testing.T, rp *rocketpool.RocketPool, block uint64) *state.NetworkState {
+	t.Helper()
+	contracts, err := state.NewNetworkContracts(rp, fakeMulticallAddress, fakeBalanceBatcherAddress, &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(block)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkState, err := state.NewNetworkState(rp, contracts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return networkState
+}
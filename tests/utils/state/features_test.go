@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+func TestFeatureSets(t *testing.T) {
+
+	// Atlas is only enabled from v1.2.0
+	legacyFeatures := state.GetFeatureSet(version.Must(version.NewVersion("1.1.0")))
+	if legacyFeatures.Has(state.FeatureAtlas) {
+		t.Errorf("Atlas enabled on v1.1.0")
+	}
+	atlasFeatures := state.GetFeatureSet(version.Must(version.NewVersion("1.2.0")))
+	if !atlasFeatures.Has(state.FeatureAtlas) {
+		t.Errorf("Atlas not enabled on v1.2.0")
+	}
+	if legacyFeatures.Equals(atlasFeatures) {
+		t.Errorf("Feature sets for v1.1.0 and v1.2.0 are equal")
+	}
+
+	// Register a feature for a later upgrade
+	testFeature := state.Feature("test-upgrade")
+	if err := state.RegisterFeature(testFeature, ">= 1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+	if state.GetFeatureSet(version.Must(version.NewVersion("1.2.0"))).Has(testFeature) {
+		t.Errorf("Test feature enabled on v1.2.0")
+	}
+	laterFeatures := state.GetFeatureSet(version.Must(version.NewVersion("1.3.1")))
+	if !laterFeatures.Has(testFeature) || !laterFeatures.Has(state.FeatureAtlas) {
+		t.Errorf("Incorrect features on v1.3.1: %v", laterFeatures)
+	}
+	if !laterFeatures.Equals(state.FeatureSet{testFeature, state.FeatureAtlas}) {
+		t.Errorf("Feature set equality depends on order")
+	}
+
+	// Invalid constraints are rejected
+	if err := state.RegisterFeature(state.Feature("invalid"), "not a version"); err == nil {
+		t.Errorf("Invalid version constraint was accepted")
+	}
+
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+// The addresses of the test nodes and minipools
+var (
+	testNodeA = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testNodeB = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	testMp1   = common.HexToAddress("0x0000000000000000000000000000000000000011")
+	testMp2   = common.HexToAddress("0x0000000000000000000000000000000000000012")
+	testMp3   = common.HexToAddress("0x0000000000000000000000000000000000000013")
+)
+
+// Convert an exact decimal ETH amount to wei
+func ethAmount(t *testing.T, amount string) *big.Int {
+	t.Helper()
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		t.Fatalf("Invalid amount %s", amount)
+	}
+	value.Mul(value, new(big.Rat).SetInt64(1e18))
+	if !value.IsInt() {
+		t.Fatalf("Amount %s has too many decimals", amount)
+	}
+	return value.Num()
+}
+
+// Create the details for a node
+func newNode(address common.Address, effectiveStake int64, distributorBalance *big.Int) state.NativeNodeDetails {
+	return state.NativeNodeDetails{
+		NodeAddress:               address,
+		EffectiveRPLStake:         eth.EthToWei(float64(effectiveStake)),
+		DistributorBalance:        distributorBalance,
+		AverageNodeFee:            big.NewInt(0),
+		CollateralisationRatio:    eth.EthToWei(2),
+		DistributorBalanceUserETH: big.NewInt(0),
+		DistributorBalanceNodeETH: big.NewInt(0),
+	}
+}
+
+// Create the details for a staking minipool
+func newMinipool(address common.Address, node common.Address, pubkey byte, fee float64) state.NativeMinipoolDetails {
+	details := state.NativeMinipoolDetails{
+		MinipoolAddress: address,
+		NodeAddress:     node,
+		Status:          types.Staking,
+		NodeFee:         eth.EthToWei(fee),
+	}
+	details.Pubkey[0] = pubkey
+	return details
+}
+
+// Create the details for a staking Atlas minipool with the given bond and an empty balance
+func newAtlasMinipool(address common.Address, node common.Address, pubkey byte, fee float64, bond int64) state.NativeMinipoolDetails {
+	details := newMinipool(address, node, pubkey, fee)
+	details.Version = 3
+	details.DepositType = types.Variable
+	details.NodeDepositBalance = eth.EthToWei(float64(bond))
+	details.UserDepositBalance = eth.EthToWei(float64(32 - bond))
+	details.Balance = big.NewInt(0)
+	details.NodeRefundBalance = big.NewInt(0)
+	return details
+}
+
+// Create the details for a 16 ETH minipool with a pending reduction to 8 ETH
+func newReducingMinipool(address common.Address, node common.Address, pubkey byte, reduceBondTime time.Time) state.NativeMinipoolDetails {
+	details := newAtlasMinipool(address, node, pubkey, 0.15, 16)
+	details.Balance = eth.EthToWei(0.1)
+	details.ReduceBondTime = big.NewInt(reduceBondTime.Unix())
+	details.ReduceBondValue = eth.EthToWei(8)
+	return details
+}
+
+// Create an Atlas network state from the given details
+func newTestNetworkState(t *testing.T, block uint64, networkDetails *state.NetworkDetails, nodes []state.NativeNodeDetails, minipools []state.NativeMinipoolDetails) *state.NetworkState {
+	t.Helper()
+	networkState, err := state.NewNetworkStateFromDetails(block, state.FeatureSet{state.FeatureAtlas}, networkDetails, nodes, minipools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return networkState
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+// Get the names of the actions in a list
+func actionNames(actions []state.MinipoolActionPlan) []state.MinipoolAction {
+	names := []state.MinipoolAction{}
+	for _, action := range actions {
+		names = append(names, action.Action)
+	}
+	return names
+}
+
+// Check that a plan has exactly the given ready actions
+func checkReadyActions(t *testing.T, name string, plan state.MinipoolLifecyclePlan, expected ...state.MinipoolAction) {
+	t.Helper()
+	ready := actionNames(plan.ReadyActions())
+	if len(ready) != len(expected) {
+		t.Errorf("%s: incorrect ready actions %v, expected %v", name, ready, expected)
+		return
+	}
+	for i := range ready {
+		if ready[i] != expected[i] {
+			t.Errorf("%s: incorrect ready actions %v, expected %v", name, ready, expected)
+			return
+		}
+	}
+}
+
+func TestPlanMinipoolActions(t *testing.T) {
+
+	networkDetails := &state.NetworkDetails{
+		ScrubPeriod:               12 * time.Hour,
+		PromotionScrubPeriod:      3 * 24 * time.Hour,
+		MinipoolLaunchTimeout:     big.NewInt(int64((72 * time.Hour).Seconds())),
+		BondReductionWindowStart:  12 * time.Hour,
+		BondReductionWindowLength: 2 * 24 * time.Hour,
+		BondReductionEnabled:      true,
+	}
+	statusTime := time.Unix(1_700_000_000, 0)
+
+	// A prelaunch minipool can stake after the scrub period and be dissolved after the launch timeout
+	prelaunch := newAtlasMinipool(testMp1, testNodeA, 1, 0.1, 8)
+	prelaunch.Status = types.Prelaunch
+	prelaunch.StatusTime = big.NewInt(statusTime.Unix())
+	plan := state.PlanMinipoolActions(&prelaunch, networkDetails, statusTime.Add(time.Hour))
+	checkReadyActions(t, "prelaunch in scrub period", plan)
+	upcoming := plan.UpcomingActions()
+	if names := actionNames(upcoming); len(names) != 2 || names[0] != state.MinipoolActionStake || names[1] != state.MinipoolActionDissolve {
+		t.Fatalf("Incorrect upcoming actions %v", names)
+	}
+	if !upcoming[0].EarliestTime.Equal(statusTime.Add(12*time.Hour)) || upcoming[0].BlockedReason == "" {
+		t.Errorf("Incorrect stake plan %+v", upcoming[0])
+	}
+	if promote, _ := plan.GetAction(state.MinipoolActionPromote); promote.Ready || !promote.EarliestTime.IsZero() || promote.BlockedReason == "" {
+		t.Errorf("Incorrect promote plan for a non-vacant minipool %+v", promote)
+	}
+	plan = state.PlanMinipoolActions(&prelaunch, networkDetails, statusTime.Add(12*time.Hour))
+	checkReadyActions(t, "prelaunch after scrub period", plan, state.MinipoolActionStake)
+	plan = state.PlanMinipoolActions(&prelaunch, networkDetails, statusTime.Add(72*time.Hour))
+	checkReadyActions(t, "prelaunch after launch timeout", plan, state.MinipoolActionStake, state.MinipoolActionDissolve)
+
+	// Vacant minipools are promoted after the promotion scrub period instead of staking
+	vacant := prelaunch
+	vacant.IsVacant = true
+	plan = state.PlanMinipoolActions(&vacant, networkDetails, statusTime.Add(24*time.Hour))
+	if promote, _ := plan.GetAction(state.MinipoolActionPromote); promote.Ready || !promote.EarliestTime.Equal(statusTime.Add(72*time.Hour)) {
+		t.Errorf("Incorrect promote plan %+v", promote)
+	}
+	if stake, _ := plan.GetAction(state.MinipoolActionStake); stake.Ready || !stake.EarliestTime.IsZero() {
+		t.Errorf("Incorrect stake plan for a vacant minipool %+v", stake)
+	}
+	plan = state.PlanMinipoolActions(&vacant, networkDetails, statusTime.Add(72*time.Hour))
+	checkReadyActions(t, "vacant after promotion scrub period", plan, state.MinipoolActionPromote, state.MinipoolActionDissolve)
+
+	// Staking minipools can distribute their balance once they have one
+	staking := newAtlasMinipool(testMp1, testNodeA, 1, 0.1, 8)
+	staking.StatusTime = big.NewInt(statusTime.Unix())
+	plan = state.PlanMinipoolActions(&staking, networkDetails, statusTime)
+	checkReadyActions(t, "staking with no balance", plan)
+	staking.Balance = eth.EthToWei(0.1)
+	plan = state.PlanMinipoolActions(&staking, networkDetails, statusTime)
+	checkReadyActions(t, "staking with rewards", plan, state.MinipoolActionDistributeBalance)
+
+	// Bond reductions can only be completed within the window
+	reduceBondTime := statusTime.Add(24 * time.Hour)
+	staking.ReduceBondTime = big.NewInt(reduceBondTime.Unix())
+	staking.ReduceBondValue = eth.EthToWei(4)
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(time.Hour))
+	reduce, _ := plan.GetAction(state.MinipoolActionReduceBondAmount)
+	if reduce.Ready || !reduce.EarliestTime.Equal(reduceBondTime.Add(12*time.Hour)) || !reduce.Deadline.Equal(reduceBondTime.Add(60*time.Hour)) {
+		t.Errorf("Incorrect bond reduction plan before the window %+v", reduce)
+	}
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(24*time.Hour))
+	checkReadyActions(t, "staking in bond reduction window", plan, state.MinipoolActionDistributeBalance, state.MinipoolActionReduceBondAmount)
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(60*time.Hour))
+	reduce, _ = plan.GetAction(state.MinipoolActionReduceBondAmount)
+	if reduce.Ready || !reduce.EarliestTime.IsZero() || reduce.BlockedReason == "" {
+		t.Errorf("Incorrect bond reduction plan after the window %+v", reduce)
+	}
+
+	// Bond reductions share the prerequisites of the bond reduction plans
+	disabledNetworkDetails := *networkDetails
+	disabledNetworkDetails.BondReductionEnabled = false
+	plan = state.PlanMinipoolActions(&staking, &disabledNetworkDetails, reduceBondTime.Add(24*time.Hour))
+	checkReadyActions(t, "staking with bond reduction disabled", plan, state.MinipoolActionDistributeBalance)
+	staking.Balance = eth.EthToWei(8)
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(24*time.Hour))
+	checkReadyActions(t, "staking with an 8 ETH balance", plan, state.MinipoolActionDistributeBalance)
+	if reduce, _ = plan.GetAction(state.MinipoolActionReduceBondAmount); !reduce.EarliestTime.IsZero() || reduce.BlockedReason == "" {
+		t.Errorf("Incorrect bond reduction plan with an 8 ETH balance %+v", reduce)
+	}
+	staking.Balance = eth.EthToWei(0.1)
+	staking.Finalised = true
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(24*time.Hour))
+	checkReadyActions(t, "finalised in bond reduction window", plan)
+	staking.Finalised = false
+	staking.ReduceBondCancelled = true
+	plan = state.PlanMinipoolActions(&staking, networkDetails, reduceBondTime.Add(24*time.Hour))
+	checkReadyActions(t, "staking with cancelled bond reduction", plan, state.MinipoolActionDistributeBalance)
+
+	// Finalisation needs a user distribution on v3 minipools
+	staking.UserDistributed = true
+	plan = state.PlanMinipoolActions(&staking, networkDetails, statusTime)
+	checkReadyActions(t, "user distributed", plan, state.MinipoolActionDistributeBalance, state.MinipoolActionFinalise)
+	staking.Finalised = true
+	plan = state.PlanMinipoolActions(&staking, networkDetails, statusTime)
+	checkReadyActions(t, "finalised", plan)
+
+	// Dissolved minipools can be closed
+	dissolved := newAtlasMinipool(testMp1, testNodeA, 1, 0.1, 8)
+	dissolved.Status = types.Dissolved
+	plan = state.PlanMinipoolActions(&dissolved, networkDetails, statusTime)
+	checkReadyActions(t, "dissolved", plan, state.MinipoolActionClose, state.MinipoolActionDistributeBalance)
+
+	// Legacy minipools can be dissolved before they're assigned, and distribute and finalise once withdrawable
+	legacy := newMinipool(testMp1, testNodeA, 1, 0.1)
+	legacy.Version = 2
+	legacy.Status = types.Initialized
+	plan = state.PlanMinipoolActions(&legacy, networkDetails, statusTime)
+	checkReadyActions(t, "legacy initialized", plan, state.MinipoolActionDissolve)
+	legacy.Status = types.Withdrawable
+	plan = state.PlanMinipoolActions(&legacy, networkDetails, statusTime)
+	checkReadyActions(t, "legacy withdrawable", plan, state.MinipoolActionDistributeBalance, state.MinipoolActionFinalise)
+
+}
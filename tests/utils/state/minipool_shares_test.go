@@ -0,0 +1,318 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/node"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+
+	"github.com/RedDuck-Software/poolsea-go/tests"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+	minipoolutils "github.com/RedDuck-Software/poolsea-go/tests/testutils/minipool"
+	nodeutils "github.com/RedDuck-Software/poolsea-go/tests/testutils/node"
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/simulated"
+)
+
+// The balances each minipool of the share network is split at: nothing, slashed, with losses, at capital and with rewards
+var shareBalances = []string{"0", "12", "20", "31.5", "32", "33.123456789012345678", "40.000000000000000001"}
+
+// Check the node and user shares of a balance
+func checkShares(t *testing.T, name string, details *state.NativeMinipoolDetails, networkDetails *state.NetworkDetails, balance string, expectedNodeShare string) {
+	t.Helper()
+	balanceWei := ethAmount(t, balance)
+	nodeShare, err := state.CalculateMinipoolNodeShare(details, networkDetails, balanceWei)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	userShare, err := state.CalculateMinipoolUserShare(details, networkDetails, balanceWei)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	expectedUserShare := big.NewInt(0).Sub(balanceWei, ethAmount(t, expectedNodeShare))
+	if nodeShare.Cmp(ethAmount(t, expectedNodeShare)) != 0 || userShare.Cmp(expectedUserShare) != 0 {
+		t.Errorf("%s: incorrect shares of %s ETH: node %s, user %s (expected node %s ETH)", name, balance, nodeShare, userShare, expectedNodeShare)
+	}
+}
+
+func TestMinipoolShareMath(t *testing.T) {
+
+	networkDetails := &state.NetworkDetails{MaxPenaltyRate: ethAmount(t, "0.2")}
+
+	// Atlas minipools split rewards by capital and pay commission on the user's portion
+	leb8 := newAtlasMinipool(testMp1, testNodeA, 1, 0.14, 8)
+	checkShares(t, "atlas with rewards", &leb8, networkDetails, "33", "8.355")
+	checkShares(t, "atlas with losses", &leb8, networkDetails, "30", "6")
+	checkShares(t, "atlas slashed below the user deposit", &leb8, networkDetails, "20", "0")
+
+	// Penalties are capped by the network's max penalty rate
+	leb8.PenaltyRate = ethAmount(t, "0.5")
+	checkShares(t, "atlas with capped penalty", &leb8, networkDetails, "33", "6.684")
+	checkShares(t, "atlas with uncapped penalty", &leb8, nil, "33", "4.1775")
+	leb8.PenaltyRate = nil
+
+	// Legacy minipools split rewards in half, or give them to the user for unbonded minipools
+	half := newMinipool(testMp1, testNodeA, 1, 0.15)
+	half.Version = 2
+	half.DepositType = types.Half
+	half.NodeDepositBalance = ethAmount(t, "16")
+	half.UserDepositBalance = ethAmount(t, "16")
+	checkShares(t, "legacy half", &half, networkDetails, "34", "17.15")
+	checkShares(t, "legacy half with losses", &half, networkDetails, "31", "15")
+	checkShares(t, "legacy half slashed", &half, networkDetails, "15", "0")
+	empty := half
+	empty.DepositType = types.Empty
+	empty.NodeDepositBalance = ethAmount(t, "0")
+	empty.UserDepositBalance = ethAmount(t, "32")
+	checkShares(t, "legacy empty", &empty, networkDetails, "33", "0.075")
+
+	// Atlas minipools with no capital can't split rewards
+	unassigned := newAtlasMinipool(testMp1, testNodeA, 1, 0.14, 8)
+	unassigned.NodeDepositBalance = nil
+	unassigned.UserDepositBalance = nil
+	if _, err := state.CalculateMinipoolNodeShare(&unassigned, networkDetails, ethAmount(t, "1")); err == nil {
+		t.Error("Expected error for a minipool with no capital")
+	}
+
+	// The complete shares include the Beacon balance and exclude the node refund
+	leb8.Balance = ethAmount(t, "1.5")
+	leb8.NodeRefundBalance = ethAmount(t, "0.5")
+	if err := state.CalculateMinipoolShares(&leb8, networkDetails); err != nil {
+		t.Fatal(err)
+	}
+	if leb8.DistributableBalance.Cmp(ethAmount(t, "1")) != 0 || leb8.NodeShareOfBalance.Sign() != 0 || leb8.UserShareOfBalance.Cmp(ethAmount(t, "1")) != 0 {
+		t.Errorf("Incorrect contract balance shares: %s, %s, %s", leb8.DistributableBalance, leb8.NodeShareOfBalance, leb8.UserShareOfBalance)
+	}
+	if err := state.CalculateCompleteMinipoolSharesOffchain(networkDetails, []*state.NativeMinipoolDetails{&leb8}, []*big.Int{ethAmount(t, "32")}); err != nil {
+		t.Fatal(err)
+	}
+	if leb8.NodeShareOfBeaconBalance.Cmp(ethAmount(t, "8")) != 0 || leb8.UserShareOfBeaconBalance.Cmp(ethAmount(t, "24")) != 0 {
+		t.Errorf("Incorrect Beacon balance shares: %s, %s", leb8.NodeShareOfBeaconBalance, leb8.UserShareOfBeaconBalance)
+	}
+	if leb8.NodeShareOfBalanceIncludingBeacon.Cmp(ethAmount(t, "8.355")) != 0 || leb8.UserShareOfBalanceIncludingBeacon.Cmp(ethAmount(t, "24.645")) != 0 {
+		t.Errorf("Incorrect total balance shares: %s, %s", leb8.NodeShareOfBalanceIncludingBeacon, leb8.UserShareOfBalanceIncludingBeacon)
+	}
+
+}
+
+// A minipool's details and its delegate's calculateNodeShare and calculateUserShare results for a balance
+type recordedShare struct {
+	Minipool           common.Address        `json:"minipool"`
+	Version            uint8                 `json:"version"`
+	DepositType        types.MinipoolDeposit `json:"depositType"`
+	NodeFee            *big.Int              `json:"nodeFee"`
+	NodeDepositBalance *big.Int              `json:"nodeDepositBalance"`
+	UserDepositBalance *big.Int              `json:"userDepositBalance"`
+	PenaltyRate        *big.Int              `json:"penaltyRate"`
+	MaxPenaltyRate     *big.Int              `json:"maxPenaltyRate"`
+	Balance            *big.Int              `json:"balance"`
+	NodeShare          *big.Int              `json:"nodeShare"`
+	UserShare          *big.Int              `json:"userShare"`
+}
+
+// Record the shares of each balance in shareBalances from the minipools' delegates at a block
+func recordShares(t *testing.T, rp *rocketpool.RocketPool, minipoolAddresses []common.Address, block uint64) []recordedShare {
+	t.Helper()
+	opts := &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(block)}
+	maxPenaltyRate, err := rp.RocketStorage.GetUint(opts, crypto.Keccak256Hash([]byte("minipool.max.penalty.rate")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []recordedShare{}
+	for _, address := range minipoolAddresses {
+		mp, err := minipool.NewMinipool(rp, address, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record := recordedShare{
+			Minipool:       address,
+			Version:        mp.GetVersion(),
+			MaxPenaltyRate: maxPenaltyRate,
+		}
+		if record.DepositType, err = mp.GetDepositType(opts); err != nil {
+			t.Fatal(err)
+		}
+		if record.NodeFee, err = mp.GetNodeFeeRaw(opts); err != nil {
+			t.Fatal(err)
+		}
+		if record.NodeDepositBalance, err = mp.GetNodeDepositBalance(opts); err != nil {
+			t.Fatal(err)
+		}
+		if record.UserDepositBalance, err = mp.GetUserDepositBalance(opts); err != nil {
+			t.Fatal(err)
+		}
+		if record.PenaltyRate, err = rp.RocketStorage.GetUint(opts, crypto.Keccak256Hash([]byte("minipool.penalty.rate"), address.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		for _, balance := range shareBalances {
+			record.Balance = ethAmount(t, balance)
+			if record.NodeShare, err = mp.CalculateNodeShare(record.Balance, opts); err != nil {
+				t.Fatal(err)
+			}
+			if record.UserShare, err = mp.CalculateUserShare(record.Balance, opts); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Record the shares of Atlas minipools with 8 and 16 ETH bonds, created on a simulated chain with the compiled contracts
+func recordAtlasShares(t *testing.T) []recordedShare {
+	chain, err := simulated.NewChainFromEnv()
+	if errors.Is(err, simulated.ErrNoContractBundle) {
+		t.Skipf("Can't record Atlas shares: %s", err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := chain.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	ownerAccount, err := accounts.GetAccount(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trustedNodeAccount, err := accounts.GetAccount(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeAccount, err := accounts.GetAccount(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := node.RegisterNode(chain.RocketPool, "Australia/Brisbane", nodeAccount.GetTransactor()); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodeutils.RegisterTrustedNode(chain.RocketPool, ownerAccount, trustedNodeAccount); err != nil {
+		t.Fatal(err)
+	}
+	minipoolAddresses := []common.Address{}
+	for i, bond := range []float64{8, 16} {
+		mp, err := minipoolutils.CreateMinipool(t, chain.RocketPool, ownerAccount, nodeAccount, eth.EthToWei(bond), i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minipoolAddresses = append(minipoolAddresses, mp.GetAddress())
+	}
+	return recordShares(t, chain.RocketPool, minipoolAddresses, chain.Client.Blockchain().CurrentBlock().NumberU64())
+}
+
+// Record the shares of legacy minipools on a network with an execution client
+func recordLegacyShares(t *testing.T) []recordedShare {
+	provider := os.Getenv(tests.ShareProviderEnvVar)
+	storageAddress := os.Getenv(tests.ShareStorageAddressEnvVar)
+	minipools := os.Getenv(tests.ShareMinipoolsEnvVar)
+	if provider == "" || storageAddress == "" || minipools == "" {
+		t.Skipf("Can't record legacy shares: %s, %s and %s must be set", tests.ShareProviderEnvVar, tests.ShareStorageAddressEnvVar, tests.ShareMinipoolsEnvVar)
+	}
+	client, err := ethclient.Dial(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	rp, err := rocketpool.NewRocketPool(client, common.HexToAddress(storageAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	minipoolAddresses := []common.Address{}
+	for _, address := range strings.Split(minipools, ",") {
+		minipoolAddresses = append(minipoolAddresses, common.HexToAddress(strings.TrimSpace(address)))
+	}
+	records := recordShares(t, rp, minipoolAddresses, block)
+	for _, record := range records {
+		if record.Version >= 3 {
+			t.Fatalf("Minipool %s uses the Atlas delegate", record.Minipool.Hex())
+		}
+	}
+	return records
+}
+
+func TestMinipoolShareMathMatchesContracts(t *testing.T) {
+	for _, source := range []struct {
+		name    string
+		fixture string
+		record  func(t *testing.T) []recordedShare
+	}{
+		{name: "atlas", fixture: "fixture/minipool_shares_atlas.json", record: recordAtlasShares},
+		{name: "legacy", fixture: "fixture/minipool_shares_legacy.json", record: recordLegacyShares},
+	} {
+		source := source
+		t.Run(source.name, func(t *testing.T) {
+
+			// Re-record the delegate shares if requested
+			if os.Getenv(tests.RecordFixturesEnvVar) != "" {
+				recordBytes, err := json.MarshalIndent(source.record(t), "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(filepath.Dir(source.fixture), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(source.fixture, append(recordBytes, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Load the recorded shares
+			recordBytes, err := os.ReadFile(source.fixture)
+			if errors.Is(err, os.ErrNotExist) {
+				t.Skipf("No %s shares have been recorded; set %s to record them", source.name, tests.RecordFixturesEnvVar)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			records := []recordedShare{}
+			if err := json.Unmarshal(recordBytes, &records); err != nil {
+				t.Fatal(err)
+			}
+
+			// Check the off-chain math against them
+			for _, record := range records {
+				details := &state.NativeMinipoolDetails{
+					MinipoolAddress:    record.Minipool,
+					Version:            record.Version,
+					DepositType:        record.DepositType,
+					NodeFee:            record.NodeFee,
+					NodeDepositBalance: record.NodeDepositBalance,
+					UserDepositBalance: record.UserDepositBalance,
+					PenaltyRate:        record.PenaltyRate,
+				}
+				networkDetails := &state.NetworkDetails{MaxPenaltyRate: record.MaxPenaltyRate}
+				nodeShare, err := state.CalculateMinipoolNodeShare(details, networkDetails, record.Balance)
+				if err != nil {
+					t.Fatal(err)
+				}
+				userShare, err := state.CalculateMinipoolUserShare(details, networkDetails, record.Balance)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if nodeShare.Cmp(record.NodeShare) != 0 || userShare.Cmp(record.UserShare) != 0 {
+					t.Errorf("Minipool %s splits %s wei into %s / %s off-chain but %s / %s on-chain", record.Minipool.Hex(), record.Balance,
+						nodeShare, userShare, record.NodeShare, record.UserShare)
+				}
+			}
+
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/beacon"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+func TestNetworkStateFromDetails(t *testing.T) {
+
+	// Two nodes, one of which has two minipools
+	nodes := []state.NativeNodeDetails{
+		newNode(testNodeA, 100, eth.EthToWei(1)),
+		newNode(testNodeB, 50, big.NewInt(0)),
+	}
+	minipools := []state.NativeMinipoolDetails{
+		newMinipool(testMp1, testNodeA, 1, 0.1),
+		newMinipool(testMp2, testNodeA, 2, 0.2),
+	}
+	networkState := newTestNetworkState(t, 1234, &state.NetworkDetails{}, nodes, minipools)
+
+	// Lookups point into the snapshot's details
+	if node, exists := networkState.GetNode(testNodeA); !exists || node != &networkState.NodeDetails[0] {
+		t.Errorf("Incorrect node lookup %v", node)
+	}
+	if _, exists := networkState.GetNode(testMp1); exists {
+		t.Error("Found a node for a minipool address")
+	}
+	if mp, exists := networkState.GetMinipool(testMp2); !exists || mp != &networkState.MinipoolDetails[1] {
+		t.Errorf("Incorrect minipool lookup %v", mp)
+	}
+	var pubkey types.ValidatorPubkey
+	pubkey[0] = 1
+	if mp, exists := networkState.GetMinipoolByPubkey(pubkey); !exists || mp.MinipoolAddress != testMp1 {
+		t.Errorf("Incorrect pubkey lookup %v", mp)
+	}
+	if len(networkState.GetNodeMinipools(testNodeA)) != 2 || len(networkState.GetNodeMinipools(testNodeB)) != 0 {
+		t.Error("Incorrect minipools by node")
+	}
+
+	// Derived fields are calculated
+	if networkState.ElBlockNumber != 1234 || networkState.TotalEffectiveRPLStake.Cmp(eth.EthToWei(150)) != 0 {
+		t.Errorf("Incorrect block %d or total effective stake %s", networkState.ElBlockNumber, networkState.TotalEffectiveRPLStake)
+	}
+	node := networkState.NodeDetails[0]
+	if node.AverageNodeFee.Cmp(eth.EthToWei(0.15)) != 0 {
+		t.Errorf("Incorrect average fee %s", node.AverageNodeFee)
+	}
+	// Half of the balance goes to the node, plus a 15% commission on the user half
+	if node.DistributorBalanceNodeETH.Cmp(eth.EthToWei(0.575)) != 0 || node.DistributorBalanceUserETH.Cmp(eth.EthToWei(0.425)) != 0 {
+		t.Errorf("Incorrect distributor shares %s / %s", node.DistributorBalanceNodeETH, node.DistributorBalanceUserETH)
+	}
+
+}
+
+func TestMinipoolBeaconBalances(t *testing.T) {
+
+	// One minipool has a validator on the Beacon chain, one doesn't, and one has no pubkey yet
+	mp1 := newMinipool(testMp1, testNodeA, 1, 0.1)
+	mp2 := newMinipool(testMp2, testNodeA, 2, 0.1)
+	mp3 := newMinipool(testMp3, testNodeA, 0, 0.1)
+	client := beacon.NewFakeClient()
+	client.SetValidator(types.HeadBeaconState, types.ValidatorDetails{Pubkey: mp1.Pubkey, Balance: 32100000000})
+
+	// Balances are in wei and aligned with the minipools
+	balances, err := state.GetMinipoolBeaconBalances(context.Background(), client, types.HeadBeaconState, []*state.NativeMinipoolDetails{&mp1, &mp2, &mp3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 3 || balances[0].String() != "32100000000000000000" || balances[1].Sign() != 0 || balances[2].Sign() != 0 {
+		t.Errorf("Incorrect balances %v", balances)
+	}
+
+}
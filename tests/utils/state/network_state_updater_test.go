@@ -0,0 +1,182 @@
+package state
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	rptypes "github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/RedDuck-Software/poolsea-go/utils/state"
+)
+
+// The nodes and minipools of the updater test network
+var (
+	updaterNodeA = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+	updaterNodeB = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+	updaterNodeC = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+	updaterMp1   = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	updaterMp2   = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+	updaterMp3   = common.HexToAddress("0x00000000000000000000000000000000000000b3")
+	updaterMp4   = common.HexToAddress("0x00000000000000000000000000000000000000b4")
+)
+
+// Create a network with two nodes and three minipools at block 100, and load its state
+func newUpdaterNetwork(t *testing.T) (*fakeNetwork, *rocketpool.RocketPool, *state.NetworkState) {
+	t.Helper()
+	snapshot := &fakeSnapshot{
+		rplPrice: eth.EthToWei(0.01),
+		balances: map[common.Address]*big.Int{updaterMp1: eth.EthToWei(0.5)},
+	}
+	snapshot.addNode(updaterNodeA)
+	snapshot.addNode(updaterNodeB)
+	snapshot.addMinipool(updaterMp1, updaterNodeA, rptypes.Staking)
+	snapshot.addMinipool(updaterMp2, updaterNodeA, rptypes.Prelaunch)
+	snapshot.addMinipool(updaterMp3, updaterNodeB, rptypes.Staking)
+	network := newFakeNetwork(t, 100, snapshot)
+	rp := network.rocketPool(t)
+	loaded := network.loadState(t, rp, 100)
+
+	// The byte outputs are converted to the details' types
+	for _, mp := range loaded.MinipoolDetails {
+		hash := crypto.Keccak256(mp.MinipoolAddress.Bytes())
+		if mp.Pubkey != rptypes.BytesToValidatorPubkey(append(hash, hash[:16]...)) || mp.WithdrawalCredentials != common.BytesToHash(mp.MinipoolAddress.Bytes()) {
+			t.Errorf("Incorrect pubkey %s or withdrawal credentials %s for minipool %s", mp.Pubkey.Hex(), mp.WithdrawalCredentials.Hex(), mp.MinipoolAddress.Hex())
+		}
+	}
+	return network, rp, loaded
+}
+
+// Update a state and check that the result matches a full load of the network at the block
+func checkUpdate(t *testing.T, network *fakeNetwork, rp *rocketpool.RocketPool, previous *state.NetworkState, block uint64, fullReload bool, updatedNodes int, updatedMinipools int) *state.NetworkStateUpdate {
+	t.Helper()
+	updater := state.NewNetworkStateUpdater(rp, fakeMulticallAddress, fakeBalanceBatcherAddress, state.NetworkStateUpdaterSettings{})
+	update, err := updater.Update(previous, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.FullReload != fullReload || update.UpdatedNodes != updatedNodes || update.UpdatedMinipools != updatedMinipools {
+		t.Errorf("Expected reload %t with %d nodes and %d minipools updated, got reload %t (%s) with %d and %d",
+			fullReload, updatedNodes, updatedMinipools, update.FullReload, update.FullReloadReason, update.UpdatedNodes, update.UpdatedMinipools)
+	}
+	expected := network.loadState(t, rp, block)
+	if update.State.ElBlockNumber != block || update.State.ElBlockHash != expected.ElBlockHash {
+		t.Errorf("Incorrect block %d with hash %s", update.State.ElBlockNumber, update.State.ElBlockHash.Hex())
+	}
+	if diff := state.DiffNetworkStates(expected, update.State); !diff.IsEmpty() {
+		t.Errorf("Updated state doesn't match a full load: %+v", diff)
+	}
+	return update
+}
+
+func TestUpdateNetworkStateWithoutChanges(t *testing.T) {
+	network, rp, previous := newUpdaterNetwork(t)
+	network.advance(110, func(s *fakeSnapshot) {})
+	checkUpdate(t, network, rp, previous, 110, false, 0, 0)
+
+	// Updating to the same block returns the previous state
+	updater := state.NewNetworkStateUpdater(rp, fakeMulticallAddress, fakeBalanceBatcherAddress, state.NetworkStateUpdaterSettings{})
+	if update, err := updater.Update(previous, 100); err != nil {
+		t.Fatal(err)
+	} else if update.State != previous {
+		t.Error("Expected the previous state for the same block")
+	}
+}
+
+func TestUpdateNetworkStateMinipoolEvent(t *testing.T) {
+
+	// A minipool starts staking and emits a status event, which updates it and its node
+	network, rp, previous := newUpdaterNetwork(t)
+	network.advance(105, func(s *fakeSnapshot) {
+		mp, _ := s.getMinipool(updaterMp2)
+		mp.status = rptypes.Staking
+	})
+	network.emit(types.Log{
+		Address:     updaterMp2,
+		BlockNumber: 105,
+		Topics:      []common.Hash{minipoolStatusUpdatedTopic, common.BigToHash(big.NewInt(int64(rptypes.Staking)))},
+	})
+	update := checkUpdate(t, network, rp, previous, 110, false, 1, 1)
+	if mp, _ := update.State.GetMinipool(updaterMp2); mp.Status != rptypes.Staking {
+		t.Errorf("Incorrect minipool status %s", mp.Status.String())
+	}
+
+	// Rewards sent to a minipool don't emit an event but change its balance, which updates it too
+	network.advance(120, func(s *fakeSnapshot) {
+		s.balances[updaterMp3] = eth.EthToWei(0.1)
+	})
+	checkUpdate(t, network, rp, update.State, 120, false, 1, 1)
+
+}
+
+func TestUpdateNetworkStateNewNodeAndMinipool(t *testing.T) {
+	network, rp, previous := newUpdaterNetwork(t)
+	network.advance(105, func(s *fakeSnapshot) {
+		s.addNode(updaterNodeC)
+		s.addMinipool(updaterMp4, updaterNodeC, rptypes.Prelaunch)
+	})
+	update := checkUpdate(t, network, rp, previous, 110, false, 1, 1)
+	if _, exists := update.State.GetNode(updaterNodeC); !exists {
+		t.Error("New node is missing")
+	}
+	if minipools := update.State.GetNodeMinipools(updaterNodeC); len(minipools) != 1 || minipools[0].MinipoolAddress != updaterMp4 {
+		t.Errorf("Incorrect minipools for the new node %v", minipools)
+	}
+}
+
+func TestUpdateNetworkStateRplPrice(t *testing.T) {
+
+	// An RPL price change changes the minimum stake of every node without any events
+	network, rp, previous := newUpdaterNetwork(t)
+	network.advance(105, func(s *fakeSnapshot) {
+		s.rplPrice = eth.EthToWei(0.02)
+	})
+	update := checkUpdate(t, network, rp, previous, 110, false, 2, 0)
+	for _, node := range update.State.NodeDetails {
+		if node.MinimumRPLStake.Cmp(eth.EthToWei(120)) != 0 {
+			t.Errorf("Incorrect minimum stake %s for node %s", node.MinimumRPLStake.String(), node.NodeAddress.Hex())
+		}
+	}
+
+}
+
+func TestUpdateNetworkStateReorg(t *testing.T) {
+
+	// The previous block was reorged out, so its hash no longer matches
+	network, rp, previous := newUpdaterNetwork(t)
+	network.advance(110, func(s *fakeSnapshot) {})
+	network.reorg(100)
+	update := checkUpdate(t, network, rp, previous, 110, true, 2, 3)
+	if !strings.Contains(update.FullReloadReason, "reorged") {
+		t.Errorf("Incorrect reload reason %s", update.FullReloadReason)
+	}
+
+	// A removed event means a later block was reorged out
+	network, rp, previous = newUpdaterNetwork(t)
+	network.advance(110, func(s *fakeSnapshot) {})
+	network.emit(types.Log{
+		Address:     updaterMp1,
+		BlockNumber: 105,
+		Topics:      []common.Hash{minipoolStatusUpdatedTopic},
+		Removed:     true,
+	})
+	update = checkUpdate(t, network, rp, previous, 110, true, 2, 3)
+	if update.FullReloadReason != "block 105 was reorged out" {
+		t.Errorf("Incorrect reload reason %s", update.FullReloadReason)
+	}
+
+}
+
+func TestUpdateNilNetworkState(t *testing.T) {
+	network, rp, _ := newUpdaterNetwork(t)
+	network.advance(110, func(s *fakeSnapshot) {})
+	updater := state.NewNetworkStateUpdater(rp, fakeMulticallAddress, fakeBalanceBatcherAddress, state.NetworkStateUpdaterSettings{})
+	if _, err := updater.Update(nil, 110); err == nil {
+		t.Error("Expected an error updating a nil state")
+	}
+}
@@ -0,0 +1,163 @@
+package txmanager
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/stub"
+)
+
+// The chain ID used to sign test transactions
+var chainID = big.NewInt(1337)
+
+// An in-memory chain with a mempool, where blocks are mined and reorged on demand
+type fakeChain struct {
+	pending  map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	headers  []*types.Header
+	nonces   map[common.Address]uint64
+	signer   types.Signer
+	lock     sync.Mutex
+}
+
+// Create a new fake chain with a genesis block
+func newFakeChain() *fakeChain {
+	chain := &fakeChain{
+		pending:  map[common.Hash]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{},
+		nonces:   map[common.Address]uint64{},
+		signer:   types.LatestSignerForChainID(chainID),
+	}
+	chain.headers = []*types.Header{chain.newHeader(0, 0)}
+	return chain
+}
+
+// Create a header for a block; the salt makes replacement blocks at the same height have different hashes
+func (c *fakeChain) newHeader(number uint64, salt byte) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(1000000000),
+		Extra:      []byte{salt},
+	}
+}
+
+// Mine a block with some pending transactions, replacing any pending transactions that share their nonces
+func (c *fakeChain) mine(hashes ...common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	header := c.newHeader(uint64(len(c.headers)), byte(len(c.headers)))
+	c.headers = append(c.headers, header)
+	for _, hash := range hashes {
+		tx := c.pending[hash]
+		sender, _ := types.Sender(c.signer, tx)
+		c.receipts[hash] = &types.Receipt{
+			TxHash:      hash,
+			Status:      types.ReceiptStatusSuccessful,
+			BlockNumber: header.Number,
+			BlockHash:   header.Hash(),
+		}
+		c.nonces[sender] = tx.Nonce() + 1
+		for pendingHash, pendingTx := range c.pending {
+			if pendingSender, _ := types.Sender(c.signer, pendingTx); pendingSender == sender && pendingTx.Nonce() == tx.Nonce() {
+				delete(c.pending, pendingHash)
+			}
+		}
+	}
+}
+
+// Replace the latest block with an empty one, returning its transactions to the mempool
+func (c *fakeChain) reorg(transactions ...*types.Transaction) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	latest := c.headers[len(c.headers)-1]
+	for _, tx := range transactions {
+		sender, _ := types.Sender(c.signer, tx)
+		delete(c.receipts, tx.Hash())
+		c.pending[tx.Hash()] = tx
+		c.nonces[sender] = tx.Nonce()
+	}
+	c.headers[len(c.headers)-1] = c.newHeader(latest.Number.Uint64(), latest.Extra[0]+100)
+}
+
+// Remove a transaction from the mempool
+func (c *fakeChain) drop(hash common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.pending, hash)
+}
+
+// Use up a sender's next nonce with a transaction that isn't tracked
+func (c *fakeChain) useNonce(sender common.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.nonces[sender]++
+}
+
+// Get an execution client backed by the chain
+func (c *fakeChain) client() *stub.Client {
+	return &stub.Client{
+		SendTransactionFunc: func(ctx context.Context, tx *types.Transaction) error {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			c.pending[tx.Hash()] = tx
+			return nil
+		},
+		PendingNonceAtFunc: func(ctx context.Context, account common.Address) (uint64, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return c.nonces[account], nil
+		},
+		NonceAtFunc: func(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return c.nonces[account], nil
+		},
+		SuggestGasTipCapFunc: func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(1000000000), nil
+		},
+		EstimateGasFunc: func(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+			return 21000, nil
+		},
+		BlockNumberFunc: func(ctx context.Context) (uint64, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			return uint64(len(c.headers) - 1), nil
+		},
+		HeaderByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Header, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if number == nil {
+				return c.headers[len(c.headers)-1], nil
+			}
+			if number.Uint64() >= uint64(len(c.headers)) {
+				return nil, ethereum.NotFound
+			}
+			return c.headers[number.Uint64()], nil
+		},
+		TransactionReceiptFunc: func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if receipt, exists := c.receipts[txHash]; exists {
+				return receipt, nil
+			}
+			return nil, ethereum.NotFound
+		},
+		TransactionByHashFunc: func(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if tx, exists := c.pending[hash]; exists {
+				return tx, true, nil
+			}
+			if _, exists := c.receipts[hash]; exists {
+				return nil, false, nil
+			}
+			return nil, false, ethereum.NotFound
+		},
+	}
+}
@@ -0,0 +1,331 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/txmanager"
+
+	"github.com/RedDuck-Software/poolsea-go/tests/testutils/accounts"
+)
+
+// A contract with a single method to transact with
+const testContractAbi = `[{"type":"function","name":"stakeRPL","inputs":[{"name":"amount","type":"uint256"}],"outputs":[],"stateMutability":"nonpayable"}]`
+
+// Records the lifecycle events reported by a manager
+type eventRecorder struct {
+	events []txmanager.EventType
+	lock   sync.Mutex
+}
+
+func (r *eventRecorder) record(event txmanager.Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event.Type)
+}
+
+func (r *eventRecorder) get() []txmanager.EventType {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]txmanager.EventType{}, r.events...)
+}
+
+// Create a transactor for the first test account
+func getTransactor(t *testing.T) *bind.TransactOpts {
+	account, err := accounts.GetAccount(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(account.PrivateKey, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+// Create a contract that routes its transactions through a manager
+func newManagedContract(t *testing.T, chain *fakeChain, manager rocketpool.TransactionManager) *rocketpool.Contract {
+	contractAbi, err := abi.JSON(strings.NewReader(testContractAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := chain.client()
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	return &rocketpool.Contract{
+		Contract:           bind.NewBoundContract(address, contractAbi, client, client, client),
+		Address:            &address,
+		ABI:                &contractAbi,
+		Client:             client,
+		Name:               "poolseaNodeStaking",
+		TransactionManager: manager,
+	}
+}
+
+// Send a transaction through a managed contract
+func transact(t *testing.T, contract *rocketpool.Contract) *types.Transaction {
+	tx, err := contract.Transact(getTransactor(t), "stakeRPL", big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// Check that a list of events matches the expected list
+func checkEvents(t *testing.T, events []txmanager.EventType, expected ...txmanager.EventType) {
+	t.Helper()
+	if len(events) != len(expected) {
+		t.Fatalf("Incorrect events %v, expected %v", events, expected)
+	}
+	for i := range events {
+		if events[i] != expected[i] {
+			t.Fatalf("Incorrect events %v, expected %v", events, expected)
+		}
+	}
+}
+
+func TestNonceAllocation(t *testing.T) {
+
+	// Send transactions without mining them; the node only reports the mined nonce
+	chain := newFakeChain()
+	recorder := &eventRecorder{}
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{OnEvent: recorder.record})
+	contract := newManagedContract(t, chain, manager)
+	for i := uint64(0); i < 3; i++ {
+		if tx := transact(t, contract); tx.Nonce() != i {
+			t.Errorf("Incorrect nonce %d for transaction %d", tx.Nonce(), i)
+		}
+	}
+	if nextNonce := manager.GetNextNonce(getTransactor(t).From); nextNonce != 3 {
+		t.Errorf("Incorrect next nonce %d", nextNonce)
+	}
+	checkEvents(t, recorder.get(), txmanager.EventSubmitted, txmanager.EventSubmitted, txmanager.EventSubmitted)
+
+	// Catch up with transactions sent elsewhere
+	for i := 0; i < 5; i++ {
+		chain.useNonce(getTransactor(t).From)
+	}
+	if tx := transact(t, contract); tx.Nonce() != 5 {
+		t.Errorf("Incorrect nonce %d after external transactions", tx.Nonce())
+	}
+
+}
+
+func TestWaitForConfirmation(t *testing.T) {
+
+	// Mine the transaction, then mine another block once it's been seen
+	chain := newFakeChain()
+	recorder := &eventRecorder{}
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{
+		Confirmations: 2,
+		PollInterval:  time.Millisecond,
+		OnEvent: func(event txmanager.Event) {
+			recorder.record(event)
+			if event.Type == txmanager.EventMined {
+				chain.mine()
+			}
+		},
+	})
+	tx := transact(t, newManagedContract(t, chain, manager))
+	chain.mine(tx.Hash())
+
+	// Wait for it
+	receipt, err := manager.WaitForConfirmation(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != tx.Hash() || receipt.BlockNumber.Uint64() != 1 {
+		t.Errorf("Incorrect receipt for transaction %s in block %d", receipt.TxHash.Hex(), receipt.BlockNumber.Uint64())
+	}
+	checkEvents(t, recorder.get(), txmanager.EventSubmitted, txmanager.EventMined, txmanager.EventConfirmed)
+
+	// Confirmed transactions are no longer tracked
+	if _, err := manager.WaitForConfirmation(context.Background(), tx.Hash()); !errors.Is(err, txmanager.ErrUnknownTransaction) {
+		t.Errorf("Expected unknown transaction error, got %v", err)
+	}
+
+}
+
+func TestReorg(t *testing.T) {
+
+	// Reorg the transaction out of the chain the first time it's mined, then mine it again
+	chain := newFakeChain()
+	recorder := &eventRecorder{}
+	var tx *types.Transaction
+	reorged := false
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{
+		Confirmations: 2,
+		PollInterval:  time.Millisecond,
+		OnEvent: func(event txmanager.Event) {
+			recorder.record(event)
+			switch event.Type {
+			case txmanager.EventMined:
+				if !reorged {
+					reorged = true
+					chain.reorg(tx)
+				} else {
+					chain.mine()
+				}
+			case txmanager.EventReorged:
+				chain.mine(tx.Hash())
+			}
+		},
+	})
+	tx = transact(t, newManagedContract(t, chain, manager))
+	chain.mine(tx.Hash())
+
+	// Wait for it
+	receipt, err := manager.WaitForConfirmation(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.BlockNumber.Uint64() != 2 {
+		t.Errorf("Incorrect block number %d after reorg", receipt.BlockNumber.Uint64())
+	}
+	checkEvents(t, recorder.get(), txmanager.EventSubmitted, txmanager.EventMined, txmanager.EventReorged, txmanager.EventMined, txmanager.EventConfirmed)
+
+}
+
+func TestSpeedUpAndCancel(t *testing.T) {
+
+	chain := newFakeChain()
+	recorder := &eventRecorder{}
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{PollInterval: time.Millisecond, OnEvent: recorder.record})
+	contract := newManagedContract(t, chain, manager)
+
+	// Speed up a transaction
+	tx := transact(t, contract)
+	fastTx, err := manager.SpeedUp(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fastTx.Nonce() != tx.Nonce() || fastTx.To() == nil || *fastTx.To() != *tx.To() || string(fastTx.Data()) != string(tx.Data()) {
+		t.Error("Sped up transaction does not match the original")
+	}
+	minTipCap := new(big.Int).Div(new(big.Int).Mul(tx.GasTipCap(), big.NewInt(110)), big.NewInt(100))
+	minFeeCap := new(big.Int).Div(new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(110)), big.NewInt(100))
+	if fastTx.GasTipCap().Cmp(minTipCap) < 0 || fastTx.GasFeeCap().Cmp(minFeeCap) < 0 {
+		t.Errorf("Fees were not bumped enough: tip %s -> %s, fee cap %s -> %s", tx.GasTipCap(), fastTx.GasTipCap(), tx.GasFeeCap(), fastTx.GasFeeCap())
+	}
+
+	// Mine the replacement and wait for the original
+	chain.mine(fastTx.Hash())
+	receipt, err := manager.WaitForConfirmation(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != fastTx.Hash() {
+		t.Errorf("Incorrect mined transaction %s", receipt.TxHash.Hex())
+	}
+
+	// Cancel a transaction
+	tx = transact(t, contract)
+	cancelTx, err := manager.Cancel(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := getTransactor(t).From
+	if cancelTx.Nonce() != tx.Nonce() || *cancelTx.To() != from || cancelTx.Value().Sign() != 0 || len(cancelTx.Data()) != 0 {
+		t.Error("Cancel transaction is not an empty transfer to the sender")
+	}
+	if hashes, err := manager.GetTransactionHashes(cancelTx.Hash()); err != nil {
+		t.Fatal(err)
+	} else if len(hashes) != 2 || hashes[0] != tx.Hash() || hashes[1] != cancelTx.Hash() {
+		t.Errorf("Incorrect transaction hashes %v", hashes)
+	}
+	chain.mine(cancelTx.Hash())
+	if _, err := manager.WaitForConfirmation(context.Background(), cancelTx.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mined transactions can't be replaced
+	if _, err := manager.SpeedUp(context.Background(), tx.Hash()); !errors.Is(err, txmanager.ErrUnknownTransaction) {
+		t.Errorf("Expected unknown transaction error, got %v", err)
+	}
+	checkEvents(t, recorder.get(),
+		txmanager.EventSubmitted, txmanager.EventSpedUp, txmanager.EventMined, txmanager.EventConfirmed,
+		txmanager.EventSubmitted, txmanager.EventCancelSubmitted, txmanager.EventMined, txmanager.EventConfirmed,
+	)
+
+}
+
+func TestDroppedTransactions(t *testing.T) {
+
+	// Transactions that disappear from the mempool
+	chain := newFakeChain()
+	recorder := &eventRecorder{}
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{PollInterval: time.Millisecond, DropTimeout: 10 * time.Millisecond, OnEvent: recorder.record})
+	contract := newManagedContract(t, chain, manager)
+	tx := transact(t, contract)
+	chain.drop(tx.Hash())
+	if _, err := manager.WaitForConfirmation(context.Background(), tx.Hash()); !errors.Is(err, txmanager.ErrTransactionDropped) {
+		t.Errorf("Expected dropped error, got %v", err)
+	}
+	checkEvents(t, recorder.get(), txmanager.EventSubmitted, txmanager.EventDropped)
+
+	// The dropped transaction's nonce is reused instead of leaving a gap
+	if nextNonce := manager.GetNextNonce(getTransactor(t).From); nextNonce != 0 {
+		t.Errorf("Incorrect next nonce %d after a dropped transaction", nextNonce)
+	}
+
+	// Transactions whose nonce is used by another transaction, after the dropped one's nonce is also used
+	tx = transact(t, contract)
+	if tx.Nonce() != 0 {
+		t.Errorf("Incorrect nonce %d after a dropped transaction", tx.Nonce())
+	}
+	chain.drop(tx.Hash())
+	chain.useNonce(getTransactor(t).From)
+	chain.useNonce(getTransactor(t).From)
+	if _, err := manager.WaitForConfirmation(context.Background(), tx.Hash()); !errors.Is(err, txmanager.ErrTransactionOverridden) {
+		t.Errorf("Expected overridden error, got %v", err)
+	}
+
+	// Waiting stops with the context
+	tx = transact(t, contract)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := manager.WaitForConfirmation(ctx, tx.Hash()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+
+}
+
+func TestUnwaitedTransactions(t *testing.T) {
+
+	// Mine a transaction that's never waited on
+	chain := newFakeChain()
+	manager := txmanager.NewManager(chain.client(), txmanager.Settings{PollInterval: time.Millisecond, DropTimeout: 20 * time.Millisecond})
+	contract := newManagedContract(t, chain, manager)
+	mined := transact(t, contract)
+	chain.mine(mined.Hash())
+
+	// It stays tracked for the drop timeout, so it can still be waited on
+	if tx := transact(t, contract); tx.Nonce() != 1 {
+		t.Errorf("Incorrect nonce %d after a mined transaction", tx.Nonce())
+	} else {
+		chain.mine(tx.Hash())
+	}
+	if _, err := manager.GetTransactionHashes(mined.Hash()); err != nil {
+		t.Errorf("Mined transaction stopped being tracked before the drop timeout: %v", err)
+	}
+
+	// Afterwards, sending another transaction stops tracking the mined ones
+	time.Sleep(30 * time.Millisecond)
+	if tx := transact(t, contract); tx.Nonce() != 2 {
+		t.Errorf("Incorrect nonce %d after mined transactions", tx.Nonce())
+	}
+	if _, err := manager.GetTransactionHashes(mined.Hash()); !errors.Is(err, txmanager.ErrUnknownTransaction) {
+		t.Errorf("Expected unknown transaction error for a mined transaction, got %v", err)
+	}
+
+}
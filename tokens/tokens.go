@@ -1,7 +1,6 @@
 package tokens
 
 import (
-	"context"
 	"fmt"
 	"math/big"
 
@@ -39,7 +38,7 @@ func GetBalances(rp *rocketpool.RocketPool, address common.Address, opts *bind.C
 	// Load data
 	wg.Go(func() error {
 		var err error
-		ethBalance, err = rp.Client.BalanceAt(context.Background(), address, blockNumber)
+		ethBalance, err = rp.Client.BalanceAt(rocketpool.GetCallContext(opts), address, blockNumber)
 		return err
 	})
 	wg.Go(func() error {
@@ -79,7 +78,7 @@ func contractETHBalance(rp *rocketpool.RocketPool, tokenContract *rocketpool.Con
 	if opts != nil {
 		blockNumber = opts.BlockNumber
 	}
-	return rp.Client.BalanceAt(context.Background(), *(tokenContract.Address), blockNumber)
+	return rp.Client.BalanceAt(rocketpool.GetCallContext(opts), *(tokenContract.Address), blockNumber)
 }
 
 // Get a token's total supply
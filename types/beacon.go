@@ -2,10 +2,12 @@ package types
 
 import (
 	"fmt"
+	"strconv"
 
 	"encoding/hex"
 
 	"github.com/RedDuck-Software/poolsea-go/utils/json"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // Validator pubkey
@@ -103,3 +105,62 @@ func (v *ValidatorSignature) UnmarshalJSON(data []byte) error {
 	}
 	return err
 }
+
+// Beacon chain state identifier; a slot number, a state root, or one of the named states
+type BeaconStateId string
+
+const (
+	HeadBeaconState      BeaconStateId = "head"
+	FinalizedBeaconState BeaconStateId = "finalized"
+	JustifiedBeaconState BeaconStateId = "justified"
+)
+
+// Get the state identifier for a slot
+func SlotBeaconState(slot uint64) BeaconStateId {
+	return BeaconStateId(strconv.FormatUint(slot, 10))
+}
+
+// Validator statuses, as reported by the Beacon API
+type ValidatorStatus string
+
+const (
+	ValidatorPendingInitialized ValidatorStatus = "pending_initialized"
+	ValidatorPendingQueued      ValidatorStatus = "pending_queued"
+	ValidatorActiveOngoing      ValidatorStatus = "active_ongoing"
+	ValidatorActiveExiting      ValidatorStatus = "active_exiting"
+	ValidatorActiveSlashed      ValidatorStatus = "active_slashed"
+	ValidatorExitedUnslashed    ValidatorStatus = "exited_unslashed"
+	ValidatorExitedSlashed      ValidatorStatus = "exited_slashed"
+	ValidatorWithdrawalPossible ValidatorStatus = "withdrawal_possible"
+	ValidatorWithdrawalDone     ValidatorStatus = "withdrawal_done"
+)
+
+// The epoch used by the Beacon chain for events that haven't happened yet
+const FarFutureEpoch uint64 = 1<<64 - 1
+
+// Details of a validator on the Beacon chain
+type ValidatorDetails struct {
+	Pubkey                     ValidatorPubkey `json:"pubkey"`
+	Index                      uint64          `json:"index"`
+	Balance                    uint64          `json:"balance"`          // In gwei
+	EffectiveBalance           uint64          `json:"effectiveBalance"` // In gwei
+	Status                     ValidatorStatus `json:"status"`
+	Slashed                    bool            `json:"slashed"`
+	ActivationEligibilityEpoch uint64          `json:"activationEligibilityEpoch"`
+	ActivationEpoch            uint64          `json:"activationEpoch"`
+	ExitEpoch                  uint64          `json:"exitEpoch"`
+	WithdrawableEpoch          uint64          `json:"withdrawableEpoch"`
+}
+
+// A Beacon chain checkpoint
+type BeaconCheckpoint struct {
+	Epoch uint64      `json:"epoch"`
+	Root  common.Hash `json:"root"`
+}
+
+// The finality checkpoints of a Beacon chain state
+type FinalityCheckpoints struct {
+	PreviousJustified BeaconCheckpoint `json:"previousJustified"`
+	CurrentJustified  BeaconCheckpoint `json:"currentJustified"`
+	Finalized         BeaconCheckpoint `json:"finalized"`
+}
@@ -0,0 +1,92 @@
+package beacon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+)
+
+// An in-memory Beacon client for tests and simulations, serving validators and checkpoints that were set on it
+type FakeClient struct {
+	states map[types.BeaconStateId]*fakeState
+	lock   sync.Mutex
+}
+
+// The contents of a single fake Beacon state
+type fakeState struct {
+	validators  map[types.ValidatorPubkey]types.ValidatorDetails
+	checkpoints types.FinalityCheckpoints
+}
+
+// Create a new fake Beacon client with no states
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		states: map[types.BeaconStateId]*fakeState{},
+	}
+}
+
+// Set a validator's details in a state, creating the state if it doesn't exist
+func (c *FakeClient) SetValidator(stateId types.BeaconStateId, validator types.ValidatorDetails) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getOrCreateState(stateId).validators[validator.Pubkey] = validator
+}
+
+// Set the finality checkpoints of a state, creating the state if it doesn't exist
+func (c *FakeClient) SetFinalityCheckpoints(stateId types.BeaconStateId, checkpoints types.FinalityCheckpoints) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getOrCreateState(stateId).checkpoints = checkpoints
+}
+
+// Get the details of the validators with the given pubkeys in the given state
+func (c *FakeClient) GetValidators(ctx context.Context, stateId types.BeaconStateId, pubkeys []types.ValidatorPubkey) (map[types.ValidatorPubkey]types.ValidatorDetails, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	state, err := c.getState(stateId)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[types.ValidatorPubkey]types.ValidatorDetails, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		if validator, exists := state.validators[pubkey]; exists {
+			validators[pubkey] = validator
+		}
+	}
+	return validators, nil
+}
+
+// Get the justified and finalized checkpoints of the given state
+func (c *FakeClient) GetFinalityCheckpoints(ctx context.Context, stateId types.BeaconStateId) (types.FinalityCheckpoints, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	state, err := c.getState(stateId)
+	if err != nil {
+		return types.FinalityCheckpoints{}, err
+	}
+	return state.checkpoints, nil
+}
+
+// Get a state, failing the way the Beacon API does if it doesn't exist
+func (c *FakeClient) getState(stateId types.BeaconStateId) (*fakeState, error) {
+	state, exists := c.states[stateId]
+	if !exists {
+		return nil, &ApiError{StatusCode: 404, Message: fmt.Sprintf("state %s not found", stateId)}
+	}
+	return state, nil
+}
+
+// Get a state, creating it if it doesn't exist
+func (c *FakeClient) getOrCreateState(stateId types.BeaconStateId) *fakeState {
+	state, exists := c.states[stateId]
+	if !exists {
+		state = &fakeState{
+			validators: map[types.ValidatorPubkey]types.ValidatorDetails{},
+		}
+		c.states[stateId] = state
+	}
+	return state
+}
@@ -0,0 +1,232 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Default HTTP client settings
+const (
+	DefaultValidatorBatchSize int           = 64 // Keeps the request URL well within the limits of common servers
+	DefaultRequestTimeout     time.Duration = 30 * time.Second
+)
+
+// Beacon API routes
+const (
+	validatorsRoute          string = "/eth/v1/beacon/states/%s/validators"
+	finalityCheckpointsRoute string = "/eth/v1/beacon/states/%s/finality_checkpoints"
+)
+
+// Make sure the clients satisfy the Beacon client interface
+var (
+	_ rocketpool.BeaconClient = (*HttpClient)(nil)
+	_ rocketpool.BeaconClient = (*FakeClient)(nil)
+)
+
+// An error response from the Beacon API
+type ApiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	return fmt.Sprintf("beacon API request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// A Beacon client that uses the standard Beacon API over HTTP
+type HttpClient struct {
+	baseUrl            string
+	client             *http.Client
+	ValidatorBatchSize int // The most validators to request at once
+}
+
+// Create a new Beacon API client for the node at the given URL
+func NewHttpClient(baseUrl string) *HttpClient {
+	return &HttpClient{
+		baseUrl:            strings.TrimSuffix(baseUrl, "/"),
+		client:             &http.Client{Timeout: DefaultRequestTimeout},
+		ValidatorBatchSize: DefaultValidatorBatchSize,
+	}
+}
+
+// Create a new Beacon API client for the node at the given URL, using the provided HTTP client for requests
+func NewHttpClientWithClient(baseUrl string, client *http.Client) *HttpClient {
+	beaconClient := NewHttpClient(baseUrl)
+	beaconClient.client = client
+	return beaconClient
+}
+
+// Validator response
+type validatorResponse struct {
+	Data []struct {
+		Index     string `json:"index"`
+		Balance   string `json:"balance"`
+		Status    string `json:"status"`
+		Validator struct {
+			Pubkey                     string `json:"pubkey"`
+			EffectiveBalance           string `json:"effective_balance"`
+			Slashed                    bool   `json:"slashed"`
+			ActivationEligibilityEpoch string `json:"activation_eligibility_epoch"`
+			ActivationEpoch            string `json:"activation_epoch"`
+			ExitEpoch                  string `json:"exit_epoch"`
+			WithdrawableEpoch          string `json:"withdrawable_epoch"`
+		} `json:"validator"`
+	} `json:"data"`
+}
+
+// Finality checkpoints response
+type checkpointResponse struct {
+	Epoch string `json:"epoch"`
+	Root  string `json:"root"`
+}
+type finalityCheckpointsResponse struct {
+	Data struct {
+		PreviousJustified checkpointResponse `json:"previous_justified"`
+		CurrentJustified  checkpointResponse `json:"current_justified"`
+		Finalized         checkpointResponse `json:"finalized"`
+	} `json:"data"`
+}
+
+// Get the details of the validators with the given pubkeys in the given state
+func (c *HttpClient) GetValidators(ctx context.Context, stateId types.BeaconStateId, pubkeys []types.ValidatorPubkey) (map[types.ValidatorPubkey]types.ValidatorDetails, error) {
+	validators := make(map[types.ValidatorPubkey]types.ValidatorDetails, len(pubkeys))
+	batchSize := c.ValidatorBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultValidatorBatchSize
+	}
+
+	for start := 0; start < len(pubkeys); start += batchSize {
+		end := start + batchSize
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+		ids := make([]string, 0, end-start)
+		for _, pubkey := range pubkeys[start:end] {
+			ids = append(ids, "0x"+pubkey.Hex())
+		}
+
+		var response validatorResponse
+		query := url.Values{"id": {strings.Join(ids, ",")}}
+		if err := c.get(ctx, fmt.Sprintf(validatorsRoute, url.PathEscape(string(stateId))), query, &response); err != nil {
+			return nil, fmt.Errorf("error getting validators: %w", err)
+		}
+		for _, data := range response.Data {
+			pubkey, err := types.HexToValidatorPubkey(strings.TrimPrefix(data.Validator.Pubkey, "0x"))
+			if err != nil {
+				return nil, fmt.Errorf("error decoding validator pubkey: %w", err)
+			}
+			details := types.ValidatorDetails{
+				Pubkey:  pubkey,
+				Status:  types.ValidatorStatus(data.Status),
+				Slashed: data.Validator.Slashed,
+			}
+			fields := []struct {
+				name  string
+				value string
+				field *uint64
+			}{
+				{"index", data.Index, &details.Index},
+				{"balance", data.Balance, &details.Balance},
+				{"effective balance", data.Validator.EffectiveBalance, &details.EffectiveBalance},
+				{"activation eligibility epoch", data.Validator.ActivationEligibilityEpoch, &details.ActivationEligibilityEpoch},
+				{"activation epoch", data.Validator.ActivationEpoch, &details.ActivationEpoch},
+				{"exit epoch", data.Validator.ExitEpoch, &details.ExitEpoch},
+				{"withdrawable epoch", data.Validator.WithdrawableEpoch, &details.WithdrawableEpoch},
+			}
+			for _, field := range fields {
+				*field.field, err = strconv.ParseUint(field.value, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("error decoding %s of validator %s: %w", field.name, pubkey.Hex(), err)
+				}
+			}
+			validators[pubkey] = details
+		}
+	}
+
+	return validators, nil
+}
+
+// Get the justified and finalized checkpoints of the given state
+func (c *HttpClient) GetFinalityCheckpoints(ctx context.Context, stateId types.BeaconStateId) (types.FinalityCheckpoints, error) {
+	var response finalityCheckpointsResponse
+	if err := c.get(ctx, fmt.Sprintf(finalityCheckpointsRoute, url.PathEscape(string(stateId))), nil, &response); err != nil {
+		return types.FinalityCheckpoints{}, fmt.Errorf("error getting finality checkpoints: %w", err)
+	}
+
+	var checkpoints types.FinalityCheckpoints
+	var err error
+	if checkpoints.PreviousJustified, err = parseCheckpoint(response.Data.PreviousJustified); err != nil {
+		return types.FinalityCheckpoints{}, fmt.Errorf("error decoding previous justified checkpoint: %w", err)
+	}
+	if checkpoints.CurrentJustified, err = parseCheckpoint(response.Data.CurrentJustified); err != nil {
+		return types.FinalityCheckpoints{}, fmt.Errorf("error decoding current justified checkpoint: %w", err)
+	}
+	if checkpoints.Finalized, err = parseCheckpoint(response.Data.Finalized); err != nil {
+		return types.FinalityCheckpoints{}, fmt.Errorf("error decoding finalized checkpoint: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// Make a GET request to the Beacon API and decode the response
+func (c *HttpClient) get(ctx context.Context, path string, query url.Values, response interface{}) error {
+	requestUrl := c.baseUrl + path
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	httpResponse, err := c.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response: %w", err)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		apiErr := &ApiError{StatusCode: httpResponse.StatusCode}
+		var errResponse struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &errResponse) == nil && errResponse.Message != "" {
+			apiErr.Message = errResponse.Message
+		} else {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return apiErr
+	}
+
+	if err := json.Unmarshal(body, response); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// Decode a checkpoint from the Beacon API
+func parseCheckpoint(response checkpointResponse) (types.BeaconCheckpoint, error) {
+	epoch, err := strconv.ParseUint(response.Epoch, 10, 64)
+	if err != nil {
+		return types.BeaconCheckpoint{}, err
+	}
+	return types.BeaconCheckpoint{
+		Epoch: epoch,
+		Root:  common.HexToHash(response.Root),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Error message fragments that indicate a problem with the node rather than the request
+var transientErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"too many requests",
+	"rate limit",
+	"timeout",
+	"timed out",
+	"header not found",
+	"service unavailable",
+	"bad gateway",
+}
+
+// Returns whether an error from an execution client is likely to go away if the request is repeated or sent to another client.
+// Reverts, invalid requests and context cancellation are never treated as transient.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// The caller gave up on the request
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// The connection dropped
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// The node responded with an HTTP error
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// Fall back to checking the message
+	message := strings.ToLower(err.Error())
+	for _, fragment := range transientErrorMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// An error from sending a transaction.
+// Transient is set when the failure was caused by the node rather than the transaction; in that case the transaction
+// may still have reached the network, so check for it before sending it again.
+type SendError struct {
+	Err       error
+	Transient bool
+}
+
+func (e *SendError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// Classify an error from sending a transaction
+func classifySendError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return err
+	}
+	return &SendError{
+		Err:       err,
+		Transient: IsTransientError(err),
+	}
+}
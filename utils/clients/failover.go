@@ -0,0 +1,93 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// An execution client that sends each request to a primary client, falling back to the other clients in order
+// whenever the current one fails with a transient error
+type FailoverClient struct {
+	clientWrapper
+	Clients []rocketpool.ExecutionClient
+
+	// Decides whether a failed request should be sent to the next client; defaults to IsTransientError
+	ShouldFailover func(err error) bool
+
+	// The index of the client that handled the last successful request, which transactions are sent to
+	current int
+	lock    sync.Mutex
+}
+
+// Create a new failover client from a primary client and any number of fallbacks
+func NewFailoverClient(primary rocketpool.ExecutionClient, fallbacks ...rocketpool.ExecutionClient) *FailoverClient {
+	client := &FailoverClient{
+		Clients:        append([]rocketpool.ExecutionClient{primary}, fallbacks...),
+		ShouldFailover: IsTransientError,
+	}
+	client.runner = client
+	return client
+}
+
+// Run a request against each client in order until one of them handles it
+func (c *FailoverClient) runRequest(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	if len(c.Clients) == 0 {
+		return errors.New("no execution clients are configured")
+	}
+
+	errs := []string{}
+	for i, client := range c.Clients {
+		err := request(client)
+		if err == nil {
+			c.setCurrent(i)
+			return nil
+		}
+		if !c.shouldFailover(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, fmt.Sprintf("client %d: %s", i, err.Error()))
+
+		// Preserve the last error for inspection with errors.Is / errors.As
+		if i == len(c.Clients)-1 {
+			return fmt.Errorf("all %d execution clients failed (%s): %w", len(c.Clients), strings.Join(errs, "; "), err)
+		}
+	}
+	return nil
+}
+
+// Check whether a failed request should be sent to the next client, treating a nil ShouldFailover as IsTransientError
+func (c *FailoverClient) shouldFailover(err error) bool {
+	if c.ShouldFailover == nil {
+		return IsTransientError(err)
+	}
+	return c.ShouldFailover(err)
+}
+
+// Run a request once against the client that handled the last successful request
+func (c *FailoverClient) runOnce(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	if len(c.Clients) == 0 {
+		return errors.New("no execution clients are configured")
+	}
+	c.lock.Lock()
+	current := c.current
+	c.lock.Unlock()
+	if current >= len(c.Clients) {
+		current = 0
+	}
+	return request(c.Clients[current])
+}
+
+// Record the client that handled the last successful request
+func (c *FailoverClient) setCurrent(index int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.current = index
+}
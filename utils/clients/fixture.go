@@ -0,0 +1,252 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// The current version of the fixture file format
+const FixtureVersion int = 1
+
+// Names of the requests that can be recorded
+const (
+	codeAtMethod         string = "CodeAt"
+	callContractMethod   string = "CallContract"
+	headerByNumberMethod string = "HeaderByNumber"
+	filterLogsMethod     string = "FilterLogs"
+	blockNumberMethod    string = "BlockNumber"
+	balanceAtMethod      string = "BalanceAt"
+	feeHistoryMethod     string = "FeeHistory"
+)
+
+// A set of recorded execution client requests and their responses
+type Fixture struct {
+	Version int            `json:"version"`
+	Entries []FixtureEntry `json:"entries"`
+}
+
+// A single recorded request and its response
+type FixtureEntry struct {
+	Method  string          `json:"method"`
+	Request json.RawMessage `json:"request"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *FixtureError   `json:"error,omitempty"`
+}
+
+// A recorded error response
+type FixtureError struct {
+	Message string      `json:"message"`
+	Code    int         `json:"code,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implementation for replayed errors, preserving the JSON-RPC code and data of the original
+type replayedError struct {
+	message string
+	code    int
+	data    interface{}
+}
+
+func (e *replayedError) Error() string          { return e.message }
+func (e *replayedError) ErrorCode() int         { return e.code }
+func (e *replayedError) ErrorData() interface{} { return e.data }
+
+// Request parameter types, used as the lookup keys for recorded responses
+type codeAtRequest struct {
+	Contract    common.Address `json:"contract"`
+	BlockNumber *big.Int       `json:"blockNumber"`
+}
+type callContractRequest struct {
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Gas         uint64          `json:"gas"`
+	GasPrice    *big.Int        `json:"gasPrice"`
+	GasFeeCap   *big.Int        `json:"gasFeeCap"`
+	GasTipCap   *big.Int        `json:"gasTipCap"`
+	Value       *big.Int        `json:"value"`
+	Data        hexutil.Bytes   `json:"data"`
+	BlockNumber *big.Int        `json:"blockNumber"`
+}
+type headerByNumberRequest struct {
+	Number *big.Int `json:"number"`
+}
+type filterLogsRequest struct {
+	BlockHash *common.Hash     `json:"blockHash"`
+	FromBlock *big.Int         `json:"fromBlock"`
+	ToBlock   *big.Int         `json:"toBlock"`
+	Addresses []common.Address `json:"addresses"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+type balanceAtRequest struct {
+	Account     common.Address `json:"account"`
+	BlockNumber *big.Int       `json:"blockNumber"`
+}
+type feeHistoryRequest struct {
+	BlockCount        uint64    `json:"blockCount"`
+	LastBlock         *big.Int  `json:"lastBlock"`
+	RewardPercentiles []float64 `json:"rewardPercentiles"`
+}
+
+// Create the request key for a contract call
+func newCallContractRequest(call ethereum.CallMsg, blockNumber *big.Int) callContractRequest {
+	return callContractRequest{
+		From:        call.From,
+		To:          call.To,
+		Gas:         call.Gas,
+		GasPrice:    call.GasPrice,
+		GasFeeCap:   call.GasFeeCap,
+		GasTipCap:   call.GasTipCap,
+		Value:       call.Value,
+		Data:        call.Data,
+		BlockNumber: blockNumber,
+	}
+}
+
+// Create the request key for a log filter
+func newFilterLogsRequest(query ethereum.FilterQuery) filterLogsRequest {
+	return filterLogsRequest{
+		BlockHash: query.BlockHash,
+		FromBlock: query.FromBlock,
+		ToBlock:   query.ToBlock,
+		Addresses: query.Addresses,
+		Topics:    query.Topics,
+	}
+}
+
+// Thread-safe storage for fixture entries, indexed by method and request
+type fixtureStore struct {
+	entries []FixtureEntry
+	index   map[string][]int
+	cursors map[string]int
+	lock    sync.Mutex
+}
+
+// Create a new, empty fixture store
+func newFixtureStore() *fixtureStore {
+	return &fixtureStore{
+		entries: []FixtureEntry{},
+		index:   map[string][]int{},
+		cursors: map[string]int{},
+	}
+}
+
+// Get the lookup key for a request, ignoring any formatting in its serialized form
+func getFixtureKey(method string, request json.RawMessage) string {
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, request); err != nil {
+		return method + ":" + string(request)
+	}
+	return method + ":" + buffer.String()
+}
+
+// Add an entry to the store
+func (s *fixtureStore) add(entry FixtureEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := getFixtureKey(entry.Method, entry.Request)
+	s.index[key] = append(s.index[key], len(s.entries))
+	s.entries = append(s.entries, entry)
+}
+
+// Get the next recorded response for a request.
+// Repeated requests get their responses in the order they were recorded, and the last response once those run out.
+func (s *fixtureStore) next(method string, request json.RawMessage) (FixtureEntry, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := getFixtureKey(method, request)
+	indices, exists := s.index[key]
+	if !exists {
+		return FixtureEntry{}, false
+	}
+	cursor := s.cursors[key]
+	if cursor < len(indices)-1 {
+		s.cursors[key] = cursor + 1
+	}
+	return s.entries[indices[cursor]], true
+}
+
+// Get a copy of the stored entries as a fixture
+func (s *fixtureStore) fixture() Fixture {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entries := make([]FixtureEntry, len(s.entries))
+	copy(entries, s.entries)
+	return Fixture{
+		Version: FixtureVersion,
+		Entries: entries,
+	}
+}
+
+// Create a fixture entry from a request and its response
+func newFixtureEntry(method string, request interface{}, result interface{}, err error) (FixtureEntry, error) {
+	requestBytes, marshalErr := json.Marshal(request)
+	if marshalErr != nil {
+		return FixtureEntry{}, fmt.Errorf("error serializing %s request: %w", method, marshalErr)
+	}
+	entry := FixtureEntry{
+		Method:  method,
+		Request: requestBytes,
+	}
+
+	// Record the error, including any JSON-RPC details
+	if err != nil {
+		entry.Error = &FixtureError{
+			Message: err.Error(),
+		}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) {
+			entry.Error.Code = rpcErr.ErrorCode()
+		}
+		var dataErr rpc.DataError
+		if errors.As(err, &dataErr) {
+			entry.Error.Data = dataErr.ErrorData()
+		}
+		return entry, nil
+	}
+
+	// Record the result
+	resultBytes, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return FixtureEntry{}, fmt.Errorf("error serializing %s result: %w", method, marshalErr)
+	}
+	entry.Result = resultBytes
+	return entry, nil
+}
+
+// Load a fixture from a file
+func LoadFixture(path string) (Fixture, error) {
+	fixtureBytes, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("error reading fixture file %s: %w", path, err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(fixtureBytes, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("error deserializing fixture file %s: %w", path, err)
+	}
+	if fixture.Version != FixtureVersion {
+		return Fixture{}, fmt.Errorf("fixture file %s has version %d but only version %d is supported", path, fixture.Version, FixtureVersion)
+	}
+	return fixture, nil
+}
+
+// Save a fixture to a file
+func SaveFixture(fixture Fixture, path string) error {
+	fixtureBytes, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing fixture: %w", err)
+	}
+	if err := os.WriteFile(path, fixtureBytes, 0644); err != nil {
+		return fmt.Errorf("error writing fixture file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// An execution client that limits the rate of requests to an inner client with a token bucket.
+// Requests block until a token is available or their context is done.
+type RateLimitedClient struct {
+	clientWrapper
+	Inner rocketpool.ExecutionClient
+
+	rate       float64 // Tokens added per second
+	burst      float64 // Bucket capacity
+	tokens     float64
+	lastRefill time.Time
+	lock       sync.Mutex
+}
+
+// Create a new rate-limited client that allows requestsPerSecond on average, with bursts of up to burst requests
+func NewRateLimitedClient(inner rocketpool.ExecutionClient, requestsPerSecond float64, burst int) *RateLimitedClient {
+	if burst < 1 {
+		burst = 1
+	}
+	client := &RateLimitedClient{
+		Inner:      inner,
+		rate:       requestsPerSecond,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+	client.runner = client
+	return client
+}
+
+// Wait for a token, then run the request against the inner client
+func (c *RateLimitedClient) runRequest(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	return request(c.Inner)
+}
+
+// Wait for a token, then run the request once against the inner client
+func (c *RateLimitedClient) runOnce(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	return c.runRequest(ctx, request)
+}
+
+// Block until a token can be taken from the bucket
+func (c *RateLimitedClient) wait(ctx context.Context) error {
+	for {
+		delay := c.reserve()
+		if delay == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Take a token if one is available, otherwise return how long until the next one will be
+func (c *RateLimitedClient) reserve() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Refill the bucket
+	now := time.Now()
+	c.tokens += now.Sub(c.lastRefill).Seconds() * c.rate
+	if c.tokens > c.burst {
+		c.tokens = c.burst
+	}
+	c.lastRefill = now
+
+	// Take a token
+	if c.tokens >= 1 {
+		c.tokens--
+		return 0
+	}
+	if c.rate <= 0 {
+		return time.Second
+	}
+	delay := time.Duration((1 - c.tokens) / c.rate * float64(time.Second))
+	if delay <= 0 {
+		delay = time.Millisecond
+	}
+	return delay
+}
@@ -0,0 +1,117 @@
+package clients
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// An execution client that passes every request to an inner client and records the read-only ones
+// (CodeAt, CallContract, HeaderByNumber, FilterLogs, BlockNumber, BalanceAt and FeeHistory) so they can be replayed later.
+// All other requests are passed through without being recorded.
+type RecordingClient struct {
+	rocketpool.ExecutionClient
+	store *fixtureStore
+
+	// The first error encountered while serializing a request or response, if any
+	recordErr error
+}
+
+// Create a new recording client around an inner client
+func NewRecordingClient(inner rocketpool.ExecutionClient) *RecordingClient {
+	return &RecordingClient{
+		ExecutionClient: inner,
+		store:           newFixtureStore(),
+	}
+}
+
+// Get everything recorded so far as a fixture
+func (c *RecordingClient) GetFixture() Fixture {
+	return c.store.fixture()
+}
+
+// Save everything recorded so far to a fixture file
+func (c *RecordingClient) Save(path string) error {
+	c.store.lock.Lock()
+	recordErr := c.recordErr
+	c.store.lock.Unlock()
+	if recordErr != nil {
+		return recordErr
+	}
+	return SaveFixture(c.GetFixture(), path)
+}
+
+// Record a request and its response
+func (c *RecordingClient) record(method string, request interface{}, result interface{}, err error) {
+	entry, recordErr := newFixtureEntry(method, request, result, err)
+	if recordErr != nil {
+		c.store.lock.Lock()
+		if c.recordErr == nil {
+			c.recordErr = recordErr
+		}
+		c.store.lock.Unlock()
+		return
+	}
+	c.store.add(entry)
+}
+
+func (c *RecordingClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	code, err := c.ExecutionClient.CodeAt(ctx, contract, blockNumber)
+	if ctx.Err() == nil {
+		c.record(codeAtMethod, codeAtRequest{Contract: contract, BlockNumber: blockNumber}, hexutil.Bytes(code), err)
+	}
+	return code, err
+}
+
+func (c *RecordingClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	response, err := c.ExecutionClient.CallContract(ctx, call, blockNumber)
+	if ctx.Err() == nil {
+		c.record(callContractMethod, newCallContractRequest(call, blockNumber), hexutil.Bytes(response), err)
+	}
+	return response, err
+}
+
+func (c *RecordingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := c.ExecutionClient.HeaderByNumber(ctx, number)
+	if ctx.Err() == nil {
+		c.record(headerByNumberMethod, headerByNumberRequest{Number: number}, header, err)
+	}
+	return header, err
+}
+
+func (c *RecordingClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := c.ExecutionClient.FilterLogs(ctx, query)
+	if ctx.Err() == nil {
+		c.record(filterLogsMethod, newFilterLogsRequest(query), logs, err)
+	}
+	return logs, err
+}
+
+func (c *RecordingClient) BlockNumber(ctx context.Context) (uint64, error) {
+	blockNumber, err := c.ExecutionClient.BlockNumber(ctx)
+	if ctx.Err() == nil {
+		c.record(blockNumberMethod, struct{}{}, blockNumber, err)
+	}
+	return blockNumber, err
+}
+
+func (c *RecordingClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	balance, err := c.ExecutionClient.BalanceAt(ctx, account, blockNumber)
+	if ctx.Err() == nil {
+		c.record(balanceAtMethod, balanceAtRequest{Account: account, BlockNumber: blockNumber}, balance, err)
+	}
+	return balance, err
+}
+
+func (c *RecordingClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	history, err := getFeeHistory(ctx, c.ExecutionClient, blockCount, lastBlock, rewardPercentiles)
+	if ctx.Err() == nil {
+		c.record(feeHistoryMethod, feeHistoryRequest{BlockCount: blockCount, LastBlock: lastBlock, RewardPercentiles: rewardPercentiles}, history, err)
+	}
+	return history, err
+}
@@ -0,0 +1,178 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Returned when a replay client receives a request that wasn't recorded in its fixture
+var ErrNotRecorded = errors.New("request was not recorded in the fixture")
+
+// Returned when a replay client receives a request that can't be recorded, such as sending a transaction
+var ErrNotReplayable = errors.New("request type cannot be replayed")
+
+// An execution client that serves responses from a recorded fixture without connecting to a node.
+// Identical requests get the same responses in the same order as when they were recorded.
+type ReplayClient struct {
+	store *fixtureStore
+}
+
+// Create a new replay client from a fixture
+func NewReplayClient(fixture Fixture) *ReplayClient {
+	store := newFixtureStore()
+	for _, entry := range fixture.Entries {
+		store.add(entry)
+	}
+	return &ReplayClient{
+		store: store,
+	}
+}
+
+// Create a new replay client from a fixture file
+func NewReplayClientFromFile(path string) (*ReplayClient, error) {
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayClient(fixture), nil
+}
+
+// Get the recorded response for a request and deserialize its result
+func (c *ReplayClient) replay(method string, request interface{}, result interface{}) error {
+	requestBytes, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("error serializing %s request: %w", method, err)
+	}
+	entry, exists := c.store.next(method, requestBytes)
+	if !exists {
+		return fmt.Errorf("error replaying %s request %s: %w", method, string(requestBytes), ErrNotRecorded)
+	}
+	if entry.Error != nil {
+		return &replayedError{
+			message: entry.Error.Message,
+			code:    entry.Error.Code,
+			data:    entry.Error.Data,
+		}
+	}
+	if err := json.Unmarshal(entry.Result, result); err != nil {
+		return fmt.Errorf("error deserializing recorded %s result: %w", method, err)
+	}
+	return nil
+}
+
+// Get the error for a request that can't be replayed
+func getNotReplayableError(method string) error {
+	return fmt.Errorf("error replaying %s request: %w", method, ErrNotReplayable)
+}
+
+func (c *ReplayClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code hexutil.Bytes
+	if err := c.replay(codeAtMethod, codeAtRequest{Contract: contract, BlockNumber: blockNumber}, &code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+func (c *ReplayClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var response hexutil.Bytes
+	if err := c.replay(callContractMethod, newCallContractRequest(call, blockNumber), &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *ReplayClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return nil, getNotReplayableError("HeaderByHash")
+}
+
+func (c *ReplayClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var header *types.Header
+	if err := c.replay(headerByNumberMethod, headerByNumberRequest{Number: number}, &header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *ReplayClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return nil, getNotReplayableError("PendingCodeAt")
+}
+
+func (c *ReplayClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 0, getNotReplayableError("PendingNonceAt")
+}
+
+func (c *ReplayClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return nil, getNotReplayableError("SuggestGasPrice")
+}
+
+func (c *ReplayClient) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return nil, getNotReplayableError("SuggestGasTipCap")
+}
+
+func (c *ReplayClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 0, getNotReplayableError("EstimateGas")
+}
+
+func (c *ReplayClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return getNotReplayableError("SendTransaction")
+}
+
+func (c *ReplayClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	if err := c.replay(filterLogsMethod, newFilterLogsRequest(query), &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (c *ReplayClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, getNotReplayableError("SubscribeFilterLogs")
+}
+
+func (c *ReplayClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, getNotReplayableError("TransactionReceipt")
+}
+
+func (c *ReplayClient) BlockNumber(ctx context.Context) (uint64, error) {
+	var blockNumber uint64
+	if err := c.replay(blockNumberMethod, struct{}{}, &blockNumber); err != nil {
+		return 0, err
+	}
+	return blockNumber, nil
+}
+
+func (c *ReplayClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	if err := c.replay(balanceAtMethod, balanceAtRequest{Account: account, BlockNumber: blockNumber}, &balance); err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (c *ReplayClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return nil, false, getNotReplayableError("TransactionByHash")
+}
+
+func (c *ReplayClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return 0, getNotReplayableError("NonceAt")
+}
+
+func (c *ReplayClient) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, getNotReplayableError("SyncProgress")
+}
+
+func (c *ReplayClient) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	var history *ethereum.FeeHistory
+	if err := c.replay(feeHistoryMethod, feeHistoryRequest{BlockCount: blockCount, LastBlock: lastBlock, RewardPercentiles: rewardPercentiles}, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,98 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// Default retry settings
+const (
+	DefaultMaxAttempts       int           = 5
+	DefaultInitialBackoff    time.Duration = 250 * time.Millisecond
+	DefaultMaxBackoff        time.Duration = 10 * time.Second
+	DefaultBackoffMultiplier float64       = 2
+)
+
+// Settings for retrying failed requests
+type RetrySettings struct {
+	// The total number of times a request is attempted, including the first
+	MaxAttempts int
+
+	// The delay before the first retry
+	InitialBackoff time.Duration
+
+	// The upper bound for the delay between any two attempts
+	MaxBackoff time.Duration
+
+	// The factor the delay grows by after each retry
+	BackoffMultiplier float64
+
+	// Decides whether a failed request should be retried; defaults to IsTransientError
+	IsRetryable func(err error) bool
+}
+
+// An execution client that retries requests which fail with transient errors, backing off between attempts
+type RetryClient struct {
+	clientWrapper
+	Inner    rocketpool.ExecutionClient
+	Settings RetrySettings
+}
+
+// Create a new retrying client around an inner client; zero-valued settings are replaced with their defaults
+func NewRetryClient(inner rocketpool.ExecutionClient, settings RetrySettings) *RetryClient {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = DefaultMaxAttempts
+	}
+	if settings.InitialBackoff <= 0 {
+		settings.InitialBackoff = DefaultInitialBackoff
+	}
+	if settings.MaxBackoff <= 0 {
+		settings.MaxBackoff = DefaultMaxBackoff
+	}
+	if settings.BackoffMultiplier < 1 {
+		settings.BackoffMultiplier = DefaultBackoffMultiplier
+	}
+	if settings.IsRetryable == nil {
+		settings.IsRetryable = IsTransientError
+	}
+
+	client := &RetryClient{
+		Inner:    inner,
+		Settings: settings,
+	}
+	client.runner = client
+	return client
+}
+
+// Run a request, retrying it until it succeeds, fails with a non-retryable error, or runs out of attempts
+func (c *RetryClient) runRequest(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	backoff := c.Settings.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := request(c.Inner)
+		if err == nil || !c.Settings.IsRetryable(err) {
+			return err
+		}
+		if attempt >= c.Settings.MaxAttempts {
+			return fmt.Errorf("request failed after %d attempts: %w", attempt, err)
+		}
+
+		// Wait before trying again
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("request cancelled after %d attempts (last error: %s): %w", attempt, err.Error(), ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = time.Duration(float64(backoff) * c.Settings.BackoffMultiplier)
+		if backoff > c.Settings.MaxBackoff {
+			backoff = c.Settings.MaxBackoff
+		}
+	}
+}
+
+// Run a request once against the inner client
+func (c *RetryClient) runOnce(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error {
+	return request(c.Inner)
+}
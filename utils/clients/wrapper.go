@@ -0,0 +1,182 @@
+package clients
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Make sure every wrapper satisfies the execution client interface
+var (
+	_ rocketpool.ExecutionClient = (*RetryClient)(nil)
+	_ rocketpool.ExecutionClient = (*FailoverClient)(nil)
+	_ rocketpool.ExecutionClient = (*RateLimitedClient)(nil)
+	_ rocketpool.ExecutionClient = (*RecordingClient)(nil)
+	_ rocketpool.ExecutionClient = (*ReplayClient)(nil)
+
+	_ rocketpool.FeeHistoryReader = (*RetryClient)(nil)
+	_ rocketpool.FeeHistoryReader = (*FailoverClient)(nil)
+	_ rocketpool.FeeHistoryReader = (*RateLimitedClient)(nil)
+	_ rocketpool.FeeHistoryReader = (*RecordingClient)(nil)
+	_ rocketpool.FeeHistoryReader = (*ReplayClient)(nil)
+)
+
+// Runs a single request against one or more inner clients
+type requestRunner interface {
+	runRequest(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error
+
+	// Run a request exactly once against the current client, without retries or failover
+	runOnce(ctx context.Context, request func(client rocketpool.ExecutionClient) error) error
+}
+
+// Implements ExecutionClient by routing every request through a runner
+type clientWrapper struct {
+	runner requestRunner
+}
+
+// Run a request that returns a value through the wrapper's runner
+func runWithResult[T any](ctx context.Context, runner requestRunner, request func(client rocketpool.ExecutionClient) (T, error)) (T, error) {
+	var result T
+	err := runner.runRequest(ctx, func(client rocketpool.ExecutionClient) error {
+		var err error
+		result, err = request(client)
+		return err
+	})
+	return result, err
+}
+
+func (w *clientWrapper) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) ([]byte, error) {
+		return client.CodeAt(ctx, contract, blockNumber)
+	})
+}
+
+func (w *clientWrapper) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) ([]byte, error) {
+		return client.CallContract(ctx, call, blockNumber)
+	})
+}
+
+func (w *clientWrapper) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*types.Header, error) {
+		return client.HeaderByHash(ctx, hash)
+	})
+}
+
+func (w *clientWrapper) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*types.Header, error) {
+		return client.HeaderByNumber(ctx, number)
+	})
+}
+
+func (w *clientWrapper) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) ([]byte, error) {
+		return client.PendingCodeAt(ctx, account)
+	})
+}
+
+func (w *clientWrapper) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (uint64, error) {
+		return client.PendingNonceAt(ctx, account)
+	})
+}
+
+func (w *clientWrapper) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*big.Int, error) {
+		return client.SuggestGasPrice(ctx)
+	})
+}
+
+func (w *clientWrapper) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*big.Int, error) {
+		return client.SuggestGasTipCap(ctx)
+	})
+}
+
+func (w *clientWrapper) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (uint64, error) {
+		return client.EstimateGas(ctx, call)
+	})
+}
+
+// Transactions are only sent once, since a failed send may still have reached the network.
+// Replacing or resending them is left to the caller.
+func (w *clientWrapper) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	err := w.runner.runOnce(ctx, func(client rocketpool.ExecutionClient) error {
+		return client.SendTransaction(ctx, tx)
+	})
+	return classifySendError(err)
+}
+
+// Fee history requests are forwarded to inner clients that support them
+func (w *clientWrapper) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*ethereum.FeeHistory, error) {
+		return getFeeHistory(ctx, client, blockCount, lastBlock, rewardPercentiles)
+	})
+}
+
+// Get the fee history from a client, if it supports it
+func getFeeHistory(ctx context.Context, client rocketpool.ExecutionClient, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	reader, ok := client.(rocketpool.FeeHistoryReader)
+	if !ok {
+		return nil, rocketpool.ErrFeeHistoryNotSupported
+	}
+	return reader.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+}
+
+func (w *clientWrapper) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) ([]types.Log, error) {
+		return client.FilterLogs(ctx, query)
+	})
+}
+
+func (w *clientWrapper) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (ethereum.Subscription, error) {
+		return client.SubscribeFilterLogs(ctx, query, ch)
+	})
+}
+
+func (w *clientWrapper) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*types.Receipt, error) {
+		return client.TransactionReceipt(ctx, txHash)
+	})
+}
+
+func (w *clientWrapper) BlockNumber(ctx context.Context) (uint64, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+}
+
+func (w *clientWrapper) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*big.Int, error) {
+		return client.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+func (w *clientWrapper) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var isPending bool
+	tx, err := runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*types.Transaction, error) {
+		var tx *types.Transaction
+		var err error
+		tx, isPending, err = client.TransactionByHash(ctx, hash)
+		return tx, err
+	})
+	return tx, isPending, err
+}
+
+func (w *clientWrapper) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (uint64, error) {
+		return client.NonceAt(ctx, account, blockNumber)
+	})
+}
+
+func (w *clientWrapper) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return runWithResult(ctx, w.runner, func(client rocketpool.ExecutionClient) (*ethereum.SyncProgress, error) {
+		return client.SyncProgress(ctx)
+	})
+}
@@ -51,7 +51,7 @@ func GetDeposits(rp *rocketpool.RocketPool, pubkeys map[rptypes.ValidatorPubkey]
 	// Get the deposit events
 	addressFilter := []common.Address{*casperDeposit.Address}
 	topicFilter := [][]common.Hash{{casperDeposit.ABI.Events["DepositEvent"].ID}}
-	logs, err := eth.GetLogs(rp, addressFilter, topicFilter, intervalSize, startBlock, nil, nil)
+	logs, err := eth.GetLogsContext(rocketpool.GetCallContext(opts), rp, addressFilter, topicFilter, intervalSize, startBlock, nil, nil)
 	if err != nil {
 		return nil, err
 	}
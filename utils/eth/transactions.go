@@ -13,6 +13,11 @@ import (
 
 // Estimate the gas of SendTransaction
 func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	return EstimateSendTransactionGasContext(rocketpool.GetTransactContext(opts), client, toAddress, opts)
+}
+
+// Estimate the gas of SendTransaction, using the provided context for the network call
+func EstimateSendTransactionGasContext(ctx context.Context, client rocketpool.ExecutionClient, toAddress common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
 
 	// User-defined settings
 	response := rocketpool.GasInfo{}
@@ -24,14 +29,14 @@ func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress com
 	}
 
 	// Estimate gas limit
-	gasLimit, err := client.EstimateGas(context.Background(), ethereum.CallMsg{
+	gasLimit, err := client.EstimateGas(ctx, ethereum.CallMsg{
 		From:     opts.From,
 		To:       &toAddress,
 		GasPrice: big.NewInt(0), // set to 0 for simulation
 		Value:    value,
 	})
 	if err != nil {
-		return rocketpool.GasInfo{}, err
+		return rocketpool.GasInfo{}, rocketpool.DecodeRevertError(err, "", "", nil)
 	}
 	response.EstGasLimit = gasLimit
 	response.SafeGasLimit = gasLimit
@@ -41,12 +46,17 @@ func EstimateSendTransactionGas(client rocketpool.ExecutionClient, toAddress com
 
 // Send a transaction to an address
 func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address, chainID *big.Int, opts *bind.TransactOpts) (common.Hash, error) {
+	return SendTransactionContext(rocketpool.GetTransactContext(opts), client, toAddress, chainID, opts)
+}
+
+// Send a transaction to an address, using the provided context for network calls
+func SendTransactionContext(ctx context.Context, client rocketpool.ExecutionClient, toAddress common.Address, chainID *big.Int, opts *bind.TransactOpts) (common.Hash, error) {
 	var err error
 
 	// Get from address nonce
 	var nonce uint64
 	if opts.Nonce == nil {
-		nonce, err = client.PendingNonceAt(context.Background(), opts.From)
+		nonce, err = client.PendingNonceAt(ctx, opts.From)
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -63,14 +73,14 @@ func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address
 	// Estimate gas limit
 	gasLimit := opts.GasLimit
 	if gasLimit == 0 {
-		gasLimit, err = client.EstimateGas(context.Background(), ethereum.CallMsg{
+		gasLimit, err = client.EstimateGas(ctx, ethereum.CallMsg{
 			From:     opts.From,
 			To:       &toAddress,
 			GasPrice: big.NewInt(0), // use 0 gwei for simulation
 			Value:    value,
 		})
 		if err != nil {
-			return common.Hash{}, err
+			return common.Hash{}, rocketpool.DecodeRevertError(err, "", "", nil)
 		}
 	}
 
@@ -94,7 +104,7 @@ func SendTransaction(client rocketpool.ExecutionClient, toAddress common.Address
 	}
 
 	// Send transaction
-	if err = client.SendTransaction(context.Background(), signedTx); err != nil {
+	if err = client.SendTransaction(ctx, signedTx); err != nil {
 		return common.Hash{}, err
 	}
 
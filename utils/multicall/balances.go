@@ -1,7 +1,6 @@
 package multicall
 
 import (
-	"context"
 	"fmt"
 	"math/big"
 	"strings"
@@ -38,33 +37,58 @@ func NewBalanceBatcher(client rocketpool.ExecutionClient, address common.Address
 	}, nil
 }
 
+// Get the ETH balances of addresses
 func (b *BalanceBatcher) GetEthBalances(addresses []common.Address, opts *bind.CallOpts) ([]*big.Int, error) {
+	tokenBalances, err := b.GetTokenBalances(addresses, []common.Address{{}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(addresses))
+	for i := range tokenBalances {
+		balances[i] = tokenBalances[i][0]
+	}
+	return balances, nil
+}
+
+// Get the balances of tokens held by addresses, indexed by address and then token; the empty token address gets the ETH balance
+func (b *BalanceBatcher) GetTokenBalances(addresses []common.Address, tokens []common.Address, opts *bind.CallOpts) ([][]*big.Int, error) {
 
 	// Sync
 	count := len(addresses)
 	var wg errgroup.Group
 	wg.SetLimit(threadLimit)
-	balances := make([]*big.Int, count)
+	balances := make([][]*big.Int, count)
+	if len(tokens) == 0 {
+		for i := range balances {
+			balances[i] = []*big.Int{}
+		}
+		return balances, nil
+	}
+	var blockNumber *big.Int
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+	}
 
-	// Run the getters in batches
-	for i := 0; i < count; i += balanceBatchSize {
+	// Run the getters in batches, keeping the number of balances in each one within the batch size
+	batchSize := balanceBatchSize / len(tokens)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for i := 0; i < count; i += batchSize {
 		i := i
-		max := i + balanceBatchSize
+		max := i + batchSize
 		if max > count {
 			max = count
 		}
 
 		wg.Go(func() error {
 			subAddresses := addresses[i:max]
-			tokens := []common.Address{
-				{}, // Empty token for ETH balance
-			}
 			callData, err := b.ABI.Pack("balances", subAddresses, tokens)
 			if err != nil {
 				return fmt.Errorf("error creating calldata for balances: %w", err)
 			}
 
-			response, err := b.Client.CallContract(context.Background(), ethereum.CallMsg{To: &b.ContractAddress, Data: callData}, opts.BlockNumber)
+			response, err := b.Client.CallContract(rocketpool.GetCallContext(opts), ethereum.CallMsg{To: &b.ContractAddress, Data: callData}, blockNumber)
 			if err != nil {
 				return fmt.Errorf("error calling balances: %w", err)
 			}
@@ -75,14 +99,19 @@ func (b *BalanceBatcher) GetEthBalances(addresses []common.Address, opts *bind.C
 				return fmt.Errorf("error unpacking balances response: %w", err)
 			}
 
-			if len(subBalances) != len(subAddresses) {
-				return fmt.Errorf("received %d balances which mismatches query batch size %d", len(subBalances), len(subAddresses))
+			if len(subBalances) != len(subAddresses)*len(tokens) {
+				return fmt.Errorf("received %d balances which mismatches query size %d", len(subBalances), len(subAddresses)*len(tokens))
 			}
-			for j, balance := range subBalances {
-				if balance == nil {
-					return fmt.Errorf("received nil balance for address %s", subAddresses[j].String())
+			for j, address := range subAddresses {
+				addressBalances := make([]*big.Int, len(tokens))
+				for k, token := range tokens {
+					balance := subBalances[j*len(tokens)+k]
+					if balance == nil {
+						return fmt.Errorf("received nil balance of token %s for address %s", token.Hex(), address.Hex())
+					}
+					addressBalances[k] = balance
 				}
-				balances[i+j] = balance
+				balances[i+j] = addressBalances
 			}
 
 			return nil
@@ -0,0 +1,214 @@
+package multicall
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"golang.org/x/sync/errgroup"
+)
+
+// Default batch settings
+const (
+	DefaultMaxBatchCalls          int    = 1000
+	DefaultMaxBatchReturnDataSize int    = 512 * 1024
+	DefaultMaxBatchGas            uint64 = 40000000 // Below the 50M gas cap most clients put on eth_call
+	DefaultBatchThreadLimit       int    = 6
+)
+
+// Estimates used to size batches
+const (
+	estimatedCallGas        uint64 = 30000 // A cold account access, a few cold storage reads and the call overhead
+	estimatedReturnWordGas  uint64 = 50    // Copying a word of return data into the results and the memory it takes up
+	estimatedDynamicSize    int    = 128   // The size of the contents of a dynamic return value, such as a string
+	aggregateResultOverhead int    = 96    // The success flag, offset and length of each result in the aggregate's return data
+)
+
+// Messages from execution clients and RPC providers when a batch is too big to execute
+var batchLimitMessages = []string{
+	"out of gas",
+	"gas required exceeds",
+	"exceeds block gas limit",
+	"gas limit reached",
+	"response size",
+	"response is too big",
+	"response too large",
+	"request entity too large",
+	"read limit exceeded",
+	"message too large",
+}
+
+// Settings for splitting calls into batches; zero values are replaced with the defaults
+type BatchSettings struct {
+	MaxCalls          int    // The most calls in one batch
+	MaxReturnDataSize int    // The most estimated return data in one batch, in bytes
+	MaxGas            uint64 // The most estimated gas used by one batch
+	ThreadLimit       int    // The most batches executed at once
+}
+
+// Limits learned from batches that were too big for the node
+type batchLimits struct {
+	maxCalls int64 // The most calls in a batch the node has handled since a batch was too big, or 0 if none have been too big
+}
+
+// Get the most calls allowed in a batch
+func (l *batchLimits) getMaxCalls(configured int) int {
+	learned := int(atomic.LoadInt64(&l.maxCalls))
+	if learned > 0 && learned < configured {
+		return learned
+	}
+	return configured
+}
+
+// Record that a batch was too big for the node
+func (l *batchLimits) setTooBig(calls int) {
+	limit := int64(calls / 2)
+	if limit < 1 {
+		limit = 1
+	}
+	for {
+		current := atomic.LoadInt64(&l.maxCalls)
+		if current > 0 && current <= limit {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&l.maxCalls, current, limit) {
+			return
+		}
+	}
+}
+
+// Get the batch settings with defaults for the unset values
+func (caller *MultiCaller) getBatchSettings() BatchSettings {
+	settings := caller.BatchSettings
+	if settings.MaxCalls <= 0 {
+		settings.MaxCalls = DefaultMaxBatchCalls
+	}
+	if settings.MaxReturnDataSize <= 0 {
+		settings.MaxReturnDataSize = DefaultMaxBatchReturnDataSize
+	}
+	if settings.MaxGas == 0 {
+		settings.MaxGas = DefaultMaxBatchGas
+	}
+	if settings.ThreadLimit <= 0 {
+		settings.ThreadLimit = DefaultBatchThreadLimit
+	}
+	settings.MaxCalls = caller.limits.getMaxCalls(settings.MaxCalls)
+	return settings
+}
+
+// Split the calls into batches and execute them concurrently
+func (caller *MultiCaller) executeBatches(allowFailure []bool, opts *bind.CallOpts) ([]CallResponse, error) {
+	results := make([]CallResponse, len(caller.calls))
+	settings := caller.getBatchSettings()
+
+	var wg errgroup.Group
+	wg.SetLimit(settings.ThreadLimit)
+	for _, batch := range splitBatches(caller.calls, settings) {
+		start, end := batch[0], batch[1]
+		wg.Go(func() error {
+			batchResults, err := caller.executeAdaptively(caller.calls[start:end], allowFailure[start:end], opts)
+			if err != nil {
+				return fmt.Errorf("error executing calls %d to %d: %w", start, end-1, err)
+			}
+			copy(results[start:end], batchResults)
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Execute a batch, splitting it in half and trying again if the node says it's too big
+func (caller *MultiCaller) executeAdaptively(calls []Call, allowFailure []bool, opts *bind.CallOpts) ([]CallResponse, error) {
+	results, err := caller.executeBatch(calls, allowFailure, opts)
+	if err == nil || len(calls) < 2 || !isBatchLimitError(err) {
+		return results, err
+	}
+
+	// Remember the limit for later batches
+	caller.limits.setTooBig(len(calls))
+
+	// Execute each half
+	middle := len(calls) / 2
+	firstResults, err := caller.executeAdaptively(calls[:middle], allowFailure[:middle], opts)
+	if err != nil {
+		return nil, err
+	}
+	secondResults, err := caller.executeAdaptively(calls[middle:], allowFailure[middle:], opts)
+	if err != nil {
+		return nil, err
+	}
+	return append(firstResults, secondResults...), nil
+}
+
+// Split calls into batches within the limits, returning the start and end index of each batch
+func splitBatches(calls []Call, settings BatchSettings) [][2]int {
+	batches := [][2]int{}
+	start := 0
+	returnSize := 0
+	gas := uint64(0)
+	for i, call := range calls {
+		callReturnSize := call.returnSize + aggregateResultOverhead
+		callGas := estimatedCallGas + uint64(callReturnSize/32)*estimatedReturnWordGas
+		if i > start && (i-start >= settings.MaxCalls || returnSize+callReturnSize > settings.MaxReturnDataSize || gas+callGas > settings.MaxGas) {
+			batches = append(batches, [2]int{start, i})
+			start = i
+			returnSize = 0
+			gas = 0
+		}
+		returnSize += callReturnSize
+		gas += callGas
+	}
+	if start < len(calls) {
+		batches = append(batches, [2]int{start, len(calls)})
+	}
+	return batches
+}
+
+// Estimate the size of a method's return data
+func estimateReturnSize(contractAbi *abi.ABI, method string) int {
+	abiMethod, exists := contractAbi.Methods[method]
+	if !exists {
+		return 0
+	}
+	size := 0
+	for _, output := range abiMethod.Outputs {
+		size += estimateTypeSize(output.Type)
+	}
+	return size
+}
+
+// Estimate the encoded size of an ABI type
+func estimateTypeSize(abiType abi.Type) int {
+	switch abiType.T {
+	case abi.StringTy, abi.BytesTy:
+		return 64 + estimatedDynamicSize
+	case abi.SliceTy:
+		return 64 + estimatedDynamicSize/32*estimateTypeSize(*abiType.Elem)
+	case abi.ArrayTy:
+		return abiType.Size * estimateTypeSize(*abiType.Elem)
+	case abi.TupleTy:
+		size := 0
+		for _, elem := range abiType.TupleElems {
+			size += estimateTypeSize(*elem)
+		}
+		return size
+	default:
+		return 32
+	}
+}
+
+// Check whether an error means a batch was too big for the node to execute or return
+func isBatchLimitError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, limitMessage := range batchLimitMessages {
+		if strings.Contains(message, limitMessage) {
+			return true
+		}
+	}
+	return false
+}
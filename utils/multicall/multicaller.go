@@ -5,8 +5,11 @@
 package multicall
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/RedDuck-Software/poolsea-go/rocketpool"
@@ -16,38 +19,120 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// The version of the multicall contract a MultiCaller uses
+type MulticallVersion int
+
+const (
+	Multicall2 MulticallVersion = 2 // Batches calls with tryAggregate
+	Multicall3 MulticallVersion = 3 // Batches calls with aggregate3, which takes the failure policy of each call
+)
+
+// The name used for the multicall contract in errors
+const multicallContractName string = "multicall"
+
 type Call struct {
-	Method   string         `json:"method"`
-	Target   common.Address `json:"target"`
-	CallData []byte         `json:"call_data"`
-	Contract *rocketpool.Contract
-	output   interface{}
+	Method       string         `json:"method"`
+	Target       common.Address `json:"target"`
+	CallData     []byte         `json:"call_data"`
+	AllowFailure bool           `json:"allow_failure"` // Whether the batch can succeed if this call fails
+	Site         string         `json:"site"`          // The file and line the call was added from
+	Contract     *rocketpool.Contract
+	output       interface{}
+	returnSize   int         // The estimated size of the call's return data
+	status       *callStatus // Where the outcome is recorded for typed queries, if set
 }
 
 type CallResponse struct {
 	Method        string
 	Status        bool
 	ReturnDataRaw []byte `json:"returnData"`
+	Error         error  // A *CallError describing the failure if the call failed
 }
 
 type Result struct {
 	Success bool `json:"success"`
 	Output  interface{}
+	Error   error // A *CallError describing the failure if the call failed or its output couldn't be decoded
+}
+
+// A call in a batch that failed or whose output couldn't be decoded
+type CallError struct {
+	Index        int    // The index of the call in the batch
+	Site         string // The file and line the call was added from
+	ContractName string
+	Method       string
+	Target       common.Address
+	Err          error // A *rocketpool.RevertError if the call reverted
+}
+
+func (e *CallError) Error() string {
+	contractName := e.ContractName
+	if contractName == "" {
+		contractName = e.Target.Hex()
+	}
+	return fmt.Sprintf("call %d to %s.%s (added at %s) failed: %s", e.Index, contractName, e.Method, e.Site, e.Err.Error())
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// The calls in a batch that were required to succeed but didn't
+type BatchError struct {
+	Errors []*CallError
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d required call(s) failed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwraps to the first failed call
+func (e *BatchError) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[0]
 }
 
 func (call Call) GetMultiCall() MultiCall {
 	return MultiCall{Target: call.Target, CallData: call.CallData}
 }
 
+func (call Call) GetMultiCall3(allowFailure bool) MultiCall3 {
+	return MultiCall3{Target: call.Target, AllowFailure: allowFailure, CallData: call.CallData}
+}
+
+// A multicaller accepts any number of calls, splitting them into batches that are executed concurrently.
+// It isn't safe to add calls from multiple goroutines; use NewCaller to get a separate multicaller for each.
 type MultiCaller struct {
 	Client          rocketpool.ExecutionClient
 	ABI             abi.ABI
 	ContractAddress common.Address
+	Version         MulticallVersion
+	BatchSettings   BatchSettings
+	contract        *rocketpool.Contract
+	limits          *batchLimits
 	calls           []Call
+	err             error // The first error adding a call since the calls were last executed
 }
 
+// Create a multicaller for a Multicall2 contract
 func NewMultiCaller(client rocketpool.ExecutionClient, multicallerAddress common.Address) (*MultiCaller, error) {
-	mcAbi, err := abi.JSON(strings.NewReader(MulticallABI))
+	return newMultiCaller(client, multicallerAddress, Multicall2, MulticallABI)
+}
+
+// Create a multicaller for a Multicall3 contract
+func NewMultiCaller3(client rocketpool.ExecutionClient, multicallerAddress common.Address) (*MultiCaller, error) {
+	return newMultiCaller(client, multicallerAddress, Multicall3, Multicall3ABI)
+}
+
+// Create a multicaller for a multicall contract version
+func newMultiCaller(client rocketpool.ExecutionClient, multicallerAddress common.Address, version MulticallVersion, abiString string) (*MultiCaller, error) {
+	mcAbi, err := abi.JSON(strings.NewReader(abiString))
 	if err != nil {
 		return nil, err
 	}
@@ -56,78 +141,284 @@ func NewMultiCaller(client rocketpool.ExecutionClient, multicallerAddress common
 		Client:          client,
 		ABI:             mcAbi,
 		ContractAddress: multicallerAddress,
-		calls:           []Call{},
+		Version:         version,
+		contract: &rocketpool.Contract{
+			Address: &multicallerAddress,
+			ABI:     &mcAbi,
+			Client:  client,
+			Name:    multicallContractName,
+		},
+		limits: &batchLimits{},
+		calls:  []Call{},
 	}, nil
 }
 
+// Create a multicaller with no calls that uses the same multicall contract and batch settings, and shares the batch limits learned from the node
+func (caller *MultiCaller) NewCaller() *MultiCaller {
+	return &MultiCaller{
+		Client:          caller.Client,
+		ABI:             caller.ABI,
+		ContractAddress: caller.ContractAddress,
+		Version:         caller.Version,
+		BatchSettings:   caller.BatchSettings,
+		contract:        caller.contract,
+		limits:          caller.limits,
+		calls:           []Call{},
+	}
+}
+
+// Add a call that must succeed for the batch to succeed.
+// If the call can't be added, the error is also returned by the next execution, which won't make any calls.
 func (caller *MultiCaller) AddCall(contract *rocketpool.Contract, output interface{}, method string, args ...interface{}) error {
+	return caller.addCall(getCallSite(2), false, nil, contract, output, method, args...)
+}
+
+// Add a call that is allowed to fail without failing the batch; check its Result for the failure
+func (caller *MultiCaller) AddOptionalCall(contract *rocketpool.Contract, output interface{}, method string, args ...interface{}) error {
+	return caller.addCall(getCallSite(2), true, nil, contract, output, method, args...)
+}
+
+// Add a call for the block number the batch is executed at
+func (caller *MultiCaller) AddBlockNumberCall(output **big.Int) error {
+	return caller.addCall(getCallSite(2), false, nil, caller.contract, output, "getBlockNumber")
+}
+
+// Add a call for the ETH balance of an address
+func (caller *MultiCaller) AddEthBalanceCall(address common.Address, output **big.Int) error {
+	return caller.addCall(getCallSite(2), false, nil, caller.contract, output, "getEthBalance", address)
+}
+
+// Get the first error adding a call since the calls were last executed
+func (caller *MultiCaller) Err() error {
+	return caller.err
+}
+
+// Add a call with a failure policy, recording the first error
+func (caller *MultiCaller) addCall(site string, allowFailure bool, status *callStatus, contract *rocketpool.Contract, output interface{}, method string, args ...interface{}) error {
 	callData, err := contract.ABI.Pack(method, args...)
 	if err != nil {
-		return fmt.Errorf("error adding call [%s]: %w", method, err)
+		err = fmt.Errorf("error adding call [%s] at %s: %w", method, site, err)
+		if caller.err == nil {
+			caller.err = err
+		}
+		return err
 	}
 	call := Call{
-		Method:   method,
-		Target:   *contract.Address,
-		CallData: callData,
-		Contract: contract,
-		output:   output,
+		Method:       method,
+		Target:       *contract.Address,
+		CallData:     callData,
+		AllowFailure: allowFailure,
+		Site:         site,
+		Contract:     contract,
+		output:       output,
+		returnSize:   estimateReturnSize(contract.ABI, method),
+		status:       status,
 	}
 	caller.calls = append(caller.calls, call)
 	return nil
 }
 
+// Execute the calls. If requireSuccess is false, every call is allowed to fail; otherwise each call's own failure policy applies.
+// If any required calls fail, the responses are returned along with a *BatchError describing them.
+// If a call couldn't be added, nothing is executed and the calls are cleared along with the error.
 func (caller *MultiCaller) Execute(requireSuccess bool, opts *bind.CallOpts) ([]CallResponse, error) {
-	var multiCalls = make([]MultiCall, 0, len(caller.calls))
-	for _, call := range caller.calls {
-		multiCalls = append(multiCalls, call.GetMultiCall())
+	if caller.err != nil {
+		err := caller.err
+		for _, call := range caller.calls {
+			call.status.set(err)
+		}
+		caller.reset()
+		return nil, err
 	}
-	callData, err := caller.ABI.Pack("tryAggregate", requireSuccess, multiCalls)
+	allowFailure := make([]bool, len(caller.calls))
+	for i, call := range caller.calls {
+		allowFailure[i] = call.AllowFailure || !requireSuccess
+	}
+
+	results, err := caller.executeBatches(allowFailure, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := caller.Client.CallContract(context.Background(), ethereum.CallMsg{To: &caller.ContractAddress, Data: callData}, opts.BlockNumber)
+	// Report the failed calls
+	batchErr := &BatchError{}
+	for i, call := range caller.calls {
+		if results[i].Status {
+			continue
+		}
+		revertErr := rocketpool.NewRevertError(results[i].ReturnDataRaw, call.Contract.Name, call.Method, call.Contract.ABI)
+		callErr := newCallError(i, call, revertErr)
+		results[i].Error = callErr
+		if !allowFailure[i] {
+			batchErr.Errors = append(batchErr.Errors, callErr)
+		}
+	}
+	if len(batchErr.Errors) > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
+
+// Execute the calls and unpack their outputs. If requireSuccess is false, every call is allowed to fail; otherwise each call's own failure policy applies.
+// The outputs of failed calls are left untouched and their results have an Error set.
+// If any required calls fail or can't be decoded, the results are returned along with a *BatchError describing them.
+func (caller *MultiCaller) FlexibleCall(requireSuccess bool, opts *bind.CallOpts) ([]Result, error) {
+	defer caller.reset()
+
+	results, err := caller.Execute(requireSuccess, opts)
+	var batchErr *BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		for _, call := range caller.calls {
+			call.status.set(err)
+		}
+		return nil, err
+	}
+
+	res := make([]Result, len(caller.calls))
+	batchErr = &BatchError{}
+	for i, call := range caller.calls {
+		res[i].Success = results[i].Status
+		res[i].Output = call.output
+		res[i].Error = results[i].Error
+		if res[i].Success {
+			err := call.Contract.ABI.UnpackIntoInterface(call.output, call.Method, results[i].ReturnDataRaw)
+			if err != nil {
+				res[i].Success = false
+				res[i].Error = newCallError(i, call, fmt.Errorf("error unpacking output: %w", err))
+			}
+		}
+		if !res[i].Success && !call.AllowFailure && requireSuccess {
+			batchErr.Errors = append(batchErr.Errors, res[i].Error.(*CallError))
+		}
+		call.status.set(res[i].Error)
+	}
+	if len(batchErr.Errors) > 0 {
+		return res, batchErr
+	}
+	return res, nil
+}
+
+// Clear the calls and the error adding them
+func (caller *MultiCaller) reset() {
+	caller.calls = []Call{}
+	caller.err = nil
+}
+
+// Execute a batch of calls in a single call to the multicall contract
+func (caller *MultiCaller) executeBatch(calls []Call, allowFailure []bool, opts *bind.CallOpts) ([]CallResponse, error) {
+	switch caller.Version {
+	case Multicall3:
+		return caller.executeAggregate3(calls, allowFailure, opts)
+	default:
+		return caller.executeTryAggregate(calls, opts)
+	}
+}
+
+// Execute calls with Multicall2's tryAggregate, letting every call fail so failures can be reported individually
+func (caller *MultiCaller) executeTryAggregate(calls []Call, opts *bind.CallOpts) ([]CallResponse, error) {
+	var multiCalls = make([]MultiCall, 0, len(calls))
+	for _, call := range calls {
+		multiCalls = append(multiCalls, call.GetMultiCall())
+	}
+	responses, err := caller.callContract("tryAggregate", opts, false, multiCalls)
 	if err != nil {
 		return nil, err
 	}
+	return getCallResponses(calls, responses)
+}
+
+// Execute calls with Multicall3's aggregate3
+func (caller *MultiCaller) executeAggregate3(calls []Call, allowFailure []bool, opts *bind.CallOpts) ([]CallResponse, error) {
+	var multiCalls = make([]MultiCall3, 0, len(calls))
+	for i, call := range calls {
+		multiCalls = append(multiCalls, call.GetMultiCall3(allowFailure[i]))
+	}
+	responses, err := caller.callContract("aggregate3", opts, multiCalls)
+	if err == nil {
+		return getCallResponses(calls, responses)
+	}
 
-	responses, err := caller.ABI.Unpack("tryAggregate", resp)
+	// If a required call failed, the whole batch reverted; run it again allowing failures to find out which calls failed and why
+	var revertErr *rocketpool.RevertError
+	if !errors.As(err, &revertErr) {
+		return nil, err
+	}
+	hasRequired := false
+	for i := range multiCalls {
+		hasRequired = hasRequired || !multiCalls[i].AllowFailure
+		multiCalls[i].AllowFailure = true
+	}
+	if !hasRequired {
+		return nil, err
+	}
+	responses, err = caller.callContract("aggregate3", opts, multiCalls)
+	if err != nil {
+		return nil, err
+	}
+	return getCallResponses(calls, responses)
+}
 
+// Call a method on the multicall contract and unpack the response
+func (caller *MultiCaller) callContract(method string, opts *bind.CallOpts, args ...interface{}) ([]interface{}, error) {
+	callData, err := caller.ABI.Pack(method, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	results := make([]CallResponse, len(caller.calls))
-	for i, response := range responses[0].([]struct {
+	var blockNumber *big.Int
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+	}
+	resp, err := caller.Client.CallContract(rocketpool.GetCallContext(opts), ethereum.CallMsg{To: &caller.ContractAddress, Data: callData}, blockNumber)
+	if err != nil {
+		return nil, rocketpool.DecodeRevertError(err, multicallContractName, method, &caller.ABI)
+	}
+
+	return caller.ABI.Unpack(method, resp)
+}
+
+// Convert the unpacked results of an aggregate call into call responses
+func getCallResponses(calls []Call, responses []interface{}) ([]CallResponse, error) {
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("multicall returned no results")
+	}
+	returnData, ok := responses[0].([]struct {
 		Success    bool   `json:"success"`
 		ReturnData []byte `json:"returnData"`
-	}) {
-		results[i].Method = caller.calls[i].Method
+	})
+	if !ok {
+		return nil, fmt.Errorf("multicall returned results of unexpected type %T", responses[0])
+	}
+	if len(returnData) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(returnData), len(calls))
+	}
+
+	results := make([]CallResponse, len(calls))
+	for i, response := range returnData {
+		results[i].Method = calls[i].Method
 		results[i].ReturnDataRaw = response.ReturnData
 		results[i].Status = response.Success
 	}
 	return results, nil
 }
 
-func (caller *MultiCaller) FlexibleCall(requireSuccess bool, opts *bind.CallOpts) ([]Result, error) {
-	res := make([]Result, len(caller.calls))
-	results, err := caller.Execute(requireSuccess, opts)
-	if err != nil {
-		caller.calls = []Call{}
-		return nil, err
+// Create an error for a call in a batch
+func newCallError(index int, call Call, err error) *CallError {
+	return &CallError{
+		Index:        index,
+		Site:         call.Site,
+		ContractName: call.Contract.Name,
+		Method:       call.Method,
+		Target:       call.Target,
+		Err:          err,
 	}
-	for i, call := range caller.calls {
-		callSuccess := results[i].Status
-		if callSuccess {
-			err := call.Contract.ABI.UnpackIntoInterface(call.output, call.Method, results[i].ReturnDataRaw)
-			if err != nil {
-				caller.calls = []Call{}
-				return nil, err
-			}
-		}
-		res[i].Success = callSuccess
-		res[i].Output = call.output
+}
+
+// Get the file and line of a caller further up the stack
+func getCallSite(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
 	}
-	caller.calls = []Call{}
-	return res, err
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
 }
@@ -0,0 +1,121 @@
+package multicall
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// Returned by a query that hasn't been executed yet
+var ErrQueryNotExecuted = errors.New("query has not been executed")
+
+// The outcome of a call, recorded when the calls are executed with FlexibleCall
+type callStatus struct {
+	executed bool
+	err      error
+}
+
+// Record the outcome of a call
+func (s *callStatus) set(err error) {
+	if s == nil {
+		return
+	}
+	s.executed = true
+	s.err = err
+}
+
+// A typed handle to the output of a call, which is available once the multicaller has executed it with FlexibleCall
+type Query[T any] struct {
+	value  T
+	status callStatus
+}
+
+// Add a call that must succeed for the batch to succeed, returning a handle to its output.
+// T must match the method's output type, or be a struct for methods with multiple outputs.
+// If the call can't be added, the error is returned by the multicaller's Err and its next execution, which won't make any calls.
+func Add[T any](caller *MultiCaller, contract *rocketpool.Contract, method string, args ...interface{}) *Query[T] {
+	return addQuery[T](caller, getCallSite(2), false, contract, method, args...)
+}
+
+// Add a call that is allowed to fail without failing the batch, returning a handle to its output
+func AddOptional[T any](caller *MultiCaller, contract *rocketpool.Contract, method string, args ...interface{}) *Query[T] {
+	return addQuery[T](caller, getCallSite(2), true, contract, method, args...)
+}
+
+// Get the output of the call, or the zero value if it hasn't been executed or failed
+func (q *Query[T]) Value() T {
+	return q.value
+}
+
+// Get the output of the call, or the reason it isn't available
+func (q *Query[T]) Get() (T, error) {
+	if err := q.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+	return q.value, nil
+}
+
+// Get the reason the call's output isn't available, or nil if it succeeded
+func (q *Query[T]) Err() error {
+	if !q.status.executed {
+		return ErrQueryNotExecuted
+	}
+	return q.status.err
+}
+
+// Check whether the call was executed successfully
+func (q *Query[T]) Succeeded() bool {
+	return q.Err() == nil
+}
+
+// Get the values of executed queries in order, using the zero value for the ones that failed
+func Values[T any](queries []*Query[T]) []T {
+	values := make([]T, len(queries))
+	for i, query := range queries {
+		values[i] = query.value
+	}
+	return values
+}
+
+// Add a typed call, checking its output type before it's executed
+func addQuery[T any](caller *MultiCaller, site string, allowFailure bool, contract *rocketpool.Contract, method string, args ...interface{}) *Query[T] {
+	query := &Query[T]{}
+	if err := checkOutputType(contract, method, reflect.TypeOf(&query.value).Elem()); err != nil {
+		err = fmt.Errorf("error adding call [%s] at %s: %w", method, site, err)
+		if caller.err == nil {
+			caller.err = err
+		}
+		query.status.set(err)
+		return query
+	}
+	if err := caller.addCall(site, allowFailure, &query.status, contract, &query.value, method, args...); err != nil {
+		query.status.set(err)
+	}
+	return query
+}
+
+// Check that a method's output can be unpacked into a type
+func checkOutputType(contract *rocketpool.Contract, method string, outputType reflect.Type) error {
+	abiMethod, exists := contract.ABI.Methods[method]
+	if !exists {
+		return fmt.Errorf("method '%s' not found", method)
+	}
+	switch len(abiMethod.Outputs) {
+	case 0:
+		return fmt.Errorf("method has no outputs")
+	case 1:
+		abiType := abiMethod.Outputs[0].Type.GetType()
+		if outputType.Kind() == reflect.Interface || abiType.ConvertibleTo(outputType) {
+			return nil
+		}
+		return fmt.Errorf("output of type %s can't be unpacked into %s", abiType, outputType)
+	default:
+		if outputType.Kind() == reflect.Struct || outputType.Kind() == reflect.Interface {
+			return nil
+		}
+		return fmt.Errorf("method has %d outputs, which must be unpacked into a struct rather than %s", len(abiMethod.Outputs), outputType)
+	}
+}
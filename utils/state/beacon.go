@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+)
+
+// The number of wei in a gwei
+var weiPerGwei = big.NewInt(1e9)
+
+// Get the Beacon chain balances of the minipools' validators in wei, in the same order as the minipools.
+// Minipools without a validator on the Beacon chain have a balance of 0.
+func GetMinipoolBeaconBalances(ctx context.Context, bc rocketpool.BeaconClient, stateId types.BeaconStateId, minipoolDetails []*NativeMinipoolDetails) ([]*big.Int, error) {
+	pubkeys := make([]types.ValidatorPubkey, 0, len(minipoolDetails))
+	for _, details := range minipoolDetails {
+		if details.Pubkey != (types.ValidatorPubkey{}) {
+			pubkeys = append(pubkeys, details.Pubkey)
+		}
+	}
+
+	validators, err := bc.GetValidators(ctx, stateId, pubkeys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validators at state %s: %w", stateId, err)
+	}
+
+	balances := make([]*big.Int, len(minipoolDetails))
+	for i, details := range minipoolDetails {
+		balances[i] = big.NewInt(0)
+		if validator, exists := validators[details.Pubkey]; exists && details.Pubkey != (types.ValidatorPubkey{}) {
+			balances[i].SetUint64(validator.Balance)
+			balances[i].Mul(balances[i], weiPerGwei)
+		}
+	}
+	return balances, nil
+}
+
+// Calculate the node and user shares of the total minipool balances, using the Beacon balances of their validators in the given state
+func CalculateCompleteMinipoolSharesFromBeacon(rp *rocketpool.RocketPool, contracts *NetworkContracts, bc rocketpool.BeaconClient, stateId types.BeaconStateId, minipoolDetails []*NativeMinipoolDetails) error {
+	return CalculateCompleteMinipoolSharesFromBeaconContext(context.Background(), rp, contracts, bc, stateId, minipoolDetails)
+}
+
+// Calculate the node and user shares of the total minipool balances, using the Beacon balances of their validators in the given state and the provided context for network calls
+func CalculateCompleteMinipoolSharesFromBeaconContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, bc rocketpool.BeaconClient, stateId types.BeaconStateId, minipoolDetails []*NativeMinipoolDetails) error {
+	balances, err := GetMinipoolBeaconBalances(ctx, bc, stateId, minipoolDetails)
+	if err != nil {
+		return err
+	}
+	return CalculateCompleteMinipoolSharesContext(ctx, rp, contracts, minipoolDetails, balances)
+}
+
+// Calculate the node and user shares of each minipool's total balance, using the Beacon balances of their validators in the given state
+func (s *NetworkState) CalculateCompleteMinipoolSharesFromBeacon(rp *rocketpool.RocketPool, contracts *NetworkContracts, bc rocketpool.BeaconClient, stateId types.BeaconStateId) error {
+	return s.CalculateCompleteMinipoolSharesFromBeaconContext(context.Background(), rp, contracts, bc, stateId)
+}
+
+// Calculate the node and user shares of each minipool's total balance, using the Beacon balances of their validators in the given state and the provided context for network calls
+func (s *NetworkState) CalculateCompleteMinipoolSharesFromBeaconContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, bc rocketpool.BeaconClient, stateId types.BeaconStateId) error {
+	if contracts.ElBlockNumber.Uint64() != s.ElBlockNumber {
+		return fmt.Errorf("contracts are at block %d but the network state is at block %d", contracts.ElBlockNumber.Uint64(), s.ElBlockNumber)
+	}
+	minipools := make([]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	for i := range s.MinipoolDetails {
+		minipools[i] = &s.MinipoolDetails[i]
+	}
+	return CalculateCompleteMinipoolSharesFromBeaconContext(ctx, rp, contracts, bc, stateId, minipools)
+}
@@ -0,0 +1,419 @@
+package state
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The magic bytes at the start of every binary encoding
+var binaryMagic = []byte("PSST")
+
+// Types with their own binary representation
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Encode a value in the compact binary format, with a header holding the encoding version and a fingerprint of the value's layout
+func marshalBinary(value interface{}) ([]byte, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, fmt.Errorf("cannot encode non-pointer type %s", v.Type())
+	}
+	v = v.Elem()
+	fingerprint, err := schemaFingerprint(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	w := &binaryWriter{}
+	w.buf.Write(binaryMagic)
+	w.writeUvarint(NetworkStateEncodingVersion)
+	w.buf.Write(fingerprint)
+	if err := w.writeValue(v); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// Decode a value in the compact binary format into the provided pointer
+func unmarshalBinary(data []byte, value interface{}) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("cannot decode into non-pointer type %s", v.Type())
+	}
+	fingerprint, err := schemaFingerprint(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	// Check the header
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return errors.New("data is not in the binary snapshot format")
+	}
+	r := &binaryReader{data: data[len(binaryMagic):]}
+	version, err := r.readUvarint()
+	if err != nil {
+		return fmt.Errorf("error reading encoding version: %w", err)
+	}
+	if version != NetworkStateEncodingVersion {
+		return fmt.Errorf("unsupported encoding version %d (expected %d)", version, NetworkStateEncodingVersion)
+	}
+	encodedFingerprint, err := r.readBytes(len(fingerprint))
+	if err != nil {
+		return fmt.Errorf("error reading schema fingerprint: %w", err)
+	}
+	if !bytes.Equal(encodedFingerprint, fingerprint) {
+		return fmt.Errorf("data was encoded with a different layout of %s", v.Elem().Type())
+	}
+
+	// Decode the value
+	if err := r.readValue(v.Elem()); err != nil {
+		return err
+	}
+	if len(r.data) > 0 {
+		return fmt.Errorf("%d unexpected bytes after the encoded value", len(r.data))
+	}
+	return nil
+}
+
+// Get a fingerprint of a type's layout, so data encoded with a different version of it is rejected instead of misread
+func schemaFingerprint(t reflect.Type) ([]byte, error) {
+	var description strings.Builder
+	if err := describeType(&description, t); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(description.String()))
+	return hash[:8], nil
+}
+
+// Write a description of a type's layout
+func describeType(description *strings.Builder, t reflect.Type) error {
+	switch t {
+	case bigIntType, durationType, timeType:
+		description.WriteString(t.String())
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		description.WriteString(t.Kind().String())
+	case reflect.Array:
+		fmt.Fprintf(description, "[%d]", t.Len())
+		return describeType(description, t.Elem())
+	case reflect.Slice:
+		description.WriteString("[]")
+		return describeType(description, t.Elem())
+	case reflect.Pointer:
+		description.WriteString("*")
+		return describeType(description, t.Elem())
+	case reflect.Struct:
+		description.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			description.WriteString(field.Name)
+			description.WriteString(" ")
+			if err := describeType(description, field.Type); err != nil {
+				return err
+			}
+			description.WriteString(";")
+		}
+		description.WriteString("}")
+	default:
+		return fmt.Errorf("type %s cannot be encoded", t)
+	}
+	return nil
+}
+
+// Writes values in the compact binary format
+type binaryWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *binaryWriter) writeUvarint(value uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, value))
+}
+
+func (w *binaryWriter) writeVarint(value int64) {
+	w.buf.Write(binary.AppendVarint(nil, value))
+}
+
+func (w *binaryWriter) writeBytes(value []byte) {
+	w.writeUvarint(uint64(len(value)))
+	w.buf.Write(value)
+}
+
+// Write a value; unexported struct fields are skipped
+func (w *binaryWriter) writeValue(v reflect.Value) error {
+	switch v.Type() {
+	case bigIntType:
+		value := v.Addr().Interface().(*big.Int)
+		if value.Sign() < 0 {
+			w.buf.WriteByte(1)
+		} else {
+			w.buf.WriteByte(0)
+		}
+		w.writeBytes(value.Bytes())
+		return nil
+	case durationType:
+		w.writeVarint(v.Int())
+		return nil
+	case timeType:
+		value := v.Interface().(time.Time)
+		w.writeVarint(value.Unix())
+		w.writeUvarint(uint64(value.Nanosecond()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			w.buf.WriteByte(1)
+		} else {
+			w.buf.WriteByte(0)
+		}
+	case reflect.Uint8:
+		w.buf.WriteByte(uint8(v.Uint()))
+	case reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		w.writeUvarint(v.Uint())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		w.writeVarint(v.Int())
+	case reflect.Float64:
+		w.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v.Float())))
+	case reflect.String:
+		w.writeBytes([]byte(v.String()))
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			for i := 0; i < v.Len(); i++ {
+				w.buf.WriteByte(uint8(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.writeValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		w.writeUvarint(uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := w.writeValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.buf.WriteByte(0)
+			return nil
+		}
+		w.buf.WriteByte(1)
+		return w.writeValue(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := w.writeValue(v.Field(i)); err != nil {
+				return fmt.Errorf("error encoding %s.%s: %w", v.Type().Name(), v.Type().Field(i).Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("type %s cannot be encoded", v.Type())
+	}
+	return nil
+}
+
+// Reads values in the compact binary format
+type binaryReader struct {
+	data []byte
+}
+
+func (r *binaryReader) readByte() (byte, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	value := r.data[0]
+	r.data = r.data[1:]
+	return value, nil
+}
+
+func (r *binaryReader) readUvarint() (uint64, error) {
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.data = r.data[n:]
+	return value, nil
+}
+
+func (r *binaryReader) readVarint() (int64, error) {
+	value, n := binary.Varint(r.data)
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.data = r.data[n:]
+	return value, nil
+}
+
+func (r *binaryReader) readBytes(length int) ([]byte, error) {
+	if length < 0 || len(r.data) < length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value, nil
+}
+
+func (r *binaryReader) readLength() (int, error) {
+	length, err := r.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if length > uint64(len(r.data)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return int(length), nil
+}
+
+// Read a value into the provided settable value
+func (r *binaryReader) readValue(v reflect.Value) error {
+	switch v.Type() {
+	case bigIntType:
+		sign, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		length, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		abs, _ := r.readBytes(length)
+		value := v.Addr().Interface().(*big.Int)
+		value.SetBytes(abs)
+		if sign == 1 {
+			value.Neg(value)
+		}
+		return nil
+	case durationType:
+		value, err := r.readVarint()
+		if err != nil {
+			return err
+		}
+		v.SetInt(value)
+		return nil
+	case timeType:
+		seconds, err := r.readVarint()
+		if err != nil {
+			return err
+		}
+		nanoseconds, err := r.readUvarint()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.Unix(seconds, int64(nanoseconds))))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		value, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		v.SetBool(value != 0)
+	case reflect.Uint8:
+		value, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(value))
+	case reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		value, err := r.readUvarint()
+		if err != nil {
+			return err
+		}
+		v.SetUint(value)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		value, err := r.readVarint()
+		if err != nil {
+			return err
+		}
+		v.SetInt(value)
+	case reflect.Float64:
+		value, err := r.readBytes(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(value)))
+	case reflect.String:
+		length, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		value, _ := r.readBytes(length)
+		v.SetString(string(value))
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			value, err := r.readBytes(v.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(value))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := r.readValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		length, err := r.readLength()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.MakeSlice(v.Type(), length, length))
+		for i := 0; i < length; i++ {
+			if err := r.readValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		present, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if present == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return r.readValue(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := r.readValue(v.Field(i)); err != nil {
+				return fmt.Errorf("error decoding %s.%s: %w", v.Type().Name(), v.Type().Field(i).Name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("type %s cannot be decoded", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The distributable balance at which minipools refuse to reduce their bond, since it's assumed to be capital instead of rewards
+var bondReductionBalanceLimit = eth.EthToWei(8)
+
+// The state of a minipool's pending bond reduction
+type BondReductionStatus string
+
+const (
+	BondReductionStatusWaiting   BondReductionStatus = "waiting"   // The window hasn't opened yet
+	BondReductionStatusReady     BondReductionStatus = "ready"     // The window is open and ReduceBondAmount can be called
+	BondReductionStatusBlocked   BondReductionStatus = "blocked"   // The window is open but a prerequisite isn't met
+	BondReductionStatusExpired   BondReductionStatus = "expired"   // The window closed before the bond was reduced
+	BondReductionStatusCancelled BondReductionStatus = "cancelled" // The reduction was cancelled by the oDAO
+)
+
+// The timing, prerequisites and projected results of a minipool's pending bond reduction
+type BondReductionPlan struct {
+	MinipoolAddress    common.Address      `json:"minipoolAddress"`
+	NodeAddress        common.Address      `json:"nodeAddress"`
+	Status             BondReductionStatus `json:"status"`
+	BeginTime          time.Time           `json:"beginTime"`
+	WindowStart        time.Time           `json:"windowStart"`
+	WindowEnd          time.Time           `json:"windowEnd"`
+	CurrentBond        *big.Int            `json:"currentBond"`
+	NewBond            *big.Int            `json:"newBond"`
+	CurrentNodeFee     *big.Int            `json:"currentNodeFee"`
+	NewNodeFee         *big.Int            `json:"newNodeFee"`         // Reducing the bond resets the fee to the network's current fee
+	CreditIncrease     *big.Int            `json:"creditIncrease"`     // The node's ETH matched and deposit credit both grow by this
+	NewDepositCredit   *big.Int            `json:"newDepositCredit"`   // Includes the credit from the node's earlier reductions in the plan
+	NewEthMatched      *big.Int            `json:"newEthMatched"`      // Includes the ETH matched from the node's earlier reductions in the plan
+	EthMatchedLimit    *big.Int            `json:"ethMatchedLimit"`    // The most ETH the node's RPL stake allows it to borrow
+	UnmetPrerequisites []string            `json:"unmetPrerequisites"` // Why the reduction would fail if the window was open
+}
+
+// Plan the pending bond reductions of every minipool in the snapshot at the given time
+func GetBondReductionPlans(state *NetworkState, currentTime time.Time) []BondReductionPlan {
+	plans := []BondReductionPlan{}
+	for _, node := range state.NodeDetails {
+		plans = append(plans, GetNodeBondReductionPlans(state, node.NodeAddress, currentTime)...)
+	}
+	return plans
+}
+
+// Plan the pending bond reductions of a node's minipools at the given time.
+// The plans are ordered by when their windows open, and each one assumes the node's earlier reductions that can go through have been completed.
+func GetNodeBondReductionPlans(state *NetworkState, nodeAddress common.Address, currentTime time.Time) []BondReductionPlan {
+	plans := []BondReductionPlan{}
+	node, exists := state.GetNode(nodeAddress)
+	if !exists {
+		return plans
+	}
+
+	// Get the pending reductions
+	pending := []*NativeMinipoolDetails{}
+	for _, mpd := range state.GetNodeMinipools(nodeAddress) {
+		if mpd.Version >= atlasMinipoolVersion && bigOrZero(mpd.ReduceBondTime).Sign() > 0 && bigOrZero(mpd.ReduceBondValue).Sign() > 0 {
+			pending = append(pending, mpd)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].ReduceBondTime.Cmp(pending[j].ReduceBondTime) < 0
+	})
+
+	// Plan them in order, accumulating the node's ETH matched and deposit credit
+	networkFee := bigOrZero(state.NetworkDetails.NodeFeeRaw)
+	ethMatchedLimit := bigOrZero(node.EthMatchedLimit)
+	ethMatched := big.NewInt(0).Set(bigOrZero(node.EthMatched))
+	depositCredit := big.NewInt(0).Set(bigOrZero(node.DepositCreditBalance))
+	for _, mpd := range pending {
+		plan := planBondReduction(state.NetworkDetails, mpd)
+		plan.NewNodeFee = big.NewInt(0).Set(networkFee)
+		plan.EthMatchedLimit = big.NewInt(0).Set(ethMatchedLimit)
+		plan.NewEthMatched = big.NewInt(0).Add(ethMatched, plan.CreditIncrease)
+		plan.NewDepositCredit = big.NewInt(0).Add(depositCredit, plan.CreditIncrease)
+		if plan.NewEthMatched.Cmp(ethMatchedLimit) > 0 {
+			plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, fmt.Sprintf("the node doesn't have enough RPL staked to borrow %s more ETH (it would borrow %s of %s)", plan.CreditIncrease, plan.NewEthMatched, ethMatchedLimit))
+		}
+
+		// Set the status
+		switch {
+		case mpd.ReduceBondCancelled:
+			plan.Status = BondReductionStatusCancelled
+		case !currentTime.Before(plan.WindowEnd):
+			plan.Status = BondReductionStatusExpired
+		case currentTime.Before(plan.WindowStart):
+			plan.Status = BondReductionStatusWaiting
+		case len(plan.UnmetPrerequisites) > 0:
+			plan.Status = BondReductionStatusBlocked
+		default:
+			plan.Status = BondReductionStatusReady
+		}
+
+		// Later reductions build on this one if it can still go through
+		if (plan.Status == BondReductionStatusReady || plan.Status == BondReductionStatusWaiting) && len(plan.UnmetPrerequisites) == 0 {
+			ethMatched = plan.NewEthMatched
+			depositCredit = plan.NewDepositCredit
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans
+}
+
+// Get the plans for the minipools that can call ReduceBondAmount now
+func GetReadyBondReductions(plans []BondReductionPlan) []BondReductionPlan {
+	ready := []BondReductionPlan{}
+	for _, plan := range plans {
+		if plan.Status == BondReductionStatusReady {
+			ready = append(ready, plan)
+		}
+	}
+	return ready
+}
+
+// Plan a minipool's bond reduction without the node-level projections
+func planBondReduction(networkDetails *NetworkDetails, mpd *NativeMinipoolDetails) BondReductionPlan {
+	beginTime := unixTime(mpd.ReduceBondTime)
+	windowStart := beginTime.Add(networkDetails.BondReductionWindowStart)
+	plan := BondReductionPlan{
+		MinipoolAddress:    mpd.MinipoolAddress,
+		NodeAddress:        mpd.NodeAddress,
+		BeginTime:          beginTime,
+		WindowStart:        windowStart,
+		WindowEnd:          windowStart.Add(networkDetails.BondReductionWindowLength),
+		CurrentBond:        big.NewInt(0).Set(bigOrZero(mpd.NodeDepositBalance)),
+		NewBond:            big.NewInt(0).Set(bigOrZero(mpd.ReduceBondValue)),
+		CurrentNodeFee:     big.NewInt(0).Set(bigOrZero(mpd.NodeFee)),
+		CreditIncrease:     big.NewInt(0),
+		UnmetPrerequisites: []string{},
+	}
+
+	if !networkDetails.BondReductionEnabled {
+		plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, "bond reduction is disabled on the network")
+	}
+	if mpd.Status != types.Staking {
+		plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, fmt.Sprintf("the minipool is %s, not Staking", mpd.Status))
+	}
+	if mpd.Finalised {
+		plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, "the minipool has been finalised")
+	}
+	if distributableBalance(mpd).Cmp(bondReductionBalanceLimit) >= 0 {
+		plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, "the minipool's balance is 8 ETH or more, so it can't reduce its bond")
+	}
+	if plan.NewBond.Cmp(plan.CurrentBond) >= 0 {
+		plan.UnmetPrerequisites = append(plan.UnmetPrerequisites, fmt.Sprintf("the new bond %s isn't lower than the current bond %s", plan.NewBond, plan.CurrentBond))
+	} else {
+		plan.CreditIncrease.Sub(plan.CurrentBond, plan.NewBond)
+	}
+
+	return plan
+}
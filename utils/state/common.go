@@ -2,6 +2,8 @@ package state
 
 import (
 	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
 )
 
 const (
@@ -11,3 +13,20 @@ const (
 // Global constants
 var zero = big.NewInt(0)
 var two = big.NewInt(2)
+
+// Functions that copy the outputs of executed queries into the fields they were added for
+type fieldSetters []func()
+
+// Add a query whose output is copied into a field when the setters are run
+func setField[T any](setters *fieldSetters, field *T, query *multicall.Query[T]) {
+	*setters = append(*setters, func() {
+		*field = query.Value()
+	})
+}
+
+// Copy the outputs of the queries into their fields, once the multicaller has executed them
+func (s fieldSetters) run() {
+	for _, set := range s {
+		set()
+	}
+}
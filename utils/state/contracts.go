@@ -20,8 +20,9 @@ type NetworkContracts struct {
 	Multicaller    *multicall.MultiCaller
 	ElBlockNumber  *big.Int
 
-	// Network version
-	Version *version.Version
+	// Network version and the features it has
+	Version  *version.Version
+	Features FeatureSet
 
 	// Redstone
 	RocketDAONodeTrustedSettingsMinipool *rocketpool.Contract
@@ -54,13 +55,19 @@ type contractArtifacts struct {
 	address    common.Address
 	abiEncoded string
 	contract   **rocketpool.Contract
+	feature    Feature // The feature the contract belongs to, or empty if every version has it
 }
 
-// Get a new network contracts container
-func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, isAtlasDeployed bool, opts *bind.CallOpts) (*NetworkContracts, error) {
+// Get a new network contracts container, detecting the protocol version and its features
+func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, opts *bind.CallOpts) (*NetworkContracts, error) {
+	return NewNetworkContractsContext(rocketpool.GetCallContext(opts), rp, multicallerAddress, balanceBatcherAddress, opts)
+}
+
+// Get a new network contracts container, detecting the protocol version and its features and using the provided context for network calls
+func NewNetworkContractsContext(ctx context.Context, rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, opts *bind.CallOpts) (*NetworkContracts, error) {
 	// Get the latest block number if it's not provided
 	if opts == nil {
-		latestElBlock, err := rp.Client.BlockNumber(context.Background())
+		latestElBlock, err := rp.Client.BlockNumber(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error getting latest block number: %w", err)
 		}
@@ -68,6 +75,7 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 			BlockNumber: big.NewInt(0).SetUint64(latestElBlock),
 		}
 	}
+	opts = rocketpool.WithCallContext(ctx, opts)
 
 	// Create the contract binding
 	contracts := &NetworkContracts{
@@ -81,6 +89,7 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 	if err != nil {
 		return nil, err
 	}
+	contracts.Multicaller.BatchSettings.ThreadLimit = threadLimit
 
 	// Create the balance batcher
 	contracts.BalanceBatcher, err = multicall.NewBalanceBatcher(rp.Client, balanceBatcherAddress)
@@ -88,7 +97,7 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 		return nil, err
 	}
 
-	// Create the contract wrappers for Redstone
+	// Create the contract wrappers
 	wrappers := []contractArtifacts{
 		{
 			name:     "poolseaDAONodeTrustedSettingsMinipool",
@@ -148,14 +157,13 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 			name:     "poolseaTokenRPLFixedSupply",
 			contract: &contracts.RocketTokenRPLFixedSupply,
 		},
-	}
 
-	// Atlas wrappers
-	if isAtlasDeployed {
-		wrappers = append(wrappers, contractArtifacts{
+		// Atlas
+		{
 			name:     "poolseaMinipoolBondReducer",
 			contract: &contracts.RocketMinipoolBondReducer,
-		})
+			feature:  FeatureAtlas,
+		},
 	}
 
 	// Add the address and ABI getters to multicall
@@ -173,45 +181,62 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 		return nil, fmt.Errorf("error executing multicall for contract retrieval: %w", err)
 	}
 
-	// Postprocess the contracts
+	// Create the contracts every version has
 	for i, wrapper := range wrappers {
-		// Decode the ABI
-		abi, err := rocketpool.DecodeAbi(wrapper.abiEncoded)
-		if err != nil {
-			return nil, fmt.Errorf("error decoding ABI for %s: %w", wrapper.name, err)
+		if wrapper.feature != "" {
+			continue
 		}
-
-		// Create the contract binding
-		contract := &rocketpool.Contract{
-			Contract: bind.NewBoundContract(wrapper.address, *abi, rp.Client, rp.Client, rp.Client),
-			Address:  &wrappers[i].address,
-			ABI:      abi,
-			Client:   rp.Client,
+		if err := wrappers[i].createContract(rp); err != nil {
+			return nil, err
 		}
-
-		// Set the contract in the main wrapper object
-		*wrappers[i].contract = contract
 	}
 
-	err = contracts.getCurrentVersion(rp)
+	// Detect the network version and its features
+	err = contracts.getCurrentVersion(ctx, rp)
 	if err != nil {
 		return nil, fmt.Errorf("error getting network contract version: %w", err)
 	}
+	contracts.Features = GetFeatureSet(contracts.Version)
+
+	// Create the contracts for the features the network has
+	for i, wrapper := range wrappers {
+		if wrapper.feature == "" || !contracts.Features.Has(wrapper.feature) {
+			continue
+		}
+		if err := wrappers[i].createContract(rp); err != nil {
+			return nil, err
+		}
+	}
 
 	return contracts, nil
 }
 
-// Returns whether or not Atlas has been deployed
-// TODO: refactor this so it comes first and we don't need to pass this check around everywhere
-func (c *NetworkContracts) _isAtlasDeployed() bool {
-	constraint, _ := version.NewConstraint(">= 1.2.0")
-	return constraint.Check(c.Version)
+// Create the contract binding from the retrieved address and ABI, and set it in the contracts container
+func (wrapper *contractArtifacts) createContract(rp *rocketpool.RocketPool) error {
+	// Decode the ABI
+	abi, err := rocketpool.DecodeAbi(wrapper.abiEncoded)
+	if err != nil {
+		return fmt.Errorf("error decoding ABI for %s: %w", wrapper.name, err)
+	}
+
+	// Create the contract binding
+	*wrapper.contract = &rocketpool.Contract{
+		Contract:           bind.NewBoundContract(wrapper.address, *abi, rp.Client, rp.Client, rp.Client),
+		Address:            &wrapper.address,
+		ABI:                abi,
+		Client:             rp.Client,
+		Name:               wrapper.name,
+		TransactionManager: rp.GetTransactionManager(),
+		GasSettings:        rp.GetGasSettings(),
+	}
+	return nil
 }
 
 // Get the current version of the network
-func (c *NetworkContracts) getCurrentVersion(rp *rocketpool.RocketPool) error {
+func (c *NetworkContracts) getCurrentVersion(ctx context.Context, rp *rocketpool.RocketPool) error {
 	opts := &bind.CallOpts{
 		BlockNumber: c.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	// Check for v1.2
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+)
+
+// A protocol feature that changes which contracts and calls the state loaders use
+type Feature string
+
+const (
+	FeatureAtlas Feature = "atlas" // Bond reduction, deposit credit, variable collateralisation and the v3 minipool delegate
+)
+
+// A feature and the protocol versions that have it
+type featureDefinition struct {
+	feature    Feature
+	constraint version.Constraints
+}
+
+// The known features
+var (
+	featureDefinitions     = []featureDefinition{}
+	featureDefinitionsLock sync.RWMutex
+)
+
+func init() {
+	if err := RegisterFeature(FeatureAtlas, ">= 1.2.0"); err != nil {
+		panic(err)
+	}
+}
+
+// Register a feature that's present in every protocol version matching the constraint (e.g. ">= 1.3.0")
+func RegisterFeature(feature Feature, versionConstraint string) error {
+	constraint, err := version.NewConstraint(versionConstraint)
+	if err != nil {
+		return fmt.Errorf("error parsing version constraint for feature %s: %w", feature, err)
+	}
+
+	featureDefinitionsLock.Lock()
+	defer featureDefinitionsLock.Unlock()
+	for i, definition := range featureDefinitions {
+		if definition.feature == feature {
+			featureDefinitions[i].constraint = constraint
+			return nil
+		}
+	}
+	featureDefinitions = append(featureDefinitions, featureDefinition{
+		feature:    feature,
+		constraint: constraint,
+	})
+	return nil
+}
+
+// The features of a protocol version, sorted by name
+type FeatureSet []Feature
+
+// Get the features of a protocol version
+func GetFeatureSet(protocolVersion *version.Version) FeatureSet {
+	featureDefinitionsLock.RLock()
+	defer featureDefinitionsLock.RUnlock()
+
+	features := FeatureSet{}
+	for _, definition := range featureDefinitions {
+		if definition.constraint.Check(protocolVersion) {
+			features = append(features, definition.feature)
+		}
+	}
+	sort.Slice(features, func(i, j int) bool {
+		return features[i] < features[j]
+	})
+	return features
+}
+
+// Check if the set has a feature
+func (s FeatureSet) Has(feature Feature) bool {
+	for _, f := range s {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if two sets have the same features
+func (s FeatureSet) Equals(other FeatureSet) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for _, feature := range s {
+		if !other.Has(feature) {
+			return false
+		}
+	}
+	return true
+}
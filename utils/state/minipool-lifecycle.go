@@ -0,0 +1,267 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The first minipool delegate version with the Atlas lifecycle (vacant minipools, bond reduction and node-triggered distribution)
+const atlasMinipoolVersion uint8 = 3
+
+// An action that can be taken on a minipool
+type MinipoolAction string
+
+const (
+	MinipoolActionStake             MinipoolAction = "stake"
+	MinipoolActionPromote           MinipoolAction = "promote"
+	MinipoolActionDissolve          MinipoolAction = "dissolve"
+	MinipoolActionClose             MinipoolAction = "close"
+	MinipoolActionDistributeBalance MinipoolAction = "distributeBalance"
+	MinipoolActionFinalise          MinipoolAction = "finalise"
+	MinipoolActionReduceBondAmount  MinipoolAction = "reduceBondAmount"
+)
+
+// All of the minipool actions, in the order they're planned
+var MinipoolActions = []MinipoolAction{
+	MinipoolActionStake,
+	MinipoolActionPromote,
+	MinipoolActionDissolve,
+	MinipoolActionClose,
+	MinipoolActionDistributeBalance,
+	MinipoolActionFinalise,
+	MinipoolActionReduceBondAmount,
+}
+
+// When a minipool action can be taken
+type MinipoolActionPlan struct {
+	Action        MinipoolAction `json:"action"`
+	Ready         bool           `json:"ready"`         // True if the action can be taken now
+	EarliestTime  time.Time      `json:"earliestTime"`  // The earliest time the action can be taken; zero if it can't be taken until the minipool changes
+	Deadline      time.Time      `json:"deadline"`      // The time the action can no longer be taken; zero if there isn't one
+	BlockedReason string         `json:"blockedReason"` // Why the action can't be taken now; empty if it's ready
+}
+
+// The actions that can be taken on a minipool at a point in time
+type MinipoolLifecyclePlan struct {
+	MinipoolAddress common.Address       `json:"minipoolAddress"`
+	Status          types.MinipoolStatus `json:"status"`
+	Time            time.Time            `json:"time"`
+	Actions         []MinipoolActionPlan `json:"actions"`
+}
+
+// Plan the actions that can be taken on a minipool at the given time, based on its details and the network's settings
+func PlanMinipoolActions(details *NativeMinipoolDetails, networkDetails *NetworkDetails, currentTime time.Time) MinipoolLifecyclePlan {
+	plan := MinipoolLifecyclePlan{
+		MinipoolAddress: details.MinipoolAddress,
+		Status:          details.Status,
+		Time:            currentTime,
+		Actions:         make([]MinipoolActionPlan, 0, len(MinipoolActions)),
+	}
+	for _, action := range MinipoolActions {
+		plan.Actions = append(plan.Actions, planMinipoolAction(action, details, networkDetails, currentTime))
+	}
+	return plan
+}
+
+// Get the actions that can be taken now
+func (p MinipoolLifecyclePlan) ReadyActions() []MinipoolActionPlan {
+	ready := []MinipoolActionPlan{}
+	for _, action := range p.Actions {
+		if action.Ready {
+			ready = append(ready, action)
+		}
+	}
+	return ready
+}
+
+// Get the actions that can't be taken now but will be once enough time has passed, ordered by the earliest time they can be taken
+func (p MinipoolLifecyclePlan) UpcomingActions() []MinipoolActionPlan {
+	upcoming := []MinipoolActionPlan{}
+	for _, action := range p.Actions {
+		if !action.Ready && !action.EarliestTime.IsZero() {
+			upcoming = append(upcoming, action)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].EarliestTime.Before(upcoming[j].EarliestTime)
+	})
+	return upcoming
+}
+
+// Get the plan for a single action
+func (p MinipoolLifecyclePlan) GetAction(action MinipoolAction) (MinipoolActionPlan, bool) {
+	for _, actionPlan := range p.Actions {
+		if actionPlan.Action == action {
+			return actionPlan, true
+		}
+	}
+	return MinipoolActionPlan{}, false
+}
+
+// Plan a single minipool action
+func planMinipoolAction(action MinipoolAction, details *NativeMinipoolDetails, networkDetails *NetworkDetails, currentTime time.Time) MinipoolActionPlan {
+	isAtlas := details.Version >= atlasMinipoolVersion
+	statusTime := unixTime(details.StatusTime)
+
+	switch action {
+	case MinipoolActionStake:
+		if details.Status != types.Prelaunch {
+			return blockedAction(action, "the minipool is %s, not Prelaunch", details.Status)
+		}
+		if details.IsVacant {
+			return blockedAction(action, "vacant minipools are promoted instead of staked")
+		}
+		return timedAction(action, currentTime, statusTime.Add(networkDetails.ScrubPeriod), time.Time{}, "the scrub period hasn't passed yet")
+
+	case MinipoolActionPromote:
+		if !isAtlas {
+			return blockedAction(action, "v%d minipools can't be promoted", details.Version)
+		}
+		if !details.IsVacant {
+			return blockedAction(action, "only vacant minipools can be promoted")
+		}
+		if details.Status != types.Prelaunch {
+			return blockedAction(action, "the minipool is %s, not Prelaunch", details.Status)
+		}
+		return timedAction(action, currentTime, statusTime.Add(networkDetails.PromotionScrubPeriod), time.Time{}, "the promotion scrub period hasn't passed yet")
+
+	case MinipoolActionDissolve:
+		if !isAtlas && details.Status == types.Initialized {
+			// Legacy minipools can be dissolved by their owner before they're assigned
+			return readyAction(action, currentTime)
+		}
+		if details.Status != types.Prelaunch {
+			return blockedAction(action, "the minipool is %s, not Prelaunch", details.Status)
+		}
+		launchTimeout := time.Duration(0)
+		if networkDetails.MinipoolLaunchTimeout != nil {
+			launchTimeout = time.Duration(networkDetails.MinipoolLaunchTimeout.Int64()) * time.Second
+		}
+		return timedAction(action, currentTime, statusTime.Add(launchTimeout), time.Time{}, "the launch timeout hasn't passed yet")
+
+	case MinipoolActionClose:
+		if details.Status != types.Dissolved {
+			return blockedAction(action, "the minipool is %s, not Dissolved", details.Status)
+		}
+		return readyAction(action, currentTime)
+
+	case MinipoolActionDistributeBalance:
+		if details.Finalised {
+			return blockedAction(action, "the minipool has already been finalised")
+		}
+		if !isAtlas {
+			if details.Status != types.Withdrawable {
+				return blockedAction(action, "the minipool is %s, not Withdrawable", details.Status)
+			}
+			return readyAction(action, currentTime)
+		}
+		if details.Status == types.Dissolved {
+			// Dissolved minipools return their whole balance to the node
+			return readyAction(action, currentTime)
+		}
+		if details.Status != types.Staking {
+			return blockedAction(action, "the minipool is %s, not Staking", details.Status)
+		}
+		if distributableBalance(details).Sign() <= 0 {
+			return blockedAction(action, "the minipool has no balance to distribute")
+		}
+		return readyAction(action, currentTime)
+
+	case MinipoolActionFinalise:
+		if details.Finalised {
+			return blockedAction(action, "the minipool has already been finalised")
+		}
+		if !isAtlas {
+			if details.Status != types.Withdrawable {
+				return blockedAction(action, "the minipool is %s, not Withdrawable", details.Status)
+			}
+			return readyAction(action, currentTime)
+		}
+		if !details.UserDistributed {
+			// Distributing the full balance as the node operator finalises the minipool automatically
+			return blockedAction(action, "the balance hasn't been distributed by a user")
+		}
+		return readyAction(action, currentTime)
+
+	case MinipoolActionReduceBondAmount:
+		if !isAtlas {
+			return blockedAction(action, "v%d minipools can't reduce their bond", details.Version)
+		}
+		if details.ReduceBondTime == nil || details.ReduceBondTime.Sign() == 0 {
+			return blockedAction(action, "a bond reduction hasn't been started")
+		}
+		if details.ReduceBondCancelled {
+			return blockedAction(action, "the bond reduction was cancelled")
+		}
+		reduction := planBondReduction(networkDetails, details)
+		if len(reduction.UnmetPrerequisites) > 0 {
+			return blockedAction(action, "%s", strings.Join(reduction.UnmetPrerequisites, "; "))
+		}
+		if !currentTime.Before(reduction.WindowEnd) {
+			return blockedAction(action, "the bond reduction window closed at %s", reduction.WindowEnd.UTC().Format(time.RFC3339))
+		}
+		return timedAction(action, currentTime, reduction.WindowStart, reduction.WindowEnd, "the bond reduction window hasn't opened yet")
+	}
+
+	return blockedAction(action, "unknown action")
+}
+
+// An action that can be taken now
+func readyAction(action MinipoolAction, currentTime time.Time) MinipoolActionPlan {
+	return MinipoolActionPlan{
+		Action:       action,
+		Ready:        true,
+		EarliestTime: currentTime,
+	}
+}
+
+// An action that can't be taken until the minipool changes
+func blockedAction(action MinipoolAction, reason string, args ...interface{}) MinipoolActionPlan {
+	return MinipoolActionPlan{
+		Action:        action,
+		BlockedReason: fmt.Sprintf(reason, args...),
+	}
+}
+
+// An action that can be taken from the given time
+func timedAction(action MinipoolAction, currentTime time.Time, earliestTime time.Time, deadline time.Time, waitingReason string) MinipoolActionPlan {
+	plan := MinipoolActionPlan{
+		Action:       action,
+		Ready:        !currentTime.Before(earliestTime),
+		EarliestTime: earliestTime,
+		Deadline:     deadline,
+	}
+	if !plan.Ready {
+		plan.BlockedReason = fmt.Sprintf("%s (it can be taken at %s)", waitingReason, earliestTime.UTC().Format(time.RFC3339))
+	}
+	return plan
+}
+
+// Get the minipool's balance without the node refund, calculating it if it hasn't been yet
+func distributableBalance(details *NativeMinipoolDetails) *big.Int {
+	if details.DistributableBalance != nil {
+		return details.DistributableBalance
+	}
+	balance := big.NewInt(0)
+	if details.Balance != nil {
+		balance.Set(details.Balance)
+	}
+	if details.NodeRefundBalance != nil {
+		balance.Sub(balance, details.NodeRefundBalance)
+	}
+	return balance
+}
+
+// Convert a timestamp in seconds to a time
+func unixTime(seconds *big.Int) time.Time {
+	if seconds == nil {
+		return time.Unix(0, 0)
+	}
+	return time.Unix(seconds.Int64(), 0)
+}
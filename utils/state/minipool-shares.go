@@ -0,0 +1,295 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Share math constants, matching the minipool delegates
+var (
+	calcBase            = big.NewInt(1e18)                                    // The fixed-point base for fees and penalty rates
+	legacyLaunchBalance = big.NewInt(0).Mul(big.NewInt(32), big.NewInt(1e18)) // The launch balance pre-Atlas delegates split rewards around
+)
+
+// A difference between the off-chain share math and the minipool contract
+type MinipoolShareMismatch struct {
+	MinipoolAddress   common.Address `json:"minipoolAddress"`
+	Balance           *big.Int       `json:"balance"`
+	OffchainNodeShare *big.Int       `json:"offchainNodeShare"`
+	OffchainUserShare *big.Int       `json:"offchainUserShare"`
+	OnchainNodeShare  *big.Int       `json:"onchainNodeShare"`
+	OnchainUserShare  *big.Int       `json:"onchainUserShare"`
+}
+
+// Calculate the node's share of a minipool balance without calling the minipool, matching its delegate's calculateNodeShare.
+// The minipool's penalty rate is capped by the network's max penalty rate if the network details are provided.
+func CalculateMinipoolNodeShare(details *NativeMinipoolDetails, networkDetails *NetworkDetails, balance *big.Int) (*big.Int, error) {
+	if balance.Sign() < 0 {
+		return nil, fmt.Errorf("minipool %s can't split a negative balance", details.MinipoolAddress.Hex())
+	}
+
+	var nodeShare *big.Int
+	var err error
+	if details.Version >= atlasMinipoolVersion {
+		nodeShare, err = calculateNodeShare_Atlas(details, balance)
+	} else {
+		nodeShare = calculateNodeShare_Legacy(details, balance)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the ETH penalty
+	penaltyRate := bigOrZero(details.PenaltyRate)
+	if networkDetails != nil && networkDetails.MaxPenaltyRate != nil && penaltyRate.Cmp(networkDetails.MaxPenaltyRate) > 0 {
+		penaltyRate = networkDetails.MaxPenaltyRate
+	}
+	if penaltyRate.Sign() > 0 {
+		penaltyAmount := big.NewInt(0).Mul(nodeShare, penaltyRate)
+		penaltyAmount.Div(penaltyAmount, calcBase)
+		if penaltyAmount.Cmp(nodeShare) > 0 {
+			penaltyAmount.Set(nodeShare)
+		}
+		nodeShare.Sub(nodeShare, penaltyAmount)
+	}
+	return nodeShare, nil
+}
+
+// Calculate the user's share of a minipool balance without calling the minipool, matching its delegate's calculateUserShare
+func CalculateMinipoolUserShare(details *NativeMinipoolDetails, networkDetails *NetworkDetails, balance *big.Int) (*big.Int, error) {
+	nodeShare, err := CalculateMinipoolNodeShare(details, networkDetails, balance)
+	if err != nil {
+		return nil, err
+	}
+	return big.NewInt(0).Sub(balance, nodeShare), nil
+}
+
+// Calculate the node and user shares of a minipool's contract balance without calling the minipool.
+// This fills the same fields as the minipool details loaders.
+func CalculateMinipoolShares(details *NativeMinipoolDetails, networkDetails *NetworkDetails) error {
+	details.DistributableBalance = big.NewInt(0).Sub(bigOrZero(details.Balance), bigOrZero(details.NodeRefundBalance))
+	if details.DistributableBalance.Sign() < 0 {
+		details.NodeShareOfBalance = big.NewInt(0)
+		details.UserShareOfBalance = big.NewInt(0)
+		return nil
+	}
+
+	var err error
+	details.NodeShareOfBalance, details.UserShareOfBalance, err = calculateMinipoolShares(details, networkDetails, details.DistributableBalance)
+	return err
+}
+
+// Calculate the node and user shares of the total minipool balances, including the portion on the Beacon chain, without calling the minipools.
+// This fills the same fields as CalculateCompleteMinipoolShares.
+func CalculateCompleteMinipoolSharesOffchain(networkDetails *NetworkDetails, minipoolDetails []*NativeMinipoolDetails, beaconBalances []*big.Int) error {
+	if len(beaconBalances) != len(minipoolDetails) {
+		return fmt.Errorf("got %d Beacon balances for %d minipools", len(beaconBalances), len(minipoolDetails))
+	}
+
+	for i, details := range minipoolDetails {
+		// Calculate the Beacon shares
+		beaconBalance := bigOrZero(beaconBalances[i])
+		if beaconBalance.Sign() > 0 {
+			nodeShare, userShare, err := calculateMinipoolShares(details, networkDetails, beaconBalance)
+			if err != nil {
+				return err
+			}
+			details.NodeShareOfBeaconBalance = nodeShare
+			details.UserShareOfBeaconBalance = userShare
+		} else {
+			details.NodeShareOfBeaconBalance = big.NewInt(0)
+			details.UserShareOfBeaconBalance = big.NewInt(0)
+		}
+
+		// Calculate the total balance
+		totalBalance := big.NewInt(0).Set(beaconBalance)                     // Total balance = beacon balance
+		totalBalance.Add(totalBalance, bigOrZero(details.Balance))           // Add contract balance
+		totalBalance.Sub(totalBalance, bigOrZero(details.NodeRefundBalance)) // Remove node refund
+
+		// Calculate the node and user shares
+		if totalBalance.Sign() > 0 {
+			nodeShare, userShare, err := calculateMinipoolShares(details, networkDetails, totalBalance)
+			if err != nil {
+				return err
+			}
+			details.NodeShareOfBalanceIncludingBeacon = nodeShare
+			details.UserShareOfBalanceIncludingBeacon = userShare
+		} else {
+			details.NodeShareOfBalanceIncludingBeacon = big.NewInt(0)
+			details.UserShareOfBalanceIncludingBeacon = big.NewInt(0)
+		}
+	}
+
+	return nil
+}
+
+// Calculate the node and user shares of each minipool's total balance from the Beacon balances of their validators, without calling the minipools
+func (s *NetworkState) CalculateCompleteMinipoolSharesOffchain(beaconBalances map[types.ValidatorPubkey]*big.Int) error {
+	minipools := make([]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	balances := make([]*big.Int, len(s.MinipoolDetails))
+	for i := range s.MinipoolDetails {
+		minipools[i] = &s.MinipoolDetails[i]
+		balances[i] = big.NewInt(0)
+		if balance, exists := beaconBalances[s.MinipoolDetails[i].Pubkey]; exists && balance != nil {
+			balances[i].Set(balance)
+		}
+	}
+	return CalculateCompleteMinipoolSharesOffchain(s.NetworkDetails, minipools, balances)
+}
+
+// Cross-check the off-chain share math against the minipool contracts for an evenly spaced sample of the minipools, splitting the balance at the same index for each.
+// A sample size of 0 checks every minipool.
+func VerifyMinipoolShares(rp *rocketpool.RocketPool, contracts *NetworkContracts, networkDetails *NetworkDetails, minipoolDetails []*NativeMinipoolDetails, balances []*big.Int, sampleSize int) ([]MinipoolShareMismatch, error) {
+	return VerifyMinipoolSharesContext(context.Background(), rp, contracts, networkDetails, minipoolDetails, balances, sampleSize)
+}
+
+// Cross-check the off-chain share math against the minipool contracts for an evenly spaced sample of the minipools, splitting the balance at the same index for each and using the provided context for network calls.
+// A sample size of 0 checks every minipool.
+func VerifyMinipoolSharesContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, networkDetails *NetworkDetails, minipoolDetails []*NativeMinipoolDetails, balances []*big.Int, sampleSize int) ([]MinipoolShareMismatch, error) {
+	if len(balances) != len(minipoolDetails) {
+		return nil, fmt.Errorf("got %d balances for %d minipools", len(balances), len(minipoolDetails))
+	}
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
+	}
+
+	// Query the contracts for the sample
+	type shareCheck struct {
+		details   *NativeMinipoolDetails
+		balance   *big.Int
+		nodeShare *multicall.Query[*big.Int]
+		userShare *multicall.Query[*big.Int]
+	}
+	checks := []shareCheck{}
+	mc := contracts.Multicaller.NewCaller()
+	for _, i := range sampleIndices(len(minipoolDetails), sampleSize) {
+		details := minipoolDetails[i]
+		balance := bigOrZero(balances[i])
+		if balance.Sign() < 0 {
+			continue
+		}
+		mp, err := minipool.NewMinipoolFromVersion(rp, details.MinipoolAddress, details.Version, opts)
+		if err != nil {
+			return nil, err
+		}
+		mpContract := mp.GetContract()
+		checks = append(checks, shareCheck{
+			details:   details,
+			balance:   balance,
+			nodeShare: multicall.Add[*big.Int](mc, mpContract, "calculateNodeShare", balance),
+			userShare: multicall.Add[*big.Int](mc, mpContract, "calculateUserShare", balance),
+		})
+	}
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
+		return nil, fmt.Errorf("error calculating minipool shares: %w", err)
+	}
+
+	// Compare them to the off-chain math
+	mismatches := []MinipoolShareMismatch{}
+	for _, check := range checks {
+		nodeShare, userShare, err := calculateMinipoolShares(check.details, networkDetails, check.balance)
+		if err != nil {
+			return nil, err
+		}
+		if !bigEqual(nodeShare, check.nodeShare.Value()) || !bigEqual(userShare, check.userShare.Value()) {
+			mismatches = append(mismatches, MinipoolShareMismatch{
+				MinipoolAddress:   check.details.MinipoolAddress,
+				Balance:           check.balance,
+				OffchainNodeShare: nodeShare,
+				OffchainUserShare: userShare,
+				OnchainNodeShare:  check.nodeShare.Value(),
+				OnchainUserShare:  check.userShare.Value(),
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+// Calculate the node and user shares of a minipool balance
+func calculateMinipoolShares(details *NativeMinipoolDetails, networkDetails *NetworkDetails, balance *big.Int) (*big.Int, *big.Int, error) {
+	nodeShare, err := CalculateMinipoolNodeShare(details, networkDetails, balance)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodeShare, big.NewInt(0).Sub(balance, nodeShare), nil
+}
+
+// The node share before penalties for the Atlas delegate, which splits rewards by the capital each side provided
+func calculateNodeShare_Atlas(details *NativeMinipoolDetails, balance *big.Int) (*big.Int, error) {
+	userCapital := bigOrZero(details.UserDepositBalance)
+	nodeCapital := bigOrZero(details.NodeDepositBalance)
+	capital := big.NewInt(0).Add(userCapital, nodeCapital)
+
+	nodeShare := big.NewInt(0)
+	if balance.Cmp(capital) > 0 {
+		if capital.Sign() == 0 {
+			// The contract reverts with a division by zero here
+			return nil, fmt.Errorf("minipool %s has no capital to split rewards by", details.MinipoolAddress.Hex())
+		}
+
+		// Node share = node capital + node portion of the rewards + commission on the user portion
+		rewards := big.NewInt(0).Sub(balance, capital)
+		nodePortion := big.NewInt(0).Mul(rewards, nodeCapital)
+		nodePortion.Div(nodePortion, capital)
+		userPortion := big.NewInt(0).Sub(rewards, nodePortion)
+		commission := userPortion.Mul(userPortion, bigOrZero(details.NodeFee))
+		commission.Div(commission, calcBase)
+		nodeShare.Add(nodeCapital, nodePortion)
+		nodeShare.Add(nodeShare, commission)
+	} else if balance.Cmp(userCapital) > 0 {
+		// The node covers any losses first
+		nodeShare.Sub(balance, userCapital)
+	}
+	return nodeShare, nil
+}
+
+// The node share before penalties for pre-Atlas delegates, which split rewards in half (or give them all to the user for unbonded minipools)
+func calculateNodeShare_Legacy(details *NativeMinipoolDetails, balance *big.Int) *big.Int {
+	userAmount := big.NewInt(0).Set(bigOrZero(details.UserDepositBalance))
+	if userAmount.Cmp(balance) > 0 {
+		// The node was slashed below the user's deposit, so none of the balance belongs to it
+		return big.NewInt(0)
+	}
+
+	if balance.Cmp(legacyLaunchBalance) > 0 {
+		totalRewards := big.NewInt(0).Sub(balance, legacyLaunchBalance)
+		halfRewards := big.NewInt(0).Div(totalRewards, two)
+		commission := big.NewInt(0).Mul(halfRewards, bigOrZero(details.NodeFee))
+		commission.Div(commission, calcBase)
+		if details.DepositType == types.Empty {
+			userAmount.Add(userAmount, totalRewards.Sub(totalRewards, commission))
+		} else {
+			userAmount.Add(userAmount, halfRewards.Sub(halfRewards, commission))
+		}
+	}
+	return big.NewInt(0).Sub(balance, userAmount)
+}
+
+// Get the indices of an evenly spaced sample of a list; a size of 0 or more than the list's length gets every index
+func sampleIndices(length int, size int) []int {
+	if size <= 0 || size > length {
+		size = length
+	}
+	indices := make([]int, size)
+	for i := range indices {
+		indices[i] = i * length / size
+	}
+	return indices
+}
+
+// Get a value, or zero if it's nil
+func bigOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return zero
+	}
+	return value
+}
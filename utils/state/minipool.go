@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -11,14 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"golang.org/x/sync/errgroup"
-)
-
-const (
-	minipoolBatchSize              int = 100
-	minipoolCompleteShareBatchSize int = 500
-	minipoolAddressBatchSize       int = 1000
-	minipoolVersionBatchSize       int = 500
 )
 
 // Complete details for a minipool
@@ -74,8 +67,14 @@ type NativeMinipoolDetails struct {
 
 // Gets the details for a minipool using the efficient multicall contract
 func GetNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolAddress common.Address) (NativeMinipoolDetails, error) {
+	return GetNativeMinipoolDetailsContext(context.Background(), rp, contracts, minipoolAddress)
+}
+
+// Gets the details for a minipool using the efficient multicall contract and the provided context
+func GetNativeMinipoolDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolAddress common.Address) (NativeMinipoolDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	details := NativeMinipoolDetails{}
@@ -86,12 +85,16 @@ func GetNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContr
 		return NativeMinipoolDetails{}, fmt.Errorf("error getting minipool version: %w", err)
 	}
 	details.Version = version
-	addMinipoolDetailsCalls(rp, contracts, contracts.Multicaller, &details, opts)
+	setters := fieldSetters{}
+	if err := addMinipoolDetailsCalls(rp, contracts, contracts.Multicaller, &details, &setters, opts); err != nil {
+		return NativeMinipoolDetails{}, fmt.Errorf("error adding minipool details calls: %w", err)
+	}
 
 	_, err = contracts.Multicaller.FlexibleCall(true, opts)
 	if err != nil {
 		return NativeMinipoolDetails{}, fmt.Errorf("error executing multicall: %w", err)
 	}
+	setters.run()
 
 	fixupMinipoolDetails(rp, &details, opts)
 
@@ -100,8 +103,14 @@ func GetNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContr
 
 // Gets the minpool details for a node using the efficient multicall contract
 func GetNodeNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) ([]NativeMinipoolDetails, error) {
+	return GetNodeNativeMinipoolDetailsContext(context.Background(), rp, contracts, nodeAddress)
+}
+
+// Gets the minpool details for a node using the efficient multicall contract and the provided context
+func GetNodeNativeMinipoolDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) ([]NativeMinipoolDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	// Get the list of minipool addresses for this node
@@ -122,8 +131,14 @@ func GetNodeNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkC
 
 // Gets all minpool details using the efficient multicall contract
 func GetAllNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) ([]NativeMinipoolDetails, error) {
+	return GetAllNativeMinipoolDetailsContext(context.Background(), rp, contracts)
+}
+
+// Gets all minpool details using the efficient multicall contract and the provided context
+func GetAllNativeMinipoolDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts) ([]NativeMinipoolDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	// Get the list of all minipool addresses
@@ -144,72 +159,57 @@ func GetAllNativeMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkCo
 
 // Calculate the node and user shares of the total minipool balance, including the portion on the Beacon chain
 func CalculateCompleteMinipoolShares(rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolDetails []*NativeMinipoolDetails, beaconBalances []*big.Int) error {
+	return CalculateCompleteMinipoolSharesContext(context.Background(), rp, contracts, minipoolDetails, beaconBalances)
+}
+
+// Calculate the node and user shares of the total minipool balance, including the portion on the Beacon chain, using the provided context
+func CalculateCompleteMinipoolSharesContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, minipoolDetails []*NativeMinipoolDetails, beaconBalances []*big.Int) error {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-	count := len(minipoolDetails)
-	for i := 0; i < count; i += minipoolCompleteShareBatchSize {
-		i := i
-		max := i + minipoolCompleteShareBatchSize
-		if max > count {
-			max = count
+	mc := contracts.Multicaller.NewCaller()
+	setters := fieldSetters{}
+	for i := range minipoolDetails {
+
+		// Make the minipool contract
+		details := minipoolDetails[i]
+		mp, err := minipool.NewMinipoolFromVersion(rp, details.MinipoolAddress, details.Version, opts)
+		if err != nil {
+			return err
+		}
+		mpContract := mp.GetContract()
+
+		// Calculate the Beacon shares
+		beaconBalance := big.NewInt(0).Set(beaconBalances[i])
+		if beaconBalance.Cmp(zero) > 0 {
+			setField(&setters, &details.NodeShareOfBeaconBalance, multicall.Add[*big.Int](mc, mpContract, "calculateNodeShare", beaconBalance))
+			setField(&setters, &details.UserShareOfBeaconBalance, multicall.Add[*big.Int](mc, mpContract, "calculateUserShare", beaconBalance))
+		} else {
+			details.NodeShareOfBeaconBalance = big.NewInt(0)
+			details.UserShareOfBeaconBalance = big.NewInt(0)
 		}
 
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-
-				// Make the minipool contract
-				details := minipoolDetails[j]
-				mp, err := minipool.NewMinipoolFromVersion(rp, details.MinipoolAddress, details.Version, opts)
-				if err != nil {
-					return err
-				}
-				mpContract := mp.GetContract()
-
-				// Calculate the Beacon shares
-				beaconBalance := big.NewInt(0).Set(beaconBalances[j])
-				if beaconBalance.Cmp(zero) > 0 {
-					mc.AddCall(mpContract, &details.NodeShareOfBeaconBalance, "calculateNodeShare", beaconBalance)
-					mc.AddCall(mpContract, &details.UserShareOfBeaconBalance, "calculateUserShare", beaconBalance)
-				} else {
-					details.NodeShareOfBeaconBalance = big.NewInt(0)
-					details.UserShareOfBeaconBalance = big.NewInt(0)
-				}
-
-				// Calculate the total balance
-				totalBalance := big.NewInt(0).Set(beaconBalances[j])      // Total balance = beacon balance
-				totalBalance.Add(totalBalance, details.Balance)           // Add contract balance
-				totalBalance.Sub(totalBalance, details.NodeRefundBalance) // Remove node refund
-
-				// Calculate the node and user shares
-				if totalBalance.Cmp(zero) > 0 {
-					mc.AddCall(mpContract, &details.NodeShareOfBalanceIncludingBeacon, "calculateNodeShare", totalBalance)
-					mc.AddCall(mpContract, &details.UserShareOfBalanceIncludingBeacon, "calculateUserShare", totalBalance)
-				} else {
-					details.NodeShareOfBalanceIncludingBeacon = big.NewInt(0)
-					details.UserShareOfBalanceIncludingBeacon = big.NewInt(0)
-				}
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-
-			return nil
-		})
-	}
-
-	if err := wg.Wait(); err != nil {
+		// Calculate the total balance
+		totalBalance := big.NewInt(0).Set(beaconBalances[i])      // Total balance = beacon balance
+		totalBalance.Add(totalBalance, details.Balance)           // Add contract balance
+		totalBalance.Sub(totalBalance, details.NodeRefundBalance) // Remove node refund
+
+		// Calculate the node and user shares
+		if totalBalance.Cmp(zero) > 0 {
+			setField(&setters, &details.NodeShareOfBalanceIncludingBeacon, multicall.Add[*big.Int](mc, mpContract, "calculateNodeShare", totalBalance))
+			setField(&setters, &details.UserShareOfBalanceIncludingBeacon, multicall.Add[*big.Int](mc, mpContract, "calculateUserShare", totalBalance))
+		} else {
+			details.NodeShareOfBalanceIncludingBeacon = big.NewInt(0)
+			details.UserShareOfBalanceIncludingBeacon = big.NewInt(0)
+		}
+	}
+	_, err := mc.FlexibleCall(true, opts)
+	if err != nil {
 		return fmt.Errorf("error calculating minipool shares: %w", err)
 	}
+	setters.run()
 
 	return nil
 }
@@ -222,42 +222,17 @@ func getNodeMinipoolAddressesFast(rp *rocketpool.RocketPool, contracts *NetworkC
 		return []common.Address{}, err
 	}
 
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-	addresses := make([]common.Address, minipoolCount)
-
-	// Run the getters in batches
-	count := int(minipoolCount)
-	for i := 0; i < count; i += minipoolAddressBatchSize {
-		i := i
-		max := i + minipoolAddressBatchSize
-		if max > count {
-			max = count
-		}
-
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				mc.AddCall(contracts.RocketMinipoolManager, &addresses[j], "getNodeMinipoolAt", nodeAddress, big.NewInt(int64(j)))
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
-	}
-
-	if err := wg.Wait(); err != nil {
+	// Run the getters
+	mc := contracts.Multicaller.NewCaller()
+	addresses := make([]*multicall.Query[common.Address], minipoolCount)
+	for i := range addresses {
+		addresses[i] = multicall.Add[common.Address](mc, contracts.RocketMinipoolManager, "getNodeMinipoolAt", nodeAddress, big.NewInt(int64(i)))
+	}
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting minipool addresses for node %s: %w", nodeAddress.Hex(), err)
 	}
 
-	return addresses, nil
+	return multicall.Values(addresses), nil
 }
 
 // Get all minipool addresses using the multicaller
@@ -268,89 +243,46 @@ func getAllMinipoolAddressesFast(rp *rocketpool.RocketPool, contracts *NetworkCo
 		return []common.Address{}, err
 	}
 
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-	addresses := make([]common.Address, minipoolCount)
-
-	// Run the getters in batches
-	count := int(minipoolCount)
-	for i := 0; i < count; i += minipoolAddressBatchSize {
-		i := i
-		max := i + minipoolAddressBatchSize
-		if max > count {
-			max = count
-		}
+	return getMinipoolAddressRange(contracts, 0, minipoolCount, opts)
+}
 
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				mc.AddCall(contracts.RocketMinipoolManager, &addresses[j], "getMinipoolAt", big.NewInt(int64(j)))
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
+// Get the addresses of the minipools from the start index up to (but not including) the end index using the multicaller
+func getMinipoolAddressRange(contracts *NetworkContracts, start uint64, end uint64, opts *bind.CallOpts) ([]common.Address, error) {
+	mc := contracts.Multicaller.NewCaller()
+	addresses := []*multicall.Query[common.Address]{}
+	for i := start; i < end; i++ {
+		addresses = append(addresses, multicall.Add[common.Address](mc, contracts.RocketMinipoolManager, "getMinipoolAt", big.NewInt(0).SetUint64(i)))
 	}
-
-	if err := wg.Wait(); err != nil {
-		return nil, fmt.Errorf("error getting all minipool addresses: %w", err)
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
+		return nil, fmt.Errorf("error getting minipool addresses: %w", err)
 	}
 
-	return addresses, nil
+	return multicall.Values(addresses), nil
 }
 
 // Get minipool versions using the multicaller
 func getMinipoolVersionsFast(rp *rocketpool.RocketPool, contracts *NetworkContracts, addresses []common.Address, opts *bind.CallOpts) ([]uint8, error) {
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-
-	// Run the getters in batches
-	count := len(addresses)
-	versions := make([]uint8, count)
-	for i := 0; i < count; i += minipoolVersionBatchSize {
-		i := i
-		max := i + minipoolVersionBatchSize
-		if max > count {
-			max = count
+	// Run the getters
+	mc := contracts.Multicaller.NewCaller()
+	queries := make([]*multicall.Query[uint8], len(addresses))
+	for i, address := range addresses {
+		contract, err := rocketpool.GetRocketVersionContractForAddress(rp, address)
+		if err != nil {
+			return nil, fmt.Errorf("error creating version contract for minipool %s: %w", address.Hex(), err)
 		}
-
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				contract, err := rocketpool.GetRocketVersionContractForAddress(rp, addresses[j])
-				if err != nil {
-					return fmt.Errorf("error creating version contract for minipool %s: %w", addresses[j].Hex(), err)
-				}
-				mc.AddCall(contract, &versions[j], "version")
-			}
-			results, err := mc.FlexibleCall(false, opts) // Allow calls to fail - necessary for Prater
-			for j, result := range results {
-				if !result.Success {
-					versions[j+i] = 1 // Anything that failed the version check didn't have the method yet so it must be v1
-				}
-			}
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
-	}
-
-	if err := wg.Wait(); err != nil {
+		queries[i] = multicall.AddOptional[uint8](mc, contract, "version") // Allow calls to fail - necessary for Prater
+	}
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting minipool versions: %w", err)
 	}
+	versions := make([]uint8, len(queries))
+	for i, query := range queries {
+		if query.Succeeded() {
+			versions[i] = query.Value()
+		} else {
+			versions[i] = 1 // Anything that failed the version check didn't have the method yet so it must be v1
+		}
+	}
 
 	return versions, nil
 }
@@ -369,77 +301,34 @@ func getBulkMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContrac
 	}
 
 	// Round 1: most of the details
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-	count := len(addresses)
-	for i := 0; i < count; i += minipoolBatchSize {
-		i := i
-		max := i + minipoolBatchSize
-		if max > count {
-			max = count
+	mc := contracts.Multicaller.NewCaller()
+	setters := fieldSetters{}
+	for i, address := range addresses {
+		details := &minipoolDetails[i]
+		details.MinipoolAddress = address
+		details.Version = versions[i]
+
+		if err := addMinipoolDetailsCalls(rp, contracts, mc, details, &setters, opts); err != nil {
+			return nil, fmt.Errorf("error adding details calls for minipool %s: %w", address.Hex(), err)
 		}
-
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-
-				address := addresses[j]
-				details := &minipoolDetails[j]
-				details.MinipoolAddress = address
-				details.Version = versions[j]
-
-				addMinipoolDetailsCalls(rp, contracts, mc, details, opts)
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-
-			return nil
-		})
 	}
-
-	if err := wg.Wait(); err != nil {
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting minipool details r1: %w", err)
 	}
+	setters.run()
 
 	// Round 2: NodeShare and UserShare once the refund amount has been populated
-	var wg2 errgroup.Group
-	wg2.SetLimit(threadLimit)
-	for i := 0; i < count; i += minipoolBatchSize {
-		i := i
-		max := i + minipoolBatchSize
-		if max > count {
-			max = count
+	setters = fieldSetters{}
+	for i := range minipoolDetails {
+		details := &minipoolDetails[i]
+		if err := addMinipoolShareCalls(rp, contracts, mc, details, &setters, opts); err != nil {
+			return nil, fmt.Errorf("error adding share calls for minipool %s: %w", details.MinipoolAddress.Hex(), err)
 		}
-
-		wg2.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				details := &minipoolDetails[j]
-				details.Version = versions[j]
-				addMinipoolShareCalls(rp, contracts, mc, details, opts)
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-
-			return nil
-		})
-	}
-
-	if err := wg2.Wait(); err != nil {
+	}
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting minipool details r2: %w", err)
 	}
+	setters.run()
 
 	// Postprocess the minipools
 	for i := range minipoolDetails {
@@ -449,8 +338,8 @@ func getBulkMinipoolDetails(rp *rocketpool.RocketPool, contracts *NetworkContrac
 	return minipoolDetails, nil
 }
 
-// Add all of the calls for the minipool details to the multicaller
-func addMinipoolDetailsCalls(rp *rocketpool.RocketPool, contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeMinipoolDetails, opts *bind.CallOpts) error {
+// Add all of the calls for the minipool details to the multicaller, with setters that copy their outputs into the details
+func addMinipoolDetailsCalls(rp *rocketpool.RocketPool, contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeMinipoolDetails, setters *fieldSetters, opts *bind.CallOpts) error {
 	// Create the minipool contract binding
 	address := details.MinipoolAddress
 	mp, err := minipool.NewMinipoolFromVersion(rp, address, details.Version, opts)
@@ -460,26 +349,30 @@ func addMinipoolDetailsCalls(rp *rocketpool.RocketPool, contracts *NetworkContra
 	mpContract := mp.GetContract()
 
 	details.Version = mp.GetVersion()
-	mc.AddCall(contracts.RocketMinipoolManager, &details.Exists, "getMinipoolExists", address)
-	mc.AddCall(contracts.RocketMinipoolManager, &details.Pubkey, "getMinipoolPubkey", address)
-	mc.AddCall(contracts.RocketMinipoolManager, &details.WithdrawalCredentials, "getMinipoolWithdrawalCredentials", address)
-	mc.AddCall(contracts.RocketMinipoolManager, &details.Slashed, "getMinipoolRPLSlashed", address)
-	mc.AddCall(mpContract, &details.StatusRaw, "getStatus")
-	mc.AddCall(mpContract, &details.StatusBlock, "getStatusBlock")
-	mc.AddCall(mpContract, &details.StatusTime, "getStatusTime")
-	mc.AddCall(mpContract, &details.Finalised, "getFinalised")
-	mc.AddCall(mpContract, &details.NodeFee, "getNodeFee")
-	mc.AddCall(mpContract, &details.NodeDepositBalance, "getNodeDepositBalance")
-	mc.AddCall(mpContract, &details.NodeDepositAssigned, "getNodeDepositAssigned")
-	mc.AddCall(mpContract, &details.UserDepositBalance, "getUserDepositBalance")
-	mc.AddCall(mpContract, &details.UserDepositAssigned, "getUserDepositAssigned")
-	mc.AddCall(mpContract, &details.UserDepositAssignedTime, "getUserDepositAssignedTime")
-	mc.AddCall(mpContract, &details.UseLatestDelegate, "getUseLatestDelegate")
-	mc.AddCall(mpContract, &details.Delegate, "getDelegate")
-	mc.AddCall(mpContract, &details.PreviousDelegate, "getPreviousDelegate")
-	mc.AddCall(mpContract, &details.EffectiveDelegate, "getEffectiveDelegate")
-	mc.AddCall(mpContract, &details.NodeAddress, "getNodeAddress")
-	mc.AddCall(mpContract, &details.NodeRefundBalance, "getNodeRefundBalance")
+	setField(setters, &details.Exists, multicall.Add[bool](mc, contracts.RocketMinipoolManager, "getMinipoolExists", address))
+	pubkey := multicall.Add[[]byte](mc, contracts.RocketMinipoolManager, "getMinipoolPubkey", address)
+	withdrawalCredentials := multicall.Add[[]byte](mc, contracts.RocketMinipoolManager, "getMinipoolWithdrawalCredentials", address)
+	*setters = append(*setters, func() {
+		details.Pubkey = types.BytesToValidatorPubkey(pubkey.Value())
+		details.WithdrawalCredentials = common.BytesToHash(withdrawalCredentials.Value())
+	})
+	setField(setters, &details.Slashed, multicall.Add[bool](mc, contracts.RocketMinipoolManager, "getMinipoolRPLSlashed", address))
+	setField(setters, &details.StatusRaw, multicall.Add[uint8](mc, mpContract, "getStatus"))
+	setField(setters, &details.StatusBlock, multicall.Add[*big.Int](mc, mpContract, "getStatusBlock"))
+	setField(setters, &details.StatusTime, multicall.Add[*big.Int](mc, mpContract, "getStatusTime"))
+	setField(setters, &details.Finalised, multicall.Add[bool](mc, mpContract, "getFinalised"))
+	setField(setters, &details.NodeFee, multicall.Add[*big.Int](mc, mpContract, "getNodeFee"))
+	setField(setters, &details.NodeDepositBalance, multicall.Add[*big.Int](mc, mpContract, "getNodeDepositBalance"))
+	setField(setters, &details.NodeDepositAssigned, multicall.Add[bool](mc, mpContract, "getNodeDepositAssigned"))
+	setField(setters, &details.UserDepositBalance, multicall.Add[*big.Int](mc, mpContract, "getUserDepositBalance"))
+	setField(setters, &details.UserDepositAssigned, multicall.Add[bool](mc, mpContract, "getUserDepositAssigned"))
+	setField(setters, &details.UserDepositAssignedTime, multicall.Add[*big.Int](mc, mpContract, "getUserDepositAssignedTime"))
+	setField(setters, &details.UseLatestDelegate, multicall.Add[bool](mc, mpContract, "getUseLatestDelegate"))
+	setField(setters, &details.Delegate, multicall.Add[common.Address](mc, mpContract, "getDelegate"))
+	setField(setters, &details.PreviousDelegate, multicall.Add[common.Address](mc, mpContract, "getPreviousDelegate"))
+	setField(setters, &details.EffectiveDelegate, multicall.Add[common.Address](mc, mpContract, "getEffectiveDelegate"))
+	setField(setters, &details.NodeAddress, multicall.Add[common.Address](mc, mpContract, "getNodeAddress"))
+	setField(setters, &details.NodeRefundBalance, multicall.Add[*big.Int](mc, mpContract, "getNodeRefundBalance"))
 
 	if details.Version < 3 {
 		// These fields are all v3+ only
@@ -493,38 +386,60 @@ func addMinipoolDetailsCalls(rp *rocketpool.RocketPool, contracts *NetworkContra
 		details.ReduceBondValue = big.NewInt(0)
 		details.PreMigrationBalance = big.NewInt(0)
 	} else {
-		mc.AddCall(mpContract, &details.UserDistributed, "getUserDistributed")
-		mc.AddCall(mpContract, &details.IsVacant, "getVacant")
-		mc.AddCall(mpContract, &details.PreMigrationBalance, "getPreMigrationBalance")
+		setField(setters, &details.UserDistributed, multicall.Add[bool](mc, mpContract, "getUserDistributed"))
+		setField(setters, &details.IsVacant, multicall.Add[bool](mc, mpContract, "getVacant"))
+		setField(setters, &details.PreMigrationBalance, multicall.Add[*big.Int](mc, mpContract, "getPreMigrationBalance"))
 
 		// If minipool v3 exists, RocketMinipoolBondReducer exists so this is safe
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.ReduceBondTime, "getReduceBondTime", address)
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.ReduceBondCancelled, "getReduceBondCancelled", address)
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.LastBondReductionTime, "getLastBondReductionTime", address)
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.LastBondReductionPrevValue, "getLastBondReductionPrevValue", address)
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.LastBondReductionPrevNodeFee, "getLastBondReductionPrevNodeFee", address)
-		mc.AddCall(contracts.RocketMinipoolBondReducer, &details.ReduceBondValue, "getReduceBondValue", address)
+		setField(setters, &details.ReduceBondTime, multicall.Add[*big.Int](mc, contracts.RocketMinipoolBondReducer, "getReduceBondTime", address))
+		setField(setters, &details.ReduceBondCancelled, multicall.Add[bool](mc, contracts.RocketMinipoolBondReducer, "getReduceBondCancelled", address))
+		setField(setters, &details.LastBondReductionTime, multicall.Add[*big.Int](mc, contracts.RocketMinipoolBondReducer, "getLastBondReductionTime", address))
+		setField(setters, &details.LastBondReductionPrevValue, multicall.Add[*big.Int](mc, contracts.RocketMinipoolBondReducer, "getLastBondReductionPrevValue", address))
+		setField(setters, &details.LastBondReductionPrevNodeFee, multicall.Add[*big.Int](mc, contracts.RocketMinipoolBondReducer, "getLastBondReductionPrevNodeFee", address))
+		setField(setters, &details.ReduceBondValue, multicall.Add[*big.Int](mc, contracts.RocketMinipoolBondReducer, "getReduceBondValue", address))
 	}
 
 	penaltyCountKey := crypto.Keccak256Hash([]byte("network.penalties.penalty"), address.Bytes())
-	mc.AddCall(contracts.RocketStorage, &details.PenaltyCount, "getUint", penaltyCountKey)
+	setField(setters, &details.PenaltyCount, multicall.Add[*big.Int](mc, contracts.RocketStorage, "getUint", penaltyCountKey))
 
 	penaltyRatekey := crypto.Keccak256Hash([]byte("minipool.penalty.rate"), address.Bytes())
-	mc.AddCall(contracts.RocketStorage, &details.PenaltyRate, "getUint", penaltyRatekey)
+	setField(setters, &details.PenaltyRate, multicall.Add[*big.Int](mc, contracts.RocketStorage, "getUint", penaltyRatekey))
 
-	if contracts._isAtlasDeployed() {
-		// Query the minipool manager using the delegate-invariant function
-		mc.AddCall(contracts.RocketMinipoolManager, &details.DepositTypeRaw, "getMinipoolDepositType", address)
-	} else {
-		// Fallback to querying the minipool
-		mc.AddCall(mpContract, &details.DepositTypeRaw, "getDepositType")
+	for _, calls := range minipoolFeatureCalls {
+		add := calls.fallback
+		if contracts.Features.Has(calls.feature) {
+			add = calls.add
+		}
+		add(contracts, mc, mpContract, details, setters)
 	}
 
 	return nil
 }
 
-// Add the calls for the minipool node and user share to the multicaller
-func addMinipoolShareCalls(rp *rocketpool.RocketPool, contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeMinipoolDetails, opts *bind.CallOpts) error {
+// Adds feature-specific calls for a minipool's details to the multicaller
+type minipoolCallSet func(contracts *NetworkContracts, mc *multicall.MultiCaller, mpContract *rocketpool.Contract, details *NativeMinipoolDetails, setters *fieldSetters)
+
+// The minipool calls that depend on the network's features
+var minipoolFeatureCalls = []struct {
+	feature  Feature
+	add      minipoolCallSet // Used if the network has the feature
+	fallback minipoolCallSet // Used if it doesn't
+}{
+	{
+		feature: FeatureAtlas,
+		add: func(contracts *NetworkContracts, mc *multicall.MultiCaller, mpContract *rocketpool.Contract, details *NativeMinipoolDetails, setters *fieldSetters) {
+			// Query the minipool manager using the delegate-invariant function
+			setField(setters, &details.DepositTypeRaw, multicall.Add[uint8](mc, contracts.RocketMinipoolManager, "getMinipoolDepositType", details.MinipoolAddress))
+		},
+		fallback: func(contracts *NetworkContracts, mc *multicall.MultiCaller, mpContract *rocketpool.Contract, details *NativeMinipoolDetails, setters *fieldSetters) {
+			// Fallback to querying the minipool
+			setField(setters, &details.DepositTypeRaw, multicall.Add[uint8](mc, mpContract, "getDepositType"))
+		},
+	},
+}
+
+// Add the calls for the minipool node and user share to the multicaller, with setters that copy their outputs into the details
+func addMinipoolShareCalls(rp *rocketpool.RocketPool, contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeMinipoolDetails, setters *fieldSetters, opts *bind.CallOpts) error {
 	// Create the minipool contract binding
 	address := details.MinipoolAddress
 	mp, err := minipool.NewMinipoolFromVersion(rp, address, details.Version, opts)
@@ -535,8 +450,8 @@ func addMinipoolShareCalls(rp *rocketpool.RocketPool, contracts *NetworkContract
 
 	details.DistributableBalance = big.NewInt(0).Sub(details.Balance, details.NodeRefundBalance)
 	if details.DistributableBalance.Cmp(zero) >= 0 {
-		mc.AddCall(mpContract, &details.NodeShareOfBalance, "calculateNodeShare", details.DistributableBalance)
-		mc.AddCall(mpContract, &details.UserShareOfBalance, "calculateUserShare", details.DistributableBalance)
+		setField(setters, &details.NodeShareOfBalance, multicall.Add[*big.Int](mc, mpContract, "calculateNodeShare", details.DistributableBalance))
+		setField(setters, &details.UserShareOfBalance, multicall.Add[*big.Int](mc, mpContract, "calculateUserShare", details.DistributableBalance))
 	} else {
 		details.NodeShareOfBalance = big.NewInt(0)
 		details.UserShareOfBalance = big.NewInt(0)
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Fields that aren't diffed because they're duplicated by their typed versions
+var diffIgnoredFields = map[string]bool{
+	"StatusRaw":      true,
+	"DepositTypeRaw": true,
+}
+
+// A change to a single field
+type FieldChange struct {
+	Field string      `json:"field"` // The name of the field, with nested fields separated by dots
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// The changes to a node's details
+type NodeDiff struct {
+	NodeAddress common.Address `json:"nodeAddress"`
+	Changes     []FieldChange  `json:"changes"`
+}
+
+// The changes to a minipool's details
+type MinipoolDiff struct {
+	MinipoolAddress common.Address `json:"minipoolAddress"`
+	NodeAddress     common.Address `json:"nodeAddress"`
+	Changes         []FieldChange  `json:"changes"`
+}
+
+// The differences between two network state snapshots
+type NetworkStateDiff struct {
+	FromBlock        uint64           `json:"fromBlock"`
+	ToBlock          uint64           `json:"toBlock"`
+	NetworkChanges   []FieldChange    `json:"networkChanges"`
+	AddedNodes       []common.Address `json:"addedNodes"`
+	RemovedNodes     []common.Address `json:"removedNodes"`
+	ChangedNodes     []NodeDiff       `json:"changedNodes"`
+	AddedMinipools   []common.Address `json:"addedMinipools"`
+	RemovedMinipools []common.Address `json:"removedMinipools"`
+	ChangedMinipools []MinipoolDiff   `json:"changedMinipools"`
+}
+
+// Get the differences between two network state snapshots.
+// Entities are reported in the order they appear in the snapshots, so the diff is deterministic.
+func DiffNetworkStates(old *NetworkState, new *NetworkState) *NetworkStateDiff {
+	diff := &NetworkStateDiff{
+		FromBlock:        old.ElBlockNumber,
+		ToBlock:          new.ElBlockNumber,
+		NetworkChanges:   DiffNetworkDetails(old.NetworkDetails, new.NetworkDetails),
+		AddedNodes:       []common.Address{},
+		RemovedNodes:     []common.Address{},
+		ChangedNodes:     []NodeDiff{},
+		AddedMinipools:   []common.Address{},
+		RemovedMinipools: []common.Address{},
+		ChangedMinipools: []MinipoolDiff{},
+	}
+
+	// Diff the nodes
+	for i := range old.NodeDetails {
+		oldDetails := &old.NodeDetails[i]
+		newDetails, exists := new.GetNode(oldDetails.NodeAddress)
+		if !exists {
+			diff.RemovedNodes = append(diff.RemovedNodes, oldDetails.NodeAddress)
+			continue
+		}
+		if changes := DiffNodeDetails(oldDetails, newDetails); len(changes) > 0 {
+			diff.ChangedNodes = append(diff.ChangedNodes, NodeDiff{
+				NodeAddress: oldDetails.NodeAddress,
+				Changes:     changes,
+			})
+		}
+	}
+	for _, details := range new.NodeDetails {
+		if _, exists := old.GetNode(details.NodeAddress); !exists {
+			diff.AddedNodes = append(diff.AddedNodes, details.NodeAddress)
+		}
+	}
+
+	// Diff the minipools
+	for i := range old.MinipoolDetails {
+		oldDetails := &old.MinipoolDetails[i]
+		newDetails, exists := new.GetMinipool(oldDetails.MinipoolAddress)
+		if !exists {
+			diff.RemovedMinipools = append(diff.RemovedMinipools, oldDetails.MinipoolAddress)
+			continue
+		}
+		if changes := DiffMinipoolDetails(oldDetails, newDetails); len(changes) > 0 {
+			diff.ChangedMinipools = append(diff.ChangedMinipools, MinipoolDiff{
+				MinipoolAddress: oldDetails.MinipoolAddress,
+				NodeAddress:     newDetails.NodeAddress,
+				Changes:         changes,
+			})
+		}
+	}
+	for _, details := range new.MinipoolDetails {
+		if _, exists := old.GetMinipool(details.MinipoolAddress); !exists {
+			diff.AddedMinipools = append(diff.AddedMinipools, details.MinipoolAddress)
+		}
+	}
+
+	return diff
+}
+
+// Check if nothing changed between the snapshots
+func (d *NetworkStateDiff) IsEmpty() bool {
+	return len(d.NetworkChanges) == 0 &&
+		len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.ChangedNodes) == 0 &&
+		len(d.AddedMinipools) == 0 && len(d.RemovedMinipools) == 0 && len(d.ChangedMinipools) == 0
+}
+
+// Get the changes to the network details
+func DiffNetworkDetails(old *NetworkDetails, new *NetworkDetails) []FieldChange {
+	if old == nil {
+		old = &NetworkDetails{}
+	}
+	if new == nil {
+		new = &NetworkDetails{}
+	}
+	return diffFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem())
+}
+
+// Get the changes to a node's details
+func DiffNodeDetails(old *NativeNodeDetails, new *NativeNodeDetails) []FieldChange {
+	return diffFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem())
+}
+
+// Get the changes to a minipool's details
+func DiffMinipoolDetails(old *NativeMinipoolDetails, new *NativeMinipoolDetails) []FieldChange {
+	return diffFields(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem())
+}
+
+// Get the changes to the exported fields of two structs of the same type
+func diffFields(old reflect.Value, new reflect.Value) []FieldChange {
+	changes := []FieldChange{}
+	addFieldChanges(&changes, "", old, new)
+	return changes
+}
+
+// Add the changes to a struct's fields, descending into nested structs
+func addFieldChanges(changes *[]FieldChange, prefix string, old reflect.Value, new reflect.Value) {
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		if !field.IsExported() || diffIgnoredFields[field.Name] {
+			continue
+		}
+		name := prefix + field.Name
+		oldField := old.Field(i)
+		newField := new.Field(i)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			addFieldChanges(changes, name+".", oldField, newField)
+			continue
+		}
+		if !fieldEqual(oldField, newField) {
+			*changes = append(*changes, FieldChange{
+				Field: name,
+				Old:   oldField.Interface(),
+				New:   newField.Interface(),
+			})
+		}
+	}
+}
+
+// Check if two field values are equal
+func fieldEqual(old reflect.Value, new reflect.Value) bool {
+	switch oldValue := old.Interface().(type) {
+	case *big.Int:
+		return bigEqual(oldValue, new.Interface().(*big.Int))
+	case time.Time:
+		return oldValue.Equal(new.Interface().(time.Time))
+	default:
+		return reflect.DeepEqual(oldValue, new.Interface())
+	}
+}
@@ -0,0 +1,129 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The version of the snapshot encodings; bump this whenever the encoded details change
+const NetworkStateEncodingVersion uint64 = 4
+
+// The encoded contents of a network state
+type networkStateRecord struct {
+	ElBlockNumber          uint64                  `json:"elBlockNumber"`
+	ElBlockHash            common.Hash             `json:"elBlockHash"`
+	Features               FeatureSet              `json:"features"`
+	NetworkDetails         *NetworkDetails         `json:"networkDetails"`
+	TotalEffectiveRPLStake *big.Int                `json:"totalEffectiveRplStake"`
+	NodeDetails            []NativeNodeDetails     `json:"nodeDetails"`
+	MinipoolDetails        []NativeMinipoolDetails `json:"minipoolDetails"`
+}
+
+// The JSON encoding of a network state
+type networkStateJson struct {
+	Version uint64 `json:"version"`
+	networkStateRecord
+}
+
+// Load a network state from either its JSON or binary encoding
+func LoadNetworkState(data []byte) (*NetworkState, error) {
+	state := &NetworkState{}
+	var err error
+	if bytes.HasPrefix(data, binaryMagic) {
+		err = state.UnmarshalBinary(data)
+	} else {
+		err = state.UnmarshalJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// JSON encoding
+func (s *NetworkState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(networkStateJson{
+		Version:            NetworkStateEncodingVersion,
+		networkStateRecord: s.toRecord(),
+	})
+}
+func (s *NetworkState) UnmarshalJSON(data []byte) error {
+	var encoded networkStateJson
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("error decoding network state: %w", err)
+	}
+	if encoded.Version != NetworkStateEncodingVersion {
+		return fmt.Errorf("unsupported network state encoding version %d (expected %d)", encoded.Version, NetworkStateEncodingVersion)
+	}
+	s.fromRecord(encoded.networkStateRecord)
+	return nil
+}
+
+// Binary encoding
+func (s *NetworkState) MarshalBinary() ([]byte, error) {
+	record := s.toRecord()
+	return marshalBinary(&record)
+}
+func (s *NetworkState) UnmarshalBinary(data []byte) error {
+	var record networkStateRecord
+	if err := unmarshalBinary(data, &record); err != nil {
+		return fmt.Errorf("error decoding network state: %w", err)
+	}
+	s.fromRecord(record)
+	return nil
+}
+
+// Binary encoding of the network details
+func (d *NetworkDetails) MarshalBinary() ([]byte, error) {
+	return marshalBinary(d)
+}
+func (d *NetworkDetails) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, d)
+}
+
+// Binary encoding of a node's details
+func (d *NativeNodeDetails) MarshalBinary() ([]byte, error) {
+	return marshalBinary(d)
+}
+func (d *NativeNodeDetails) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, d)
+}
+
+// Binary encoding of a minipool's details
+func (d *NativeMinipoolDetails) MarshalBinary() ([]byte, error) {
+	return marshalBinary(d)
+}
+func (d *NativeMinipoolDetails) UnmarshalBinary(data []byte) error {
+	return unmarshalBinary(data, d)
+}
+
+// Get the encoded contents of the network state
+func (s *NetworkState) toRecord() networkStateRecord {
+	return networkStateRecord{
+		ElBlockNumber:          s.ElBlockNumber,
+		ElBlockHash:            s.ElBlockHash,
+		Features:               s.Features,
+		NetworkDetails:         s.NetworkDetails,
+		TotalEffectiveRPLStake: s.TotalEffectiveRPLStake,
+		NodeDetails:            s.NodeDetails,
+		MinipoolDetails:        s.MinipoolDetails,
+	}
+}
+
+// Restore the network state from its encoded contents; the derived fields were encoded with it, so only the indices are rebuilt
+func (s *NetworkState) fromRecord(record networkStateRecord) {
+	*s = NetworkState{
+		ElBlockNumber:          record.ElBlockNumber,
+		ElBlockHash:            record.ElBlockHash,
+		Features:               record.Features,
+		NetworkDetails:         record.NetworkDetails,
+		TotalEffectiveRPLStake: record.TotalEffectiveRPLStake,
+		NodeDetails:            record.NodeDetails,
+		MinipoolDetails:        record.MinipoolDetails,
+	}
+	s.buildIndices()
+}
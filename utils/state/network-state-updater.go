@@ -0,0 +1,437 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/minipool"
+	"github.com/RedDuck-Software/poolsea-go/node"
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/utils/eth"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Default updater settings
+const (
+	DefaultMaxUpdateBlockGap     uint64 = 7200 // About a day of blocks
+	DefaultUpdateLogBlockRange   uint64 = 1000
+	DefaultUpdateLogAddressLimit int    = 1000
+	minipoolDestroyedEvent       string = "MinipoolDestroyed"
+)
+
+// Network state updater settings; zero values are replaced with the defaults
+type NetworkStateUpdaterSettings struct {
+	MaxBlockGap     uint64 // The most blocks between the previous snapshot and the new one before a full reload is done instead
+	LogBlockRange   uint64 // The most blocks to scan for events in one request
+	LogAddressLimit int    // The most contract addresses to scan for events in one request
+}
+
+// The result of updating a network state
+type NetworkStateUpdate struct {
+	State            *NetworkState
+	FullReload       bool   // True if the state was reloaded from scratch instead of updated
+	FullReloadReason string // Why the state was reloaded from scratch
+	UpdatedNodes     int    // The number of nodes whose details were queried again
+	UpdatedMinipools int    // The number of minipools whose details were queried again
+}
+
+// Updates network state snapshots to newer blocks, re-querying only the nodes and minipools affected by the events in between
+type NetworkStateUpdater struct {
+	rp                    *rocketpool.RocketPool
+	multicallerAddress    common.Address
+	balanceBatcherAddress common.Address
+	settings              NetworkStateUpdaterSettings
+}
+
+// The nodes and minipools that need to be queried again
+type affectedDetails struct {
+	nodes     map[common.Address]bool
+	minipools map[common.Address]bool
+}
+
+// Create a new network state updater
+func NewNetworkStateUpdater(rp *rocketpool.RocketPool, multicallerAddress common.Address, balanceBatcherAddress common.Address, settings NetworkStateUpdaterSettings) *NetworkStateUpdater {
+	if settings.MaxBlockGap == 0 {
+		settings.MaxBlockGap = DefaultMaxUpdateBlockGap
+	}
+	if settings.LogBlockRange == 0 {
+		settings.LogBlockRange = DefaultUpdateLogBlockRange
+	}
+	if settings.LogAddressLimit <= 0 {
+		settings.LogAddressLimit = DefaultUpdateLogAddressLimit
+	}
+	return &NetworkStateUpdater{
+		rp:                    rp,
+		multicallerAddress:    multicallerAddress,
+		balanceBatcherAddress: balanceBatcherAddress,
+		settings:              settings,
+	}
+}
+
+// Update a snapshot of the entire network to a newer block
+func (u *NetworkStateUpdater) Update(previous *NetworkState, blockNumber uint64) (*NetworkStateUpdate, error) {
+	return u.UpdateContext(context.Background(), previous, blockNumber)
+}
+
+// Update a snapshot of the entire network to a newer block, using the provided context for network calls.
+// The previous snapshot isn't modified. Beacon shares are copied from it for minipools that weren't queried again; call CalculateCompleteMinipoolShares to refresh them.
+func (u *NetworkStateUpdater) UpdateContext(ctx context.Context, previous *NetworkState, blockNumber uint64) (*NetworkStateUpdate, error) {
+	if previous == nil {
+		return nil, fmt.Errorf("cannot update a nil network state to block %d", blockNumber)
+	}
+	if blockNumber < previous.ElBlockNumber {
+		return nil, fmt.Errorf("cannot update network state at block %d to earlier block %d", previous.ElBlockNumber, blockNumber)
+	}
+	opts := &bind.CallOpts{
+		BlockNumber: big.NewInt(0).SetUint64(blockNumber),
+		Context:     ctx,
+	}
+	previousOpts := &bind.CallOpts{
+		BlockNumber: big.NewInt(0).SetUint64(previous.ElBlockNumber),
+		Context:     ctx,
+	}
+
+	// Get the contracts at the new block
+	contracts, err := NewNetworkContractsContext(ctx, u.rp, u.multicallerAddress, u.balanceBatcherAddress, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network contracts: %w", err)
+	}
+	if !contracts.Features.Equals(previous.Features) {
+		return u.reload(ctx, contracts, "the network's protocol features changed")
+	}
+
+	// Make sure the previous snapshot can be updated
+	if blockNumber-previous.ElBlockNumber > u.settings.MaxBlockGap {
+		return u.reload(ctx, contracts, fmt.Sprintf("%d blocks have passed since the previous snapshot", blockNumber-previous.ElBlockNumber))
+	}
+	if previous.ElBlockHash == (common.Hash{}) {
+		return u.reload(ctx, contracts, "the previous snapshot has no block hash")
+	}
+	previousHash, err := getBlockHash(ctx, u.rp, previousOpts.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if previousHash != previous.ElBlockHash {
+		return u.reload(ctx, contracts, fmt.Sprintf("block %d was reorged out", previous.ElBlockNumber))
+	}
+	previousNodeCount, err := node.GetNodeCount(u.rp, previousOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting previous node count: %w", err)
+	}
+	previousMinipoolCount, err := minipool.GetMinipoolCount(u.rp, previousOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting previous minipool count: %w", err)
+	}
+	if previousNodeCount != uint64(len(previous.NodeDetails)) || previousMinipoolCount != uint64(len(previous.MinipoolDetails)) {
+		return u.reload(ctx, contracts, "the previous snapshot doesn't cover the entire network")
+	}
+	if blockNumber == previous.ElBlockNumber {
+		return &NetworkStateUpdate{State: previous}, nil
+	}
+
+	// Get the new nodes and minipools
+	nodeCount, err := node.GetNodeCount(u.rp, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node count: %w", err)
+	}
+	minipoolCount, err := minipool.GetMinipoolCount(u.rp, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool count: %w", err)
+	}
+	if nodeCount < previousNodeCount || minipoolCount < previousMinipoolCount {
+		return u.reload(ctx, contracts, "nodes or minipools were removed")
+	}
+	newNodes, err := getNodeAddressRange(contracts, previousNodeCount, nodeCount, opts)
+	if err != nil {
+		return nil, err
+	}
+	newMinipools, err := getMinipoolAddressRange(contracts, previousMinipoolCount, minipoolCount, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the nodes and minipools affected by the events since the previous snapshot
+	affected, reloadReason, err := u.getAffectedDetails(ctx, previous, contracts, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if reloadReason != "" {
+		return u.reload(ctx, contracts, reloadReason)
+	}
+
+	// Get the network details
+	networkDetails, err := NewNetworkDetailsContext(ctx, u.rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+
+	// Changes to the RPL price and collateral limits change the stake of every node without any node events
+	if previous.NetworkDetails == nil ||
+		!bigEqual(networkDetails.RplPrice, previous.NetworkDetails.RplPrice) ||
+		!bigEqual(networkDetails.MinCollateralFraction, previous.NetworkDetails.MinCollateralFraction) ||
+		!bigEqual(networkDetails.MaxCollateralFraction, previous.NetworkDetails.MaxCollateralFraction) {
+		for _, details := range previous.NodeDetails {
+			affected.nodes[details.NodeAddress] = true
+		}
+	}
+
+	// Rewards withdrawals change minipool balances without any minipool events
+	minipoolAddresses := make([]common.Address, len(previous.MinipoolDetails))
+	for i, details := range previous.MinipoolDetails {
+		minipoolAddresses[i] = details.MinipoolAddress
+	}
+	minipoolBalances, err := contracts.BalanceBatcher.GetEthBalances(minipoolAddresses, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool balances: %w", err)
+	}
+	for i, details := range previous.MinipoolDetails {
+		if !bigEqual(minipoolBalances[i], details.Balance) {
+			affected.minipools[details.MinipoolAddress] = true
+		}
+	}
+
+	// Minipool changes can change the node's details too
+	for address := range affected.minipools {
+		if details, exists := previous.GetMinipool(address); exists {
+			affected.nodes[details.NodeAddress] = true
+		}
+	}
+
+	// Update the minipools
+	minipoolDetails, updatedMinipools, err := u.updateMinipools(contracts, previous, affected, newMinipools, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the nodes
+	nodeDetails, updatedNodes, err := u.updateNodes(contracts, previous, affected, newNodes, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the new snapshot
+	state, err := NewNetworkStateFromDetails(blockNumber, contracts.Features, networkDetails, nodeDetails, minipoolDetails)
+	if err != nil {
+		return nil, err
+	}
+	state.ElBlockHash, err = getBlockHash(ctx, u.rp, opts.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkStateUpdate{
+		State:            state,
+		UpdatedNodes:     updatedNodes,
+		UpdatedMinipools: updatedMinipools,
+	}, nil
+}
+
+// Reload the entire network state
+func (u *NetworkStateUpdater) reload(ctx context.Context, contracts *NetworkContracts, reason string) (*NetworkStateUpdate, error) {
+	state, err := NewNetworkStateContext(ctx, u.rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error reloading network state (%s): %w", reason, err)
+	}
+	return &NetworkStateUpdate{
+		State:            state,
+		FullReload:       true,
+		FullReloadReason: reason,
+		UpdatedNodes:     len(state.NodeDetails),
+		UpdatedMinipools: len(state.MinipoolDetails),
+	}, nil
+}
+
+// Scan the events emitted since the previous snapshot for the nodes and minipools they affect.
+// Returns a reason to reload the entire state instead if the events can't be handled incrementally.
+func (u *NetworkStateUpdater) getAffectedDetails(ctx context.Context, previous *NetworkState, contracts *NetworkContracts, blockNumber uint64) (affectedDetails, string, error) {
+	affected := affectedDetails{
+		nodes:     map[common.Address]bool{},
+		minipools: map[common.Address]bool{},
+	}
+
+	// Get the contracts that emit node and minipool events
+	upgradeContract, err := u.rp.GetContractContext(ctx, rocketpool.UpgradeContractName, &bind.CallOpts{BlockNumber: contracts.ElBlockNumber, Context: ctx})
+	if err != nil {
+		return affected, "", fmt.Errorf("error getting upgrade contract: %w", err)
+	}
+	networkContracts := []*rocketpool.Contract{
+		contracts.RocketStorage,
+		contracts.RocketDepositPool,
+		contracts.RocketMinipoolManager,
+		contracts.RocketMinipoolQueue,
+		contracts.RocketNodeDeposit,
+		contracts.RocketNodeDistributorFactory,
+		contracts.RocketNodeManager,
+		contracts.RocketNodeStaking,
+		upgradeContract,
+	}
+	if contracts.RocketMinipoolBondReducer != nil {
+		networkContracts = append(networkContracts, contracts.RocketMinipoolBondReducer)
+	}
+	addresses := []common.Address{}
+	for _, contract := range networkContracts {
+		addresses = append(addresses, *contract.Address)
+	}
+	for _, details := range previous.MinipoolDetails {
+		addresses = append(addresses, details.MinipoolAddress)
+	}
+
+	// Get the events
+	var destroyedEventId common.Hash
+	if event, exists := contracts.RocketMinipoolManager.ABI.Events[minipoolDestroyedEvent]; exists {
+		destroyedEventId = event.ID
+	}
+	fromBlock := big.NewInt(0).SetUint64(previous.ElBlockNumber + 1)
+	toBlock := big.NewInt(0).SetUint64(blockNumber)
+	logRange := big.NewInt(0).SetUint64(u.settings.LogBlockRange)
+	for start := 0; start < len(addresses); start += u.settings.LogAddressLimit {
+		end := start + u.settings.LogAddressLimit
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		logs, err := eth.GetLogsContext(ctx, u.rp, addresses[start:end], nil, logRange, fromBlock, toBlock, nil)
+		if err != nil {
+			return affected, "", fmt.Errorf("error getting events between blocks %s and %s: %w", fromBlock.String(), toBlock.String(), err)
+		}
+
+		for _, log := range logs {
+			switch {
+			case log.Removed:
+				return affected, fmt.Sprintf("block %d was reorged out", log.BlockNumber), nil
+			case log.Address == *upgradeContract.Address:
+				return affected, "network contracts were upgraded", nil
+			case log.Address == *contracts.RocketMinipoolManager.Address && len(log.Topics) > 0 && log.Topics[0] == destroyedEventId:
+				return affected, "a minipool was destroyed", nil
+			}
+			addAffectedDetails(previous, affected, log)
+		}
+	}
+	return affected, "", nil
+}
+
+// Mark the nodes and minipools an event was emitted by or about as affected
+func addAffectedDetails(previous *NetworkState, affected affectedDetails, log types.Log) {
+	if _, exists := previous.GetMinipool(log.Address); exists {
+		affected.minipools[log.Address] = true
+	}
+
+	// Network contract events refer to nodes and minipools in their indexed parameters
+	if len(log.Topics) == 0 {
+		return
+	}
+	for _, topic := range log.Topics[1:] {
+		if !isAddressTopic(topic) {
+			continue
+		}
+		address := common.BytesToAddress(topic.Bytes())
+		if _, exists := previous.GetNode(address); exists {
+			affected.nodes[address] = true
+		}
+		if _, exists := previous.GetMinipool(address); exists {
+			affected.minipools[address] = true
+		}
+	}
+}
+
+// Query the affected and new minipools, copying the rest from the previous snapshot
+func (u *NetworkStateUpdater) updateMinipools(contracts *NetworkContracts, previous *NetworkState, affected affectedDetails, newMinipools []common.Address, opts *bind.CallOpts) ([]NativeMinipoolDetails, int, error) {
+	addresses := []common.Address{}
+	for _, details := range previous.MinipoolDetails {
+		if affected.minipools[details.MinipoolAddress] {
+			addresses = append(addresses, details.MinipoolAddress)
+		}
+	}
+	addresses = append(addresses, newMinipools...)
+
+	versions, err := getMinipoolVersionsFast(u.rp, contracts, addresses, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting minipool versions: %w", err)
+	}
+	updated, err := getBulkMinipoolDetails(u.rp, contracts, addresses, versions, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	minipoolDetails := make([]NativeMinipoolDetails, 0, len(previous.MinipoolDetails)+len(newMinipools))
+	next := 0
+	for _, details := range previous.MinipoolDetails {
+		if affected.minipools[details.MinipoolAddress] {
+			details = updated[next]
+			next++
+		}
+		minipoolDetails = append(minipoolDetails, details)
+	}
+	minipoolDetails = append(minipoolDetails, updated[next:]...)
+	return minipoolDetails, len(updated), nil
+}
+
+// Query the affected and new nodes, copying the rest from the previous snapshot with their balances refreshed
+func (u *NetworkStateUpdater) updateNodes(contracts *NetworkContracts, previous *NetworkState, affected affectedDetails, newNodes []common.Address, opts *bind.CallOpts) ([]NativeNodeDetails, int, error) {
+	addresses := []common.Address{}
+	unaffected := []common.Address{}
+	distributors := []common.Address{}
+	for _, details := range previous.NodeDetails {
+		if affected.nodes[details.NodeAddress] {
+			addresses = append(addresses, details.NodeAddress)
+		} else {
+			unaffected = append(unaffected, details.NodeAddress)
+			distributors = append(distributors, details.FeeDistributorAddress)
+		}
+	}
+	addresses = append(addresses, newNodes...)
+
+	updated, err := getBulkNodeDetails(contracts, addresses, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Balances change without any events, so they're refreshed for every node
+	balances, err := getNodeBalances(contracts, unaffected, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting node balances: %w", err)
+	}
+	distributorBalances, err := contracts.BalanceBatcher.GetEthBalances(distributors, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting distributor balances: %w", err)
+	}
+
+	nodeDetails := make([]NativeNodeDetails, 0, len(previous.NodeDetails)+len(newNodes))
+	nextUpdated := 0
+	nextUnaffected := 0
+	for _, details := range previous.NodeDetails {
+		if affected.nodes[details.NodeAddress] {
+			details = updated[nextUpdated]
+			nextUpdated++
+		} else {
+			setNodeBalances(&details, balances[nextUnaffected])
+			details.DistributorBalance = distributorBalances[nextUnaffected]
+			details.AverageNodeFee = big.NewInt(0)
+			details.DistributorBalanceUserETH = big.NewInt(0)
+			details.DistributorBalanceNodeETH = big.NewInt(0)
+			nextUnaffected++
+		}
+		nodeDetails = append(nodeDetails, details)
+	}
+	nodeDetails = append(nodeDetails, updated[nextUpdated:]...)
+	return nodeDetails, len(updated), nil
+}
+
+// Check if an event topic holds an address
+func isAddressTopic(topic common.Hash) bool {
+	for _, b := range topic[:common.HashLength-common.AddressLength] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if two optional values are equal
+func bigEqual(a *big.Int, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/RedDuck-Software/poolsea-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// A consistent snapshot of the network, its nodes and its minipools at a single block
+type NetworkState struct {
+	// The block the snapshot was taken at
+	ElBlockNumber uint64
+	ElBlockHash   common.Hash // Empty if the snapshot wasn't loaded from the chain
+	Features      FeatureSet  // The protocol features of the network at the block
+
+	// Network details
+	NetworkDetails         *NetworkDetails
+	TotalEffectiveRPLStake *big.Int
+
+	// Node and minipool details
+	NodeDetails     []NativeNodeDetails
+	MinipoolDetails []NativeMinipoolDetails
+
+	// Indices into the details
+	nodeDetailsByAddress     map[common.Address]*NativeNodeDetails
+	minipoolDetailsByAddress map[common.Address]*NativeMinipoolDetails
+	minipoolDetailsByPubkey  map[types.ValidatorPubkey]*NativeMinipoolDetails
+	minipoolDetailsByNode    map[common.Address][]*NativeMinipoolDetails
+}
+
+// Create a snapshot of the entire network at the contracts' block
+func NewNetworkState(rp *rocketpool.RocketPool, contracts *NetworkContracts) (*NetworkState, error) {
+	return NewNetworkStateContext(context.Background(), rp, contracts)
+}
+
+// Create a snapshot of the entire network at the contracts' block, using the provided context for network calls
+func NewNetworkStateContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts) (*NetworkState, error) {
+	networkDetails, err := NewNetworkDetailsContext(ctx, rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+
+	nodeDetails, err := GetAllNativeNodeDetailsContext(ctx, rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting all node details: %w", err)
+	}
+
+	minipoolDetails, err := GetAllNativeMinipoolDetailsContext(ctx, rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting all minipool details: %w", err)
+	}
+
+	state, err := NewNetworkStateFromDetails(contracts.ElBlockNumber.Uint64(), contracts.Features, networkDetails, nodeDetails, minipoolDetails)
+	if err != nil {
+		return nil, err
+	}
+	state.ElBlockHash, err = getBlockHash(ctx, rp, contracts.ElBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Create a snapshot of the network, a single node and its minipools at the contracts' block
+func NewNetworkStateForNode(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (*NetworkState, error) {
+	return NewNetworkStateForNodeContext(context.Background(), rp, contracts, nodeAddress)
+}
+
+// Create a snapshot of the network, a single node and its minipools at the contracts' block, using the provided context for network calls.
+// The total effective RPL stake still covers every node on the network.
+func NewNetworkStateForNodeContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (*NetworkState, error) {
+	networkDetails, err := NewNetworkDetailsContext(ctx, rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+
+	nodeDetails, err := GetNativeNodeDetailsContext(ctx, rp, contracts, nodeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting details for node %s: %w", nodeAddress.Hex(), err)
+	}
+
+	minipoolDetails, err := GetNodeNativeMinipoolDetailsContext(ctx, rp, contracts, nodeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool details for node %s: %w", nodeAddress.Hex(), err)
+	}
+
+	totalEffectiveRplStake, err := GetTotalEffectiveRplStakeContext(ctx, rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting total effective RPL stake: %w", err)
+	}
+
+	state, err := NewNetworkStateFromDetails(contracts.ElBlockNumber.Uint64(), contracts.Features, networkDetails, []NativeNodeDetails{nodeDetails}, minipoolDetails)
+	if err != nil {
+		return nil, err
+	}
+	state.TotalEffectiveRPLStake = totalEffectiveRplStake
+	state.ElBlockHash, err = getBlockHash(ctx, rp, contracts.ElBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Create a snapshot from details that were already retrieved at the given block, indexing them and calculating the derived fields
+func NewNetworkStateFromDetails(elBlockNumber uint64, features FeatureSet, networkDetails *NetworkDetails, nodeDetails []NativeNodeDetails, minipoolDetails []NativeMinipoolDetails) (*NetworkState, error) {
+	state := &NetworkState{
+		ElBlockNumber:   elBlockNumber,
+		Features:        features,
+		NetworkDetails:  networkDetails,
+		NodeDetails:     nodeDetails,
+		MinipoolDetails: minipoolDetails,
+	}
+	state.buildIndices()
+
+	// Calculate the total effective RPL stake
+	state.TotalEffectiveRPLStake = big.NewInt(0)
+	for _, node := range state.NodeDetails {
+		if node.EffectiveRPLStake != nil {
+			state.TotalEffectiveRPLStake.Add(state.TotalEffectiveRPLStake, node.EffectiveRPLStake)
+		}
+	}
+
+	// Calculate the average fees and distributor shares
+	for _, node := range state.NodeDetails {
+		var err error
+		minipools := state.minipoolDetailsByNode[node.NodeAddress]
+		if features.Has(FeatureAtlas) {
+			err = CalculateAverageFeeAndDistributorShares_New(nil, nil, node, minipools)
+		} else {
+			err = CalculateAverageFeeAndDistributorShares_Legacy(nil, nil, node, minipools)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error calculating average fee and distributor shares for node %s: %w", node.NodeAddress.Hex(), err)
+		}
+	}
+
+	return state, nil
+}
+
+// Calculate the node and user shares of each minipool's total balance from the Beacon balances of their validators.
+// Minipools without a Beacon balance are treated as having a balance of 0.
+func (s *NetworkState) CalculateCompleteMinipoolShares(rp *rocketpool.RocketPool, contracts *NetworkContracts, beaconBalances map[types.ValidatorPubkey]*big.Int) error {
+	return s.CalculateCompleteMinipoolSharesContext(context.Background(), rp, contracts, beaconBalances)
+}
+
+// Calculate the node and user shares of each minipool's total balance from the Beacon balances of their validators, using the provided context for network calls
+func (s *NetworkState) CalculateCompleteMinipoolSharesContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, beaconBalances map[types.ValidatorPubkey]*big.Int) error {
+	if contracts.ElBlockNumber.Uint64() != s.ElBlockNumber {
+		return fmt.Errorf("contracts are at block %d but the network state is at block %d", contracts.ElBlockNumber.Uint64(), s.ElBlockNumber)
+	}
+
+	minipools := make([]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	balances := make([]*big.Int, len(s.MinipoolDetails))
+	for i := range s.MinipoolDetails {
+		minipools[i] = &s.MinipoolDetails[i]
+		balances[i] = big.NewInt(0)
+		if balance, exists := beaconBalances[s.MinipoolDetails[i].Pubkey]; exists && balance != nil {
+			balances[i].Set(balance)
+		}
+	}
+	return CalculateCompleteMinipoolSharesContext(ctx, rp, contracts, minipools, balances)
+}
+
+// Get the details for a node
+func (s *NetworkState) GetNode(nodeAddress common.Address) (*NativeNodeDetails, bool) {
+	details, exists := s.nodeDetailsByAddress[nodeAddress]
+	return details, exists
+}
+
+// Get the details for a minipool
+func (s *NetworkState) GetMinipool(minipoolAddress common.Address) (*NativeMinipoolDetails, bool) {
+	details, exists := s.minipoolDetailsByAddress[minipoolAddress]
+	return details, exists
+}
+
+// Get the details for the minipool with the given validator pubkey
+func (s *NetworkState) GetMinipoolByPubkey(pubkey types.ValidatorPubkey) (*NativeMinipoolDetails, bool) {
+	details, exists := s.minipoolDetailsByPubkey[pubkey]
+	return details, exists
+}
+
+// Get the details for a node's minipools
+func (s *NetworkState) GetNodeMinipools(nodeAddress common.Address) []*NativeMinipoolDetails {
+	return s.minipoolDetailsByNode[nodeAddress]
+}
+
+// Build the lookup indices for the node and minipool details
+func (s *NetworkState) buildIndices() {
+	s.nodeDetailsByAddress = make(map[common.Address]*NativeNodeDetails, len(s.NodeDetails))
+	for i := range s.NodeDetails {
+		details := &s.NodeDetails[i]
+		s.nodeDetailsByAddress[details.NodeAddress] = details
+	}
+
+	s.minipoolDetailsByAddress = make(map[common.Address]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	s.minipoolDetailsByPubkey = make(map[types.ValidatorPubkey]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	s.minipoolDetailsByNode = make(map[common.Address][]*NativeMinipoolDetails, len(s.NodeDetails))
+	for i := range s.MinipoolDetails {
+		details := &s.MinipoolDetails[i]
+		s.minipoolDetailsByAddress[details.MinipoolAddress] = details
+		if details.Pubkey != (types.ValidatorPubkey{}) {
+			s.minipoolDetailsByPubkey[details.Pubkey] = details
+		}
+		s.minipoolDetailsByNode[details.NodeAddress] = append(s.minipoolDetailsByNode[details.NodeAddress], details)
+	}
+}
+
+// Get the hash of a block
+func getBlockHash(ctx context.Context, rp *rocketpool.RocketPool, blockNumber *big.Int) (common.Hash, error) {
+	header, err := rp.Client.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting header for block %s: %w", blockNumber.String(), err)
+	}
+	return header.Hash(), nil
+}
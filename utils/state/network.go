@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"time"
@@ -11,11 +12,7 @@ import (
 	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"golang.org/x/sync/errgroup"
-)
-
-const (
-	networkEffectiveStakeBatchSize int = 250
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type NetworkDetails struct {
@@ -49,23 +46,32 @@ type NetworkDetails struct {
 	TotalRPLStake                     *big.Int
 	SmoothingPoolBalance              *big.Int
 	NodeFee                           float64
+	NodeFeeRaw                        *big.Int
 	BalancesBlock                     *big.Int
 	LatestReportableBalancesBlock     *big.Int
 	SubmitBalancesEnabled             bool
 	SubmitPricesEnabled               bool
 	MinipoolLaunchTimeout             *big.Int
+	MaxPenaltyRate                    *big.Int
 
 	// Atlas
 	PromotionScrubPeriod      time.Duration
 	BondReductionWindowStart  time.Duration
 	BondReductionWindowLength time.Duration
+	BondReductionEnabled      bool
 	DepositPoolUserBalance    *big.Int
 }
 
 // Create a snapshot of all of the network's details
-func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, isAtlasDeployed bool) (*NetworkDetails, error) {
+func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) (*NetworkDetails, error) {
+	return NewNetworkDetailsContext(context.Background(), rp, contracts)
+}
+
+// Create a snapshot of all of the network's details, using the provided context for network calls
+func NewNetworkDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts) (*NetworkDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	details := &NetworkDetails{}
@@ -86,9 +92,6 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, i
 	var balancesBlock *big.Int
 	var latestReportableBalancesBlock *big.Int
 	var minipoolLaunchTimeout *big.Int
-	var promotionScrubPeriodSeconds *big.Int
-	var windowStartRaw *big.Int
-	var windowLengthRaw *big.Int
 
 	// Multicall getters
 	contracts.Multicaller.AddCall(contracts.RocketNetworkPrices, &details.RplPrice, "getRPLPrice")
@@ -123,23 +126,25 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, i
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.SubmitBalancesEnabled, "getSubmitBalancesEnabled")
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.SubmitPricesEnabled, "getSubmitPricesEnabled")
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &minipoolLaunchTimeout, "getLaunchTimeout")
+	contracts.Multicaller.AddCall(contracts.RocketStorage, &details.MaxPenaltyRate, "getUint", crypto.Keccak256Hash([]byte("minipool.max.penalty.rate")))
 
-	if isAtlasDeployed {
-		contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &promotionScrubPeriodSeconds, "getPromotionScrubPeriod")
-		contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowStartRaw, "getBondReductionWindowStart")
-		contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowLengthRaw, "getBondReductionWindowLength")
-		contracts.Multicaller.AddCall(contracts.RocketDepositPool, &details.DepositPoolUserBalance, "getUserBalance")
-	} else {
-		promotionScrubPeriodSeconds = big.NewInt(0)
-		windowStartRaw = big.NewInt(0)
-		windowLengthRaw = big.NewInt(0)
-		details.DepositPoolUserBalance = big.NewInt(0)
+	// Feature-specific getters
+	featureConversions := []func(){}
+	for _, calls := range networkFeatureCalls {
+		if contracts.Features.Has(calls.feature) {
+			featureConversions = append(featureConversions, calls.add(contracts, contracts.Multicaller, details))
+		} else {
+			calls.fallback(details)
+		}
 	}
 
 	_, err := contracts.Multicaller.FlexibleCall(true, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error executing multicall: %w", err)
 	}
+	for _, convert := range featureConversions {
+		convert()
+	}
 
 	// Conversion for raw parameters
 	details.RewardIndex = rewardIndex.Uint64()
@@ -157,12 +162,10 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, i
 	details.ETHUtilizationRate = eth.WeiToEth(ethUtilizationRate)
 	details.RETHExchangeRate = eth.WeiToEth(rETHExchangeRate)
 	details.NodeFee = eth.WeiToEth(nodeFee)
+	details.NodeFeeRaw = nodeFee
 	details.BalancesBlock = balancesBlock
 	details.LatestReportableBalancesBlock = latestReportableBalancesBlock
 	details.MinipoolLaunchTimeout = minipoolLaunchTimeout
-	details.PromotionScrubPeriod = convertToDuration(promotionScrubPeriodSeconds)
-	details.BondReductionWindowStart = convertToDuration(windowStartRaw)
-	details.BondReductionWindowLength = convertToDuration(windowLengthRaw)
 
 	// Get various balances
 	addresses := []common.Address{
@@ -179,10 +182,42 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, i
 	return details, nil
 }
 
+// The network calls that depend on the network's features
+var networkFeatureCalls = []struct {
+	feature  Feature
+	add      func(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NetworkDetails) func() // Used if the network has the feature; returns a function that converts the raw results once the calls are done
+	fallback func(details *NetworkDetails)                                                                // Used if it doesn't
+}{
+	{
+		feature: FeatureAtlas,
+		add: func(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NetworkDetails) func() {
+			promotionScrubPeriodSeconds := multicall.Add[*big.Int](mc, contracts.RocketDAONodeTrustedSettingsMinipool, "getPromotionScrubPeriod")
+			windowStartRaw := multicall.Add[*big.Int](mc, contracts.RocketDAONodeTrustedSettingsMinipool, "getBondReductionWindowStart")
+			windowLengthRaw := multicall.Add[*big.Int](mc, contracts.RocketDAONodeTrustedSettingsMinipool, "getBondReductionWindowLength")
+			mc.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &details.BondReductionEnabled, "getBondReductionEnabled")
+			mc.AddCall(contracts.RocketDepositPool, &details.DepositPoolUserBalance, "getUserBalance")
+			return func() {
+				details.PromotionScrubPeriod = convertToDuration(promotionScrubPeriodSeconds.Value())
+				details.BondReductionWindowStart = convertToDuration(windowStartRaw.Value())
+				details.BondReductionWindowLength = convertToDuration(windowLengthRaw.Value())
+			}
+		},
+		fallback: func(details *NetworkDetails) {
+			details.DepositPoolUserBalance = big.NewInt(0)
+		},
+	},
+}
+
 // Gets the details for a node using the efficient multicall contract
 func GetTotalEffectiveRplStake(rp *rocketpool.RocketPool, contracts *NetworkContracts) (*big.Int, error) {
+	return GetTotalEffectiveRplStakeContext(context.Background(), rp, contracts)
+}
+
+// Gets the total effective RPL stake of the network, using the provided context for network calls
+func GetTotalEffectiveRplStakeContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts) (*big.Int, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	// Get the list of node addresses
@@ -191,47 +226,22 @@ func GetTotalEffectiveRplStake(rp *rocketpool.RocketPool, contracts *NetworkCont
 		return nil, fmt.Errorf("error getting node addresses: %w", err)
 	}
 	count := len(addresses)
-	minimumStakes := make([]*big.Int, count)
-	effectiveStakes := make([]*big.Int, count)
-
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-
-	// Run the getters in batches
-	for i := 0; i < count; i += networkEffectiveStakeBatchSize {
-		i := i
-		max := i + networkEffectiveStakeBatchSize
-		if max > count {
-			max = count
-		}
+	minimumStakes := make([]*multicall.Query[*big.Int], count)
+	effectiveStakes := make([]*multicall.Query[*big.Int], count)
 
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				address := addresses[j]
-				mc.AddCall(contracts.RocketNodeStaking, &minimumStakes[j], "getNodeMinimumRPLStake", address)
-				mc.AddCall(contracts.RocketNodeStaking, &effectiveStakes[j], "getNodeEffectiveRPLStake", address)
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
+	// Run the getters
+	mc := contracts.Multicaller.NewCaller()
+	for i, address := range addresses {
+		minimumStakes[i] = multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeMinimumRPLStake", address)
+		effectiveStakes[i] = multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeEffectiveRPLStake", address)
 	}
-
-	if err := wg.Wait(); err != nil {
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting effective stakes for all nodes: %w", err)
 	}
 
 	totalEffectiveStake := big.NewInt(0)
-	for i, effectiveStake := range effectiveStakes {
-		minimumStake := minimumStakes[i]
+	for i, effectiveStake := range multicall.Values(effectiveStakes) {
+		minimumStake := minimumStakes[i].Value()
 		// Fix the effective stake
 		if effectiveStake.Cmp(minimumStake) >= 0 {
 			totalEffectiveStake.Add(totalEffectiveStake, effectiveStake)
@@ -13,12 +13,6 @@ import (
 	"github.com/RedDuck-Software/poolsea-go/utils/multicall"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"golang.org/x/sync/errgroup"
-)
-
-const (
-	legacyNodeBatchSize  int = 100
-	nodeAddressBatchSize int = 1000
 )
 
 // Complete details for a node
@@ -54,9 +48,15 @@ type NativeNodeDetails struct {
 }
 
 // Gets the details for a node using the efficient multicall contract
-func GetNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address, isAtlasDeployed bool) (NativeNodeDetails, error) {
+func GetNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (NativeNodeDetails, error) {
+	return GetNativeNodeDetailsContext(context.Background(), rp, contracts, nodeAddress)
+}
+
+// Gets the details for a node using the efficient multicall contract and the provided context
+func GetNativeNodeDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (NativeNodeDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 	details := NativeNodeDetails{
 		NodeAddress:               nodeAddress,
@@ -66,21 +66,22 @@ func GetNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts
 		DistributorBalanceNodeETH: big.NewInt(0),
 	}
 
-	addNodeDetailsCalls(contracts, contracts.Multicaller, &details, nodeAddress, isAtlasDeployed)
-
+	setters := addNodeDetailsCalls(contracts, contracts.Multicaller, &details, nodeAddress)
 	_, err := contracts.Multicaller.FlexibleCall(true, opts)
 	if err != nil {
 		return NativeNodeDetails{}, fmt.Errorf("error executing multicall: %w", err)
 	}
+	setters.run()
 
-	// Get the node's ETH balance
-	details.BalanceETH, err = rp.Client.BalanceAt(context.Background(), nodeAddress, opts.BlockNumber)
+	// Get the node's balances
+	balances, err := getNodeBalances(contracts, []common.Address{nodeAddress}, opts)
 	if err != nil {
-		return NativeNodeDetails{}, err
+		return NativeNodeDetails{}, fmt.Errorf("error getting node balances: %w", err)
 	}
+	setNodeBalances(&details, balances[0])
 
 	// Get the distributor balance
-	distributorBalance, err := rp.Client.BalanceAt(context.Background(), details.FeeDistributorAddress, opts.BlockNumber)
+	distributorBalance, err := rp.Client.BalanceAt(ctx, details.FeeDistributorAddress, opts.BlockNumber)
 	if err != nil {
 		return NativeNodeDetails{}, err
 	}
@@ -97,9 +98,15 @@ func GetNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts
 }
 
 // Gets the details for all nodes using the efficient multicall contract
-func GetAllNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, isAtlasDeployed bool) ([]NativeNodeDetails, error) {
+func GetAllNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) ([]NativeNodeDetails, error) {
+	return GetAllNativeNodeDetailsContext(context.Background(), rp, contracts)
+}
+
+// Gets the details for all nodes using the efficient multicall contract and the provided context
+func GetAllNativeNodeDetailsContext(ctx context.Context, rp *rocketpool.RocketPool, contracts *NetworkContracts) ([]NativeNodeDetails, error) {
 	opts := &bind.CallOpts{
 		BlockNumber: contracts.ElBlockNumber,
+		Context:     ctx,
 	}
 
 	// Get the list of node addresses
@@ -107,69 +114,47 @@ func GetAllNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContra
 	if err != nil {
 		return nil, fmt.Errorf("error getting node addresses: %w", err)
 	}
+
+	// Get the node details
+	return getBulkNodeDetails(contracts, addresses, opts)
+}
+
+// Get multiple node details at once
+func getBulkNodeDetails(contracts *NetworkContracts, addresses []common.Address, opts *bind.CallOpts) ([]NativeNodeDetails, error) {
 	count := len(addresses)
 	nodeDetails := make([]NativeNodeDetails, count)
 
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-
-	// Run the getters in batches
-	for i := 0; i < count; i += legacyNodeBatchSize {
-		i := i
-		max := i + legacyNodeBatchSize
-		if max > count {
-			max = count
-		}
+	// Run the getters
+	mc := contracts.Multicaller.NewCaller()
+	setters := fieldSetters{}
+	for i, address := range addresses {
+		details := &nodeDetails[i]
+		details.NodeAddress = address
+		details.AverageNodeFee = big.NewInt(0)
+		details.DistributorBalanceUserETH = big.NewInt(0)
+		details.DistributorBalanceNodeETH = big.NewInt(0)
+		details.CollateralisationRatio = big.NewInt(0)
 
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				address := addresses[j]
-				details := &nodeDetails[j]
-				details.NodeAddress = address
-				details.AverageNodeFee = big.NewInt(0)
-				details.DistributorBalanceUserETH = big.NewInt(0)
-				details.DistributorBalanceNodeETH = big.NewInt(0)
-
-				if !isAtlasDeployed {
-					// Before Atlas, all node's had a 1:1 collateralisation ratio
-					details.CollateralisationRatio = eth.EthToWei(2)
-				} else {
-					details.CollateralisationRatio = big.NewInt(0)
-				}
-
-				addNodeDetailsCalls(contracts, mc, details, address, isAtlasDeployed)
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
+		setters = append(setters, addNodeDetailsCalls(contracts, mc, details, address)...)
 	}
-
-	if err := wg.Wait(); err != nil {
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting node details: %w", err)
 	}
+	setters.run()
 
 	// Get the balances of the nodes
 	distributorAddresses := make([]common.Address, count)
-	balances, err := contracts.BalanceBatcher.GetEthBalances(addresses, opts)
+	nodeBalances, err := getNodeBalances(contracts, addresses, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error getting node balances: %w", err)
 	}
 	for i, details := range nodeDetails {
-		nodeDetails[i].BalanceETH = balances[i]
+		setNodeBalances(&nodeDetails[i], nodeBalances[i])
 		distributorAddresses[i] = details.FeeDistributorAddress
 	}
 
 	// Get the balances of the distributors
-	balances, err = contracts.BalanceBatcher.GetEthBalances(distributorAddresses, opts)
+	balances, err := contracts.BalanceBatcher.GetEthBalances(distributorAddresses, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error getting distributor balances: %w", err)
 	}
@@ -214,8 +199,8 @@ func CalculateAverageFeeAndDistributorShares_Legacy(rp *rocketpool.RocketPool, c
 
 		if eligibleMinipools == 0 {
 			// Split it 50/50 if there are no minipools
-			node.DistributorBalanceNodeETH = big.NewInt(0).Set(halfBalance)
-			node.DistributorBalanceUserETH = big.NewInt(0).Sub(distributorBalance, halfBalance)
+			node.DistributorBalanceNodeETH.Set(halfBalance)
+			node.DistributorBalanceUserETH.Sub(distributorBalance, halfBalance)
 		} else {
 			// Amount of ETH given to the NO as a commission
 			commissionEth := big.NewInt(0)
@@ -228,8 +213,8 @@ func CalculateAverageFeeAndDistributorShares_Legacy(rp *rocketpool.RocketPool, c
 
 	} else {
 		// No distributor balance
-		node.DistributorBalanceNodeETH = big.NewInt(0)
-		node.DistributorBalanceUserETH = big.NewInt(0)
+		node.DistributorBalanceNodeETH.SetUint64(0)
+		node.DistributorBalanceUserETH.SetUint64(0)
 	}
 
 	return nil
@@ -265,8 +250,8 @@ func CalculateAverageFeeAndDistributorShares_New(rp *rocketpool.RocketPool, cont
 
 		if eligibleMinipools == 0 {
 			// Split it based solely on the collateralisation ratio if there are no minipools (and hence no average fee)
-			node.DistributorBalanceNodeETH = big.NewInt(0).Set(nodeBalance)
-			node.DistributorBalanceUserETH = big.NewInt(0).Sub(distributorBalance, nodeBalance)
+			node.DistributorBalanceNodeETH.Set(nodeBalance)
+			node.DistributorBalanceUserETH.Sub(distributorBalance, nodeBalance)
 		} else {
 			// Amount of ETH given to the NO as a commission
 			commissionEth := big.NewInt(0)
@@ -279,8 +264,8 @@ func CalculateAverageFeeAndDistributorShares_New(rp *rocketpool.RocketPool, cont
 
 	} else {
 		// No distributor balance
-		node.DistributorBalanceNodeETH = big.NewInt(0)
-		node.DistributorBalanceUserETH = big.NewInt(0)
+		node.DistributorBalanceNodeETH.SetUint64(0)
+		node.DistributorBalanceUserETH.SetUint64(0)
 	}
 
 	return nil
@@ -294,69 +279,93 @@ func getNodeAddressesFast(rp *rocketpool.RocketPool, contracts *NetworkContracts
 		return []common.Address{}, err
 	}
 
-	// Sync
-	var wg errgroup.Group
-	wg.SetLimit(threadLimit)
-	addresses := make([]common.Address, nodeCount)
-
-	// Run the getters in batches
-	count := int(nodeCount)
-	for i := 0; i < count; i += nodeAddressBatchSize {
-		i := i
-		max := i + nodeAddressBatchSize
-		if max > count {
-			max = count
-		}
+	return getNodeAddressRange(contracts, 0, nodeCount, opts)
+}
 
-		wg.Go(func() error {
-			var err error
-			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
-			if err != nil {
-				return err
-			}
-			for j := i; j < max; j++ {
-				mc.AddCall(contracts.RocketNodeManager, &addresses[j], "getNodeAt", big.NewInt(int64(j)))
-			}
-			_, err = mc.FlexibleCall(true, opts)
-			if err != nil {
-				return fmt.Errorf("error executing multicall: %w", err)
-			}
-			return nil
-		})
+// Get the addresses of the nodes from the start index up to (but not including) the end index using the multicaller
+func getNodeAddressRange(contracts *NetworkContracts, start uint64, end uint64, opts *bind.CallOpts) ([]common.Address, error) {
+	mc := contracts.Multicaller.NewCaller()
+	addresses := []*multicall.Query[common.Address]{}
+	for i := start; i < end; i++ {
+		addresses = append(addresses, multicall.Add[common.Address](mc, contracts.RocketNodeManager, "getNodeAt", big.NewInt(0).SetUint64(i)))
 	}
-
-	if err := wg.Wait(); err != nil {
+	if _, err := mc.FlexibleCall(true, opts); err != nil {
 		return nil, fmt.Errorf("error getting node addresses: %w", err)
 	}
 
-	return addresses, nil
+	return multicall.Values(addresses), nil
 }
 
-// Add all of the calls for the node details to the multicaller
-func addNodeDetailsCalls(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeNodeDetails, address common.Address, isAtlasDeployed bool) {
-	mc.AddCall(contracts.RocketNodeManager, &details.Exists, "getNodeExists", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.RegistrationTime, "getNodeRegistrationTime", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.TimezoneLocation, "getNodeTimezoneLocation", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.FeeDistributorInitialised, "getFeeDistributorInitialised", address)
-	mc.AddCall(contracts.RocketNodeDistributorFactory, &details.FeeDistributorAddress, "getProxyAddress", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.RewardNetwork, "getRewardNetwork", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.RplStake, "getNodeRPLStake", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.EffectiveRPLStake, "getNodeEffectiveRPLStake", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.MinimumRPLStake, "getNodeMinimumRPLStake", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.MaximumRPLStake, "getNodeMaximumRPLStake", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.EthMatched, "getNodeETHMatched", address)
-	mc.AddCall(contracts.RocketNodeStaking, &details.EthMatchedLimit, "getNodeETHMatchedLimit", address)
-	mc.AddCall(contracts.RocketMinipoolManager, &details.MinipoolCount, "getNodeMinipoolCount", address)
-	mc.AddCall(contracts.RocketTokenRETH, &details.BalanceRETH, "balanceOf", address)
-	mc.AddCall(contracts.RocketTokenRPL, &details.BalanceRPL, "balanceOf", address)
-	mc.AddCall(contracts.RocketTokenRPLFixedSupply, &details.BalanceOldRPL, "balanceOf", address)
-	mc.AddCall(contracts.RocketStorage, &details.WithdrawalAddress, "getNodeWithdrawalAddress", address)
-	mc.AddCall(contracts.RocketStorage, &details.PendingWithdrawalAddress, "getNodePendingWithdrawalAddress", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.SmoothingPoolRegistrationState, "getSmoothingPoolRegistrationState", address)
-	mc.AddCall(contracts.RocketNodeManager, &details.SmoothingPoolRegistrationChanged, "getSmoothingPoolRegistrationChanged", address)
-
-	if isAtlasDeployed {
-		mc.AddCall(contracts.RocketNodeDeposit, &details.DepositCreditBalance, "getNodeDepositCredit", address)
-		mc.AddCall(contracts.RocketNodeStaking, &details.CollateralisationRatio, "getNodeETHCollateralisationRatio", address)
+// Add all of the calls for the node details to the multicaller, returning the setters that copy their outputs into the details
+func addNodeDetailsCalls(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeNodeDetails, address common.Address) fieldSetters {
+	setters := fieldSetters{}
+	setField(&setters, &details.Exists, multicall.Add[bool](mc, contracts.RocketNodeManager, "getNodeExists", address))
+	setField(&setters, &details.RegistrationTime, multicall.Add[*big.Int](mc, contracts.RocketNodeManager, "getNodeRegistrationTime", address))
+	setField(&setters, &details.TimezoneLocation, multicall.Add[string](mc, contracts.RocketNodeManager, "getNodeTimezoneLocation", address))
+	setField(&setters, &details.FeeDistributorInitialised, multicall.Add[bool](mc, contracts.RocketNodeManager, "getFeeDistributorInitialised", address))
+	setField(&setters, &details.FeeDistributorAddress, multicall.Add[common.Address](mc, contracts.RocketNodeDistributorFactory, "getProxyAddress", address))
+	setField(&setters, &details.RewardNetwork, multicall.Add[*big.Int](mc, contracts.RocketNodeManager, "getRewardNetwork", address))
+	setField(&setters, &details.RplStake, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeRPLStake", address))
+	setField(&setters, &details.EffectiveRPLStake, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeEffectiveRPLStake", address))
+	setField(&setters, &details.MinimumRPLStake, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeMinimumRPLStake", address))
+	setField(&setters, &details.MaximumRPLStake, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeMaximumRPLStake", address))
+	setField(&setters, &details.EthMatched, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeETHMatched", address))
+	setField(&setters, &details.EthMatchedLimit, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeETHMatchedLimit", address))
+	setField(&setters, &details.MinipoolCount, multicall.Add[*big.Int](mc, contracts.RocketMinipoolManager, "getNodeMinipoolCount", address))
+	setField(&setters, &details.WithdrawalAddress, multicall.Add[common.Address](mc, contracts.RocketStorage, "getNodeWithdrawalAddress", address))
+	setField(&setters, &details.PendingWithdrawalAddress, multicall.Add[common.Address](mc, contracts.RocketStorage, "getNodePendingWithdrawalAddress", address))
+	setField(&setters, &details.SmoothingPoolRegistrationState, multicall.Add[bool](mc, contracts.RocketNodeManager, "getSmoothingPoolRegistrationState", address))
+	setField(&setters, &details.SmoothingPoolRegistrationChanged, multicall.Add[*big.Int](mc, contracts.RocketNodeManager, "getSmoothingPoolRegistrationChanged", address))
+
+	for _, calls := range nodeFeatureCalls {
+		add := calls.fallback
+		if contracts.Features.Has(calls.feature) {
+			add = calls.add
+		}
+		add(contracts, mc, details, address, &setters)
 	}
+
+	return setters
+}
+
+// Adds feature-specific calls for a node's details to the multicaller
+type nodeCallSet func(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeNodeDetails, address common.Address, setters *fieldSetters)
+
+// The node calls that depend on the network's features
+var nodeFeatureCalls = []struct {
+	feature  Feature
+	add      nodeCallSet // Used if the network has the feature
+	fallback nodeCallSet // Used if it doesn't
+}{
+	{
+		feature: FeatureAtlas,
+		add: func(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeNodeDetails, address common.Address, setters *fieldSetters) {
+			setField(setters, &details.DepositCreditBalance, multicall.Add[*big.Int](mc, contracts.RocketNodeDeposit, "getNodeDepositCredit", address))
+			setField(setters, &details.CollateralisationRatio, multicall.Add[*big.Int](mc, contracts.RocketNodeStaking, "getNodeETHCollateralisationRatio", address))
+		},
+		fallback: func(contracts *NetworkContracts, mc *multicall.MultiCaller, details *NativeNodeDetails, address common.Address, setters *fieldSetters) {
+			// Before Atlas, all node's had a 1:1 collateralisation ratio
+			details.DepositCreditBalance = big.NewInt(0)
+			details.CollateralisationRatio = eth.EthToWei(2)
+		},
+	},
+}
+
+// Get the ETH, rETH, RPL and legacy RPL balances of nodes in one sweep
+func getNodeBalances(contracts *NetworkContracts, addresses []common.Address, opts *bind.CallOpts) ([][]*big.Int, error) {
+	tokens := []common.Address{
+		{}, // Empty token for ETH balance
+		*contracts.RocketTokenRETH.Address,
+		*contracts.RocketTokenRPL.Address,
+		*contracts.RocketTokenRPLFixedSupply.Address,
+	}
+	return contracts.BalanceBatcher.GetTokenBalances(addresses, tokens, opts)
+}
+
+// Set a node's balances from the ones returned by getNodeBalances
+func setNodeBalances(details *NativeNodeDetails, balances []*big.Int) {
+	details.BalanceETH = balances[0]
+	details.BalanceRETH = balances[1]
+	details.BalanceRPL = balances[2]
+	details.BalanceOldRPL = balances[3]
 }
@@ -0,0 +1,55 @@
+package txmanager
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The stage of a transaction's lifecycle that an event reports
+type EventType int
+
+const (
+	EventSubmitted       EventType = iota // The transaction was sent to the network
+	EventSpedUp                           // A replacement with higher fees was sent
+	EventCancelSubmitted                  // A zero-value replacement to the sender was sent to cancel the transaction
+	EventMined                            // The transaction (or one of its replacements) was included in a block
+	EventConfirmed                        // The transaction reached the required number of confirmations
+	EventReorged                          // The block the transaction was included in is no longer canonical
+	EventDropped                          // The transaction is no longer known to the network and will never be mined
+	EventFailed                           // The transaction was mined but reverted
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSubmitted:
+		return "submitted"
+	case EventSpedUp:
+		return "sped up"
+	case EventCancelSubmitted:
+		return "cancel submitted"
+	case EventMined:
+		return "mined"
+	case EventConfirmed:
+		return "confirmed"
+	case EventReorged:
+		return "reorged"
+	case EventDropped:
+		return "dropped"
+	case EventFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// A change in the state of a tracked transaction
+type Event struct {
+	Type          EventType
+	Sender        common.Address
+	Nonce         uint64
+	Hash          common.Hash        // The hash of the transaction version the event applies to
+	OriginalHash  common.Hash        // The hash of the first version of the transaction
+	Transaction   *types.Transaction // The transaction version the event applies to
+	Receipt       *types.Receipt     // The receipt, for mined, confirmed and failed events
+	Confirmations uint64             // The number of confirmations, for mined and confirmed events
+}
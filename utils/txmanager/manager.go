@@ -0,0 +1,271 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+)
+
+// Default settings
+const (
+	DefaultConfirmations  uint64        = 1
+	DefaultPollInterval   time.Duration = time.Second
+	DefaultDropTimeout    time.Duration = 5 * time.Minute
+	DefaultFeeBumpPercent uint64        = 12 // Execution clients require at least 10% to accept a replacement
+)
+
+// Errors
+var (
+	ErrUnknownTransaction    = errors.New("transaction is not tracked by this manager")
+	ErrTransactionMined      = errors.New("transaction has already been mined")
+	ErrTransactionDropped    = errors.New("transaction was dropped by the network")
+	ErrTransactionOverridden = errors.New("transaction nonce was used by a transaction that wasn't sent through this manager")
+)
+
+// Make sure the manager can be used by contracts
+var _ rocketpool.TransactionManager = (*Manager)(nil)
+
+// Transaction manager settings; zero values are replaced with the defaults
+type Settings struct {
+	Confirmations  uint64        // The number of blocks (including the one a transaction was mined in) before a transaction is confirmed
+	PollInterval   time.Duration // How often to check on transactions that are being waited for
+	DropTimeout    time.Duration // How long a transaction can be unknown to the network before it's considered dropped, and how long mined transactions stay tracked if they aren't waited on
+	FeeBumpPercent uint64        // The minimum increase in fees for a replacement transaction
+	OnEvent        func(Event)   // Called whenever a tracked transaction changes state
+}
+
+// Allocates nonces for transactions, tracks them until they're confirmed, and replaces them to speed them up or cancel them
+type Manager struct {
+	client       rocketpool.ExecutionClient
+	settings     Settings
+	senders      map[common.Address]*senderState
+	transactions map[common.Hash]*trackedTransaction
+	lock         sync.Mutex
+}
+
+// The nonce state of a sender
+type senderState struct {
+	nextNonce uint64
+	lock      sync.Mutex
+}
+
+// A transaction and all of the replacements that have been sent for it
+type trackedTransaction struct {
+	sender       common.Address
+	nonce        uint64
+	originalHash common.Hash
+	signer       bind.SignerFn
+	attempts     []*types.Transaction
+	receipt      *types.Receipt
+	sentAt       time.Time // When the latest version was sent
+	lock         sync.Mutex
+}
+
+// Create a new transaction manager
+func NewManager(client rocketpool.ExecutionClient, settings Settings) *Manager {
+	if settings.Confirmations == 0 {
+		settings.Confirmations = DefaultConfirmations
+	}
+	if settings.PollInterval == 0 {
+		settings.PollInterval = DefaultPollInterval
+	}
+	if settings.DropTimeout == 0 {
+		settings.DropTimeout = DefaultDropTimeout
+	}
+	if settings.FeeBumpPercent == 0 {
+		settings.FeeBumpPercent = DefaultFeeBumpPercent
+	}
+	return &Manager{
+		client:       client,
+		settings:     settings,
+		senders:      map[common.Address]*senderState{},
+		transactions: map[common.Hash]*trackedTransaction{},
+	}
+}
+
+// Send a transaction with the next nonce for its sender and start tracking it.
+// If opts already has a nonce, it is used as-is.
+// Transactions that are never waited on stop being tracked once their nonce has been used and the drop timeout has passed since they were sent.
+func (m *Manager) Send(ctx context.Context, opts *bind.TransactOpts, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+
+	// Hold the sender's nonce until the transaction has been sent
+	sender := m.getSender(opts.From)
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	// Allocate a nonce.
+	// The manager's own count is only ahead of the network's while it has transactions in flight, so resync it otherwise.
+	txOpts := *opts
+	txOpts.Context = ctx
+	if txOpts.Nonce == nil {
+		pendingNonce, err := m.client.PendingNonceAt(ctx, opts.From)
+		if err != nil {
+			return nil, err
+		}
+		confirmedNonce, err := m.client.NonceAt(ctx, opts.From, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !m.pruneTrackedTransactions(opts.From, confirmedNonce) {
+			sender.nextNonce = pendingNonce
+		}
+		nonce := sender.nextNonce
+		if pendingNonce > nonce {
+			nonce = pendingNonce
+		}
+		txOpts.Nonce = new(big.Int).SetUint64(nonce)
+	}
+
+	// Send the transaction; the nonce is only used up once it's been sent
+	tx, err := send(&txOpts)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Nonce() >= sender.nextNonce {
+		sender.nextNonce = tx.Nonce() + 1
+	}
+
+	// Track it
+	tracked := &trackedTransaction{
+		sender:       opts.From,
+		nonce:        tx.Nonce(),
+		originalHash: tx.Hash(),
+		signer:       opts.Signer,
+		attempts:     []*types.Transaction{tx},
+		sentAt:       time.Now(),
+	}
+	m.lock.Lock()
+	m.transactions[tx.Hash()] = tracked
+	m.lock.Unlock()
+	m.emit(EventSubmitted, tracked, tx, nil, 0)
+	return tx, nil
+
+}
+
+// Get the next nonce the manager will allocate for a sender, or 0 if it hasn't sent anything for it yet
+func (m *Manager) GetNextNonce(sender common.Address) uint64 {
+	state := m.getSender(sender)
+	state.lock.Lock()
+	defer state.lock.Unlock()
+	return state.nextNonce
+}
+
+// Get the hashes of every version of a tracked transaction, in the order they were sent
+func (m *Manager) GetTransactionHashes(hash common.Hash) ([]common.Hash, error) {
+	tracked, err := m.getTrackedTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	tracked.lock.Lock()
+	defer tracked.lock.Unlock()
+	hashes := make([]common.Hash, len(tracked.attempts))
+	for i, attempt := range tracked.attempts {
+		hashes[i] = attempt.Hash()
+	}
+	return hashes, nil
+}
+
+// Get the nonce state for a sender
+func (m *Manager) getSender(address common.Address) *senderState {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	sender, exists := m.senders[address]
+	if !exists {
+		sender = &senderState{}
+		m.senders[address] = sender
+	}
+	return sender
+}
+
+// Stop tracking a sender's transactions whose nonce has been used and that were sent longer ago than the drop timeout.
+// Returns true if any of the sender's tracked transactions haven't had their nonce used yet.
+func (m *Manager) pruneTrackedTransactions(sender common.Address, confirmedNonce uint64) bool {
+
+	// Find the transactions that are still in flight, and the ones that can be pruned
+	inFlight := false
+	used := []*trackedTransaction{}
+	m.lock.Lock()
+	for _, tracked := range m.transactions {
+		if tracked.sender != sender {
+			continue
+		}
+		if tracked.nonce >= confirmedNonce {
+			inFlight = true
+		} else {
+			used = append(used, tracked)
+		}
+	}
+	m.lock.Unlock()
+
+	// Prune the ones that have been kept long enough to be waited on
+	for _, tracked := range used {
+		tracked.lock.Lock()
+		expired := time.Since(tracked.sentAt) >= m.settings.DropTimeout
+		tracked.lock.Unlock()
+		if expired {
+			m.untrack(tracked)
+		}
+	}
+	return inFlight
+
+}
+
+// Give a dropped transaction's nonce back to its sender so the next transaction fills the gap
+func (m *Manager) releaseNonce(tracked *trackedTransaction) {
+	sender := m.getSender(tracked.sender)
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	if sender.nextNonce > tracked.nonce {
+		sender.nextNonce = tracked.nonce
+	}
+}
+
+// Get a tracked transaction by the hash of any of its versions
+func (m *Manager) getTrackedTransaction(hash common.Hash) (*trackedTransaction, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	tracked, exists := m.transactions[hash]
+	if !exists {
+		return nil, ErrUnknownTransaction
+	}
+	return tracked, nil
+}
+
+// Stop tracking a transaction
+func (m *Manager) untrack(tracked *trackedTransaction) {
+	tracked.lock.Lock()
+	defer tracked.lock.Unlock()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, attempt := range tracked.attempts {
+		delete(m.transactions, attempt.Hash())
+	}
+}
+
+// Report a lifecycle event
+func (m *Manager) emit(eventType EventType, tracked *trackedTransaction, tx *types.Transaction, receipt *types.Receipt, confirmations uint64) {
+	if m.settings.OnEvent == nil {
+		return
+	}
+	event := Event{
+		Type:          eventType,
+		Sender:        tracked.sender,
+		Nonce:         tracked.nonce,
+		OriginalHash:  tracked.originalHash,
+		Transaction:   tx,
+		Receipt:       receipt,
+		Confirmations: confirmations,
+	}
+	if tx != nil {
+		event.Hash = tx.Hash()
+	}
+	m.settings.OnEvent(event)
+}
@@ -0,0 +1,121 @@
+package txmanager
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Resend a tracked transaction with bumped fees so it gets mined sooner
+func (m *Manager) SpeedUp(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	return m.replace(ctx, hash, EventSpedUp, func(latest *types.Transaction, sender common.Address) (*common.Address, *big.Int, []byte, uint64) {
+		return latest.To(), latest.Value(), latest.Data(), latest.Gas()
+	})
+}
+
+// Replace a tracked transaction with a zero-value transfer to its sender, with bumped fees, so its nonce is used up without doing anything
+func (m *Manager) Cancel(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	return m.replace(ctx, hash, EventCancelSubmitted, func(latest *types.Transaction, sender common.Address) (*common.Address, *big.Int, []byte, uint64) {
+		return &sender, big.NewInt(0), []byte{}, params.TxGas
+	})
+}
+
+// Sign and send a replacement for the latest version of a tracked transaction
+func (m *Manager) replace(ctx context.Context, hash common.Hash, eventType EventType, getContents func(latest *types.Transaction, sender common.Address) (*common.Address, *big.Int, []byte, uint64)) (*types.Transaction, error) {
+	tracked, err := m.getTrackedTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := m.sendReplacement(ctx, tracked, getContents)
+	if err != nil {
+		return nil, err
+	}
+	m.emit(eventType, tracked, replacement, nil, 0)
+	return replacement, nil
+}
+
+// Sign and send a replacement while holding the tracked transaction's lock
+func (m *Manager) sendReplacement(ctx context.Context, tracked *trackedTransaction, getContents func(latest *types.Transaction, sender common.Address) (*common.Address, *big.Int, []byte, uint64)) (*types.Transaction, error) {
+
+	// Check the transaction can be replaced
+	tracked.lock.Lock()
+	defer tracked.lock.Unlock()
+	if tracked.receipt != nil {
+		return nil, ErrTransactionMined
+	}
+	if tracked.signer == nil {
+		return nil, fmt.Errorf("transaction %s can't be replaced because it has no signer", tracked.originalHash.Hex())
+	}
+	latest := tracked.attempts[len(tracked.attempts)-1]
+
+	// Get the network's current tip suggestion so the replacement isn't underpriced if fees have risen
+	suggestedTip, err := m.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get suggested priority fee: %w", err)
+	}
+
+	// Build the replacement
+	to, value, data, gas := getContents(latest, tracked.sender)
+	var replacement *types.Transaction
+	if latest.Type() == types.LegacyTxType {
+		replacement = types.NewTx(&types.LegacyTx{
+			Nonce:    tracked.nonce,
+			GasPrice: maxBig(m.bumpFee(latest.GasPrice()), suggestedTip),
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	} else {
+		tipCap := maxBig(m.bumpFee(latest.GasTipCap()), suggestedTip)
+		feeCap := maxBig(m.bumpFee(latest.GasFeeCap()), tipCap)
+		replacement = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   latest.ChainId(),
+			Nonce:     tracked.nonce,
+			GasTipCap: tipCap,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+
+	// Sign and send it
+	signedTx, err := tracked.signer(tracked.sender, replacement)
+	if err != nil {
+		return nil, fmt.Errorf("Could not sign replacement transaction: %w", err)
+	}
+	if err := m.client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("Could not send replacement transaction: %w", err)
+	}
+
+	// Track it
+	tracked.attempts = append(tracked.attempts, signedTx)
+	tracked.sentAt = time.Now()
+	m.lock.Lock()
+	m.transactions[signedTx.Hash()] = tracked
+	m.lock.Unlock()
+	return signedTx, nil
+
+}
+
+// Increase a fee by the bump percentage, rounding up
+func (m *Manager) bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, new(big.Int).SetUint64(100+m.settings.FeeBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// Get the larger of two values
+func maxBig(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,201 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Wait until a tracked transaction, or one of its replacements, has been mined and has the required number of confirmations.
+// Reorgs are followed: if the block it was mined in stops being canonical, waiting continues until it's mined again.
+// Returns an error if the mined transaction reverted, or if it's dropped by the network.
+// The manager stops tracking the transaction once this returns a receipt or a dropped error.
+func (m *Manager) WaitForConfirmation(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+
+	tracked, err := m.getTrackedTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknownSince time.Time
+	for {
+
+		// Check on the transaction
+		receipt, finished, err := m.checkTransaction(ctx, tracked, &unknownSince)
+		if err != nil || finished {
+			return receipt, err
+		}
+
+		// Wait for the next check
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.settings.PollInterval):
+		}
+
+	}
+
+}
+
+// Check the state of a tracked transaction, reporting any changes.
+// Returns the receipt and true once the transaction is confirmed.
+func (m *Manager) checkTransaction(ctx context.Context, tracked *trackedTransaction, unknownSince *time.Time) (*types.Receipt, bool, error) {
+
+	// Look for a receipt for any version of the transaction
+	receipt, attempt, err := m.findReceipt(ctx, tracked)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Handle transactions that haven't been mined
+	if receipt == nil {
+		if previous := m.setReceipt(tracked, nil); previous != nil {
+			m.emit(EventReorged, tracked, m.getAttempt(tracked, previous.TxHash), previous, 0)
+		}
+		return nil, false, m.checkDropped(ctx, tracked, unknownSince)
+	}
+	*unknownSince = time.Time{}
+
+	// Make sure the block it was mined in is still canonical
+	header, err := m.client.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, false, fmt.Errorf("Could not get block %s header: %w", receipt.BlockNumber.String(), err)
+	}
+	if header == nil || header.Hash() != receipt.BlockHash {
+		if previous := m.setReceipt(tracked, nil); previous != nil {
+			m.emit(EventReorged, tracked, m.getAttempt(tracked, previous.TxHash), previous, 0)
+		}
+		return nil, false, nil
+	}
+
+	// Report newly mined transactions
+	latestBlock, err := m.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("Could not get latest block number: %w", err)
+	}
+	confirmations := uint64(0)
+	if receiptBlock := receipt.BlockNumber.Uint64(); latestBlock >= receiptBlock {
+		confirmations = latestBlock - receiptBlock + 1
+	}
+	if previous := m.setReceipt(tracked, receipt); previous == nil || previous.BlockHash != receipt.BlockHash {
+		m.emit(EventMined, tracked, attempt, receipt, confirmations)
+	}
+
+	// Check confirmations
+	if confirmations < m.settings.Confirmations {
+		return nil, false, nil
+	}
+	m.untrack(tracked)
+	if receipt.Status == types.ReceiptStatusFailed {
+		m.emit(EventFailed, tracked, attempt, receipt, confirmations)
+		return receipt, true, fmt.Errorf("Transaction %s failed with status 0", attempt.Hash().Hex())
+	}
+	m.emit(EventConfirmed, tracked, attempt, receipt, confirmations)
+	return receipt, true, nil
+
+}
+
+// Check whether a transaction that hasn't been mined has been dropped, returning an error if it has
+func (m *Manager) checkDropped(ctx context.Context, tracked *trackedTransaction, unknownSince *time.Time) error {
+
+	// Check whether the network still knows about any version of the transaction
+	for _, attempt := range m.getAttempts(tracked) {
+		_, _, err := m.client.TransactionByHash(ctx, attempt.Hash())
+		if err == nil {
+			*unknownSince = time.Time{}
+			return nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("Could not get transaction %s: %w", attempt.Hash().Hex(), err)
+		}
+	}
+
+	// Check whether the nonce was used by a transaction this manager didn't send
+	latestNonce, err := m.client.NonceAt(ctx, tracked.sender, nil)
+	if err != nil {
+		return fmt.Errorf("Could not get latest nonce for %s: %w", tracked.sender.Hex(), err)
+	}
+	if latestNonce > tracked.nonce {
+
+		// Look for a receipt again in case one of ours was mined since the last check
+		receipt, _, err := m.findReceipt(ctx, tracked)
+		if err != nil || receipt != nil {
+			return err
+		}
+		m.untrack(tracked)
+		m.emit(EventDropped, tracked, m.getLatestAttempt(tracked), nil, 0)
+		return ErrTransactionOverridden
+
+	}
+
+	// Give up once the transaction has been unknown for too long
+	if unknownSince.IsZero() {
+		*unknownSince = time.Now()
+		return nil
+	}
+	if time.Since(*unknownSince) < m.settings.DropTimeout {
+		return nil
+	}
+	m.untrack(tracked)
+	m.releaseNonce(tracked)
+	m.emit(EventDropped, tracked, m.getLatestAttempt(tracked), nil, 0)
+	return ErrTransactionDropped
+
+}
+
+// Find the receipt for whichever version of a transaction was mined, if any
+func (m *Manager) findReceipt(ctx context.Context, tracked *trackedTransaction) (*types.Receipt, *types.Transaction, error) {
+	attempts := m.getAttempts(tracked)
+	for i := len(attempts) - 1; i >= 0; i-- {
+		receipt, err := m.client.TransactionReceipt(ctx, attempts[i].Hash())
+		if err != nil {
+			if errors.Is(err, ethereum.NotFound) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("Could not get transaction %s receipt: %w", attempts[i].Hash().Hex(), err)
+		}
+		if receipt != nil && receipt.BlockNumber != nil {
+			return receipt, attempts[i], nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// Get a copy of every version of a transaction
+func (m *Manager) getAttempts(tracked *trackedTransaction) []*types.Transaction {
+	tracked.lock.Lock()
+	defer tracked.lock.Unlock()
+	attempts := make([]*types.Transaction, len(tracked.attempts))
+	copy(attempts, tracked.attempts)
+	return attempts
+}
+
+// Get the latest version of a transaction
+func (m *Manager) getLatestAttempt(tracked *trackedTransaction) *types.Transaction {
+	attempts := m.getAttempts(tracked)
+	return attempts[len(attempts)-1]
+}
+
+// Get the version of a transaction with a hash
+func (m *Manager) getAttempt(tracked *trackedTransaction, hash common.Hash) *types.Transaction {
+	for _, attempt := range m.getAttempts(tracked) {
+		if attempt.Hash() == hash {
+			return attempt
+		}
+	}
+	return nil
+}
+
+// Set the receipt of the mined version of a transaction, returning the previous one
+func (m *Manager) setReceipt(tracked *trackedTransaction, receipt *types.Receipt) *types.Receipt {
+	tracked.lock.Lock()
+	defer tracked.lock.Unlock()
+	previous := tracked.receipt
+	tracked.receipt = receipt
+	return previous
+}
@@ -3,17 +3,25 @@ package utils
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/RedDuck-Software/poolsea-go/rocketpool"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// Returned when a transaction can't be found, for example because it was dropped from the mempool
+var ErrTransactionNotFound = errors.New("Transaction not found after 30 seconds.")
+
 // Wait for a transaction to get mined
 func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*types.Receipt, error) {
+	return WaitForTransactionContext(context.Background(), client, hash)
+}
+
+// Wait for a transaction to get mined, stopping early if the provided context is cancelled
+func WaitForTransactionContext(ctx context.Context, client rocketpool.ExecutionClient, hash common.Hash) (*types.Receipt, error) {
 
 	var tx *types.Transaction
 	var err error
@@ -21,13 +29,17 @@ func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*t
 	// Get the transaction from its hash, retrying for 30 sec if it wasn't found
 	for i := 0; i < 30; i++ {
 		if i == 29 {
-			return nil, fmt.Errorf("Transaction not found after 30 seconds.")
+			return nil, ErrTransactionNotFound
 		}
 
-		tx, _, err = client.TransactionByHash(context.Background(), hash)
+		tx, _, err = client.TransactionByHash(ctx, hash)
 		if err != nil {
-			if err.Error() == "not found" {
-				time.Sleep(1 * time.Second)
+			if errors.Is(err, ethereum.NotFound) {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(1 * time.Second):
+				}
 				continue
 			}
 			return nil, err
@@ -37,7 +49,7 @@ func WaitForTransaction(client rocketpool.ExecutionClient, hash common.Hash) (*t
 	}
 
 	// Wait for transaction to be mined
-	txReceipt, err := bind.WaitMined(context.Background(), client, tx)
+	txReceipt, err := bind.WaitMined(ctx, client, tx)
 	if err != nil {
 		return nil, err
 	}